@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile applies the values of the --config file to every flag that was
+// neither passed on the command line nor set through its environment variable,
+// giving the precedence flags > env vars > config file > defaults.
+func loadConfigFile(c *cli.Context) error {
+	path := c.String("config")
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %w", err)
+	}
+	// YAML is a superset of JSON, so this reads both
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	known := make(map[string]bool)
+	for _, f := range c.App.Flags {
+		for _, name := range f.Names() {
+			known[name] = true
+		}
+	}
+	delete(known, "config")
+	delete(known, cli.HelpFlag.Names()[0])
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !known[key] {
+			return fmt.Errorf("unknown key %q in config file %s", key, path)
+		}
+		if c.IsSet(key) {
+			continue
+		}
+		value, err := configValue(values[key])
+		if err != nil {
+			return fmt.Errorf("invalid value for %q in config file %s: %w", key, path, err)
+		}
+		if err := c.Set(key, value); err != nil {
+			return fmt.Errorf("invalid value for %q in config file %s: %w", key, path, err)
+		}
+	}
+	return nil
+}
+
+// configValue renders a config file value the way it would be passed on the command line.
+// Lists become comma-separated values, as for --allow-tools.
+func configValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := configValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("expected a string, number, boolean or list, got %T", v)
+	}
+}
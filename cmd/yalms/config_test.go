@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// runWithConfig runs the real app with its Action replaced by one that captures the resolved flags
+func runWithConfig(t *testing.T, config string, args ...string) (*cli.Context, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "yalms.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var got *cli.Context
+	app := newApp()
+	app.Action = func(c *cli.Context) error {
+		got = c
+		return nil
+	}
+	err := app.Run(append([]string{"yalms", "--config", path}, args...))
+	return got, err
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	t.Setenv("LOGSEQ_MODE", "ontological")
+	c, err := runWithConfig(t, `
+logseq-token: from-file
+logseq-url: http://file:12315
+logseq-mode: general
+timeout: 30s
+batch-concurrency: 2
+debug: true
+deny-tools: [delete_page, delete_pages]
+`, "--logseq-url", "http://flag:12315")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := c.String("logseq-url"); got != "http://flag:12315" {
+		t.Errorf("Expected the flag to win over the config file, got %s", got)
+	}
+	if got := c.String("logseq-mode"); got != "ontological" {
+		t.Errorf("Expected the env var to win over the config file, got %s", got)
+	}
+	if c.String("logseq-token") != "from-file" || c.Duration("timeout") != 30*time.Second || c.Int("batch-concurrency") != 2 || !c.Bool("debug") {
+		t.Errorf("Expected config file values to be applied, got token=%s timeout=%s concurrency=%d debug=%v",
+			c.String("logseq-token"), c.Duration("timeout"), c.Int("batch-concurrency"), c.Bool("debug"))
+	}
+	if got := strings.Join(c.StringSlice("deny-tools"), ","); got != "delete_page,delete_pages" {
+		t.Errorf("Expected the list to be applied, got %s", got)
+	}
+	if got := c.Duration("heavy-timeout"); got != 2*time.Minute {
+		t.Errorf("Expected the default for keys missing from the file, got %s", got)
+	}
+}
+
+func TestLoadConfigFile_JSON(t *testing.T) {
+	c, err := runWithConfig(t, `{"logseq-token": "json-token", "read-only": true}`)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if c.String("logseq-token") != "json-token" || !c.Bool("read-only") {
+		t.Errorf("Expected JSON config values to be applied, got token=%s read-only=%v", c.String("logseq-token"), c.Bool("read-only"))
+	}
+}
+
+func TestLoadConfigFile_Errors(t *testing.T) {
+	if _, err := runWithConfig(t, "logseq-tokn: typo\n"); err == nil || !strings.Contains(err.Error(), `unknown key "logseq-tokn"`) {
+		t.Errorf("Expected an unknown key error, got %v", err)
+	}
+	if _, err := runWithConfig(t, "timeout: soon\n"); err == nil || !strings.Contains(err.Error(), `invalid value for "timeout"`) {
+		t.Errorf("Expected an invalid value error, got %v", err)
+	}
+	if _, err := runWithConfig(t, "logseq-token: [unclosed\n"); err == nil || !strings.Contains(err.Error(), "invalid config file") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+
+	app := newApp()
+	app.Action = func(c *cli.Context) error { return nil }
+	err := app.Run([]string{"yalms", "--config", filepath.Join(t.TempDir(), "missing.yaml")})
+	if err == nil || !strings.Contains(err.Error(), "cannot read config file") {
+		t.Errorf("Expected an unreadable file error, got %v", err)
+	}
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/clstb/yalms/internal/server"
 	"github.com/clstb/yalms/pkg/logseq"
@@ -13,11 +15,18 @@ import (
 	"go.uber.org/zap"
 )
 
-func main() {
-	app := &cli.App{
-		Name:  "yalms",
-		Usage: "Logseq MCP Server",
+// newApp builds the yalms command line application
+func newApp() *cli.App {
+	return &cli.App{
+		Name:   "yalms",
+		Usage:  "Logseq MCP Server",
+		Before: loadConfigFile,
 		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "YAML or JSON file with flag values keyed by flag name (e.g. logseq-token: ...); flags and env vars take precedence",
+				EnvVars: []string{"LOGSEQ_CONFIG"},
+			},
 			&cli.StringFlag{
 				Name:    "logseq-url",
 				Value:   "http://127.0.0.1:12315",
@@ -33,9 +42,118 @@ func main() {
 			&cli.StringFlag{
 				Name:    "logseq-mode",
 				Value:   "general",
-				Usage:   "Logseq Mode (general or ontological)",
+				Usage:   "Logseq Mode (general, ontological or journal)",
 				EnvVars: []string{"LOGSEQ_MODE"},
 			},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				Value:   logseq.DefaultTimeout,
+				Usage:   "Deadline of a single Logseq API call",
+				EnvVars: []string{"LOGSEQ_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "heavy-timeout",
+				Value:   logseq.DefaultHeavyTimeout,
+				Usage:   "Deadline of graph-wide Logseq API calls (page lists, graph scans and exports)",
+				EnvVars: []string{"LOGSEQ_HEAVY_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "max-query-timeout",
+				Value:   server.DefaultMaxQueryTimeout,
+				Usage:   "Upper bound of the per-call timeout_seconds the query tool accepts",
+				EnvVars: []string{"LOGSEQ_MAX_QUERY_TIMEOUT"},
+			},
+			&cli.DurationFlag{
+				Name:    "page-cache-ttl",
+				Value:   0,
+				Usage:   "Cache GetPage lookups for this long (0 disables the cache)",
+				EnvVars: []string{"LOGSEQ_PAGE_CACHE_TTL"},
+			},
+			&cli.StringFlag{
+				Name:    "timezone",
+				Usage:   "IANA timezone (e.g. Europe/Berlin) used to determine today's journal page (defaults to the system local zone)",
+				EnvVars: []string{"LOGSEQ_TIMEZONE"},
+			},
+			&cli.IntFlag{
+				Name:    "batch-concurrency",
+				Value:   server.DefaultBatchConcurrency,
+				Usage:   "Maximum number of items batch tools process in parallel (1 disables concurrency)",
+				EnvVars: []string{"LOGSEQ_BATCH_CONCURRENCY"},
+			},
+			&cli.IntFlag{
+				Name:    "max-response-bytes",
+				Value:   0,
+				Usage:   "Fail Logseq API calls whose response exceeds this many bytes instead of buffering them (0 disables the limit)",
+				EnvVars: []string{"LOGSEQ_MAX_RESPONSE_BYTES"},
+			},
+			&cli.DurationFlag{
+				Name:    "watch-interval",
+				Value:   0,
+				Usage:   "Poll the graph for changes this often and notify clients (0 disables polling)",
+				EnvVars: []string{"LOGSEQ_WATCH_INTERVAL"},
+			},
+			&cli.BoolFlag{
+				Name:    "insecure-skip-verify",
+				Usage:   "Do not verify the TLS certificate of an HTTPS Logseq API (insecure, for self-signed setups)",
+				EnvVars: []string{"LOGSEQ_INSECURE_SKIP_VERIFY"},
+			},
+			&cli.StringFlag{
+				Name:    "ca-file",
+				Usage:   "PEM file with additional CA certificates to trust for an HTTPS Logseq API",
+				EnvVars: []string{"LOGSEQ_CA_FILE"},
+			},
+			&cli.StringFlag{
+				Name:    "key-style",
+				Value:   string(server.KeyStyleSnake),
+				Usage:   "Casing property keys are normalized to in ontological mode (snake, kebab, camel or none)",
+				EnvVars: []string{"LOGSEQ_KEY_STYLE"},
+			},
+			&cli.StringFlag{
+				Name:    "format",
+				Value:   logseq.FormatMarkdown,
+				Usage:   "Content format of the graph (markdown or org), used for new pages and to parse blocks that do not report a format. Content written by tools is not converted",
+				EnvVars: []string{"LOGSEQ_FORMAT"},
+			},
+			&cli.BoolFlag{
+				Name:    "idempotent-append",
+				Usage:   "Skip appending a block identical to the page's last block, so retried appends are not duplicated",
+				EnvVars: []string{"LOGSEQ_IDEMPOTENT_APPEND"},
+			},
+			&cli.BoolFlag{
+				Name:    "no-autocreate-links",
+				Usage:   "Do not create missing [[linked]] pages when writing content; log them as a warning instead",
+				EnvVars: []string{"LOGSEQ_NO_AUTOCREATE_LINKS"},
+			},
+			&cli.BoolFlag{
+				Name:    "no-alias-resolution",
+				Usage:   "Do not resolve page names through alias:: properties in read tools when no page has the name",
+				EnvVars: []string{"LOGSEQ_NO_ALIAS_RESOLUTION"},
+			},
+			&cli.BoolFlag{
+				Name:    "coerce-property-types",
+				Usage:   "Store property values given to tools, such as \"1937\", \"4.5\" or \"true\", as numbers/booleans instead of strings",
+				EnvVars: []string{"LOGSEQ_COERCE_PROPERTY_TYPES"},
+			},
+			&cli.BoolFlag{
+				Name:    "read-only",
+				Usage:   "Only expose tools that do not modify the graph",
+				EnvVars: []string{"LOGSEQ_READ_ONLY"},
+			},
+			&cli.BoolFlag{
+				Name:    "enable-raw-api",
+				Usage:   "Expose the raw_api tool, which calls arbitrary Logseq API methods (unvalidated; see README)",
+				EnvVars: []string{"LOGSEQ_ENABLE_RAW_API"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "allow-tools",
+				Usage:   "Comma-separated list of tools to expose (default: all tools of the selected mode)",
+				EnvVars: []string{"LOGSEQ_ALLOW_TOOLS"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "deny-tools",
+				Usage:   "Comma-separated list of tools to hide, e.g. delete_page,delete_pages (wins over --allow-tools)",
+				EnvVars: []string{"LOGSEQ_DENY_TOOLS"},
+			},
 			&cli.BoolFlag{
 				Name:  "debug",
 				Usage: "Enable debug logging",
@@ -59,15 +177,84 @@ func main() {
 
 			apiURL := c.String("logseq-url")
 			token := c.String("logseq-token")
-			mode := server.LogseqMode(c.String("logseq-mode"))
+			mode, err := server.ParseLogseqMode(c.String("logseq-mode"))
+			if err != nil {
+				return err
+			}
 
 			logger.Info("Starting yalms", zap.String("url", apiURL), zap.String("mode", string(mode)))
 
 			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 			defer stop()
 
-			client := logseq.NewClient(apiURL, token, logger)
-			mcpServer := server.NewMCPServer(client, logger, mode)
+			opts := []logseq.ClientOption{
+				logseq.WithUserAgent("yalms/" + server.Version),
+				logseq.WithConcurrency(c.Int("batch-concurrency")),
+			}
+			if tz := c.String("timezone"); tz != "" {
+				loc, err := time.LoadLocation(tz)
+				if err != nil {
+					return fmt.Errorf("invalid timezone %q: %w", tz, err)
+				}
+				opts = append(opts, logseq.WithLocation(loc))
+			}
+			if n := c.Int("max-response-bytes"); n > 0 {
+				opts = append(opts, logseq.WithMaxResponseBytes(n))
+			}
+			if c.Bool("insecure-skip-verify") || c.String("ca-file") != "" {
+				tlsConfig, err := logseq.NewTLSConfig(c.Bool("insecure-skip-verify"), c.String("ca-file"))
+				if err != nil {
+					return err
+				}
+				if tlsConfig.InsecureSkipVerify {
+					logger.Warn("TLS certificate verification is disabled; the connection to Logseq is vulnerable to interception")
+				}
+				opts = append(opts, logseq.WithTLSConfig(tlsConfig))
+			}
+			format := c.String("format")
+			if !logseq.IsBlockFormat(format) {
+				return fmt.Errorf("invalid format %q: must be %q or %q", format, logseq.FormatMarkdown, logseq.FormatOrg)
+			}
+			opts = append(opts, logseq.WithDefaultFormat(format))
+			if c.Bool("idempotent-append") {
+				opts = append(opts, logseq.WithIdempotentAppend())
+			}
+			if c.Bool("no-autocreate-links") {
+				opts = append(opts, logseq.WithoutLinkAutoCreate())
+			}
+			keyStyle := c.String("key-style")
+			if !server.IsKeyStyle(keyStyle) {
+				return fmt.Errorf("invalid key style %q: must be snake, kebab, camel or none", keyStyle)
+			}
+			if c.Bool("no-alias-resolution") {
+				opts = append(opts, logseq.WithoutAliasResolution())
+			}
+			opts = append(opts, logseq.WithTimeout(c.Duration("timeout")), logseq.WithHeavyTimeout(c.Duration("heavy-timeout")))
+			if ttl := c.Duration("page-cache-ttl"); ttl > 0 {
+				opts = append(opts, logseq.WithPageCache(ttl))
+			}
+
+			client := logseq.NewClient(apiURL, token, logger, opts...)
+			serverOpts := []server.ServerOption{
+				server.WithBatchConcurrency(c.Int("batch-concurrency")),
+				server.WithAllowedTools(c.StringSlice("allow-tools")),
+				server.WithDeniedTools(c.StringSlice("deny-tools")),
+				server.WithWatchInterval(c.Duration("watch-interval")),
+				server.WithKeyStyle(server.KeyStyle(keyStyle)),
+				server.WithMaxQueryTimeout(c.Duration("max-query-timeout")),
+			}
+			if c.Bool("read-only") {
+				serverOpts = append(serverOpts, server.WithReadOnly())
+			}
+			if c.Bool("coerce-property-types") {
+				serverOpts = append(serverOpts, server.WithPropertyTypeCoercion())
+			}
+			if c.Bool("enable-raw-api") {
+				logger.Warn("raw_api is enabled: clients can call any Logseq API method")
+				serverOpts = append(serverOpts, server.WithRawAPI())
+			}
+			mcpServer := server.NewMCPServer(client, logger, mode, serverOpts...)
+			go mcpServer.Watch(ctx)
 
 			errChan := make(chan error, 1)
 			go func() {
@@ -87,8 +274,10 @@ func main() {
 			return nil
 		},
 	}
+}
 
-	if err := app.Run(os.Args); err != nil {
+func main() {
+	if err := newApp().Run(os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
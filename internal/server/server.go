@@ -2,9 +2,13 @@ package server
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/clstb/yalms/pkg/logseq"
@@ -13,41 +17,375 @@ import (
 	"go.uber.org/zap"
 )
 
+// Version is the yalms release version, reported as the MCP server version and in the Logseq API User-Agent
+const Version = "0.1.0"
+
 type LogseqMode string
 
 const (
 	ModeGeneral     LogseqMode = "general"
 	ModeOntological LogseqMode = "ontological"
+	ModeJournal     LogseqMode = "journal"
+)
+
+// validModes lists every supported mode, in the order error messages name them
+var validModes = []LogseqMode{ModeGeneral, ModeOntological, ModeJournal}
+
+// IsValid reports whether m is one of the supported server modes
+func (m LogseqMode) IsValid() bool {
+	for _, mode := range validModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLogseqMode normalizes case and surrounding whitespace of mode and
+// validates it against the supported modes
+func ParseLogseqMode(mode string) (LogseqMode, error) {
+	m := LogseqMode(strings.ToLower(strings.TrimSpace(mode)))
+	if !m.IsValid() {
+		names := make([]string, len(validModes))
+		for i, mode := range validModes {
+			names[i] = string(mode)
+		}
+		return "", fmt.Errorf("invalid logseq mode %q: must be one of %s", mode, strings.Join(names, ", "))
+	}
+	return m, nil
+}
+
+// journalTools is the tool set journal mode exposes: daily-note capture and
+// reading, without page management
+var journalTools = map[string]bool{
+	"get_daily_journal":   true,
+	"append_to_journal":   true,
+	"create_journal_page": true,
+	"append_block":        true,
+	"query":               true,
+}
+
+// KeyStyle is the casing ontological mode normalizes property keys to
+type KeyStyle string
+
+const (
+	KeyStyleSnake KeyStyle = "snake" // first_name (default)
+	KeyStyleKebab KeyStyle = "kebab" // first-name
+	KeyStyleCamel KeyStyle = "camel" // firstName
+	KeyStyleNone  KeyStyle = "none"  // keys are kept as given
 )
 
+// IsKeyStyle reports whether style is one of the supported key styles
+func IsKeyStyle(style string) bool {
+	switch KeyStyle(style) {
+	case KeyStyleSnake, KeyStyleKebab, KeyStyleCamel, KeyStyleNone:
+		return true
+	}
+	return false
+}
+
+// DefaultBatchConcurrency is the number of items batch tools process in parallel
+const DefaultBatchConcurrency = 4
+
+// DescribeClassSampleSize caps the number of Instances describe_class inspects
+const DescribeClassSampleSize = 50
+
+// DefaultRecentPagesLimit is the number of pages recent_pages returns when no limit is given
+const DefaultRecentPagesLimit = 20
+
+// DefaultSearchLimit is the number of hits search_all returns when no limit is given
+const DefaultSearchLimit = 50
+
+// DefaultNamespaceLimit is the page size read_namespace uses when no limit is given
+const DefaultNamespaceLimit = 100
+
+// DefaultValidationSampleSize is the number of samples per section validate_graph returns when no sample_limit is given
+const DefaultValidationSampleSize = 20
+
+// MaxValidationSampleSize caps validate_graph's sample_limit so the report stays small
+const MaxValidationSampleSize = 200
+
+// DefaultMaxQueryTimeout caps the timeout_seconds a query tool call may ask for
+const DefaultMaxQueryTimeout = 5 * time.Minute
+
 type MCPServer struct {
 	server *server.MCPServer
 	client *logseq.Client
 	logger *zap.Logger
 	mode   LogseqMode
+
+	batchConcurrency int
+
+	allowTools map[string]bool // When non-empty, only these tools are registered
+	denyTools  map[string]bool // Never registered; wins over allowTools
+	knownTools map[string]bool // Every tool name offered in this mode, permitted or not
+
+	watchInterval time.Duration // 0 disables change polling
+
+	keyStyle KeyStyle // Casing of property keys in ontological mode
+
+	readOnly bool // Only tools annotated as read-only are registered
+
+	rawAPI bool // Register the raw_api escape hatch
+
+	coercePropertyTypes bool // Store user-supplied property values that look like bools/numbers as such
+
+	maxQueryTimeout time.Duration // Upper bound of the query tool's timeout_seconds
+}
+
+// ServerOption configures optional MCPServer behavior
+type ServerOption func(*MCPServer)
+
+// WithBatchConcurrency bounds how many items batch tools process in parallel. Values below 1 are treated as 1.
+func WithBatchConcurrency(n int) ServerOption {
+	return func(s *MCPServer) {
+		if n < 1 {
+			n = 1
+		}
+		s.batchConcurrency = n
+	}
+}
+
+// WithMaxQueryTimeout caps the per-call timeout_seconds of the query tool. Values <= 0 keep the default.
+func WithMaxQueryTimeout(d time.Duration) ServerOption {
+	return func(s *MCPServer) {
+		if d > 0 {
+			s.maxQueryTimeout = d
+		}
+	}
+}
+
+// WithAllowedTools restricts the registered tools to the given names
+func WithAllowedTools(names []string) ServerOption {
+	return func(s *MCPServer) {
+		s.allowTools = toolSet(names)
+	}
+}
+
+// WithDeniedTools prevents the given tools from being registered, even if they are also allowed
+func WithDeniedTools(names []string) ServerOption {
+	return func(s *MCPServer) {
+		s.denyTools = toolSet(names)
+	}
+}
+
+// WithWatchInterval makes Watch poll the graph for changes at the given interval. 0 disables polling.
+func WithWatchInterval(d time.Duration) ServerOption {
+	return func(s *MCPServer) {
+		s.watchInterval = d
+	}
+}
+
+// WithKeyStyle sets the casing ontological mode normalizes property keys to. The default is snake_case.
+func WithKeyStyle(style KeyStyle) ServerOption {
+	return func(s *MCPServer) {
+		s.keyStyle = style
+	}
+}
+
+// WithReadOnly registers only the tools that do not modify the graph
+func WithReadOnly() ServerOption {
+	return func(s *MCPServer) {
+		s.readOnly = true
+	}
+}
+
+// WithRawAPI registers the raw_api tool, which calls arbitrary Logseq API methods.
+// Combined with WithReadOnly, only the known read methods of readMethodPrefixes and readMethods are allowed.
+func WithRawAPI() ServerOption {
+	return func(s *MCPServer) {
+		s.rawAPI = true
+	}
+}
+
+// WithPropertyTypeCoercion makes the tools store property values supplied by the client that parse as a bool,
+// integer or decimal as that type (see logseq.CoercePropertyValue), so numeric queries match them.
+// Properties the server writes itself, such as aliases and tags, are left alone.
+func WithPropertyTypeCoercion() ServerOption {
+	return func(s *MCPServer) {
+		s.coercePropertyTypes = true
+	}
+}
+
+// readMethodPrefixes and readMethods are the Logseq API methods raw_api allows under --read-only.
+// It is an allowlist because a denylist can never be complete: an unlisted method may change
+// the graph or the app (navigation, git, external commands), so it is refused.
+var readMethodPrefixes = []string{
+	"logseq.Editor.get",
+	"logseq.App.get",
+	"logseq.Assets.list",
+}
+
+var readMethods = []string{
+	"logseq.DB.q",
+	"logseq.DB.datascriptQuery",
+	"logseq.Editor.checkEditing",
+	"logseq.App.checkCurrentIsDbGraph",
+}
+
+// isReadMethod reports whether method is a known read method, ignoring case
+func isReadMethod(method string) bool {
+	method = strings.ToLower(method)
+	for _, m := range readMethods {
+		if method == strings.ToLower(m) {
+			return true
+		}
+	}
+	for _, prefix := range readMethodPrefixes {
+		if strings.HasPrefix(method, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func toolSet(names []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+	return set
 }
 
-func NewMCPServer(client *logseq.Client, logger *zap.Logger, mode LogseqMode) *MCPServer {
-	s := server.NewMCPServer("yalms", "0.1.0")
+func NewMCPServer(client *logseq.Client, logger *zap.Logger, mode LogseqMode, opts ...ServerOption) *MCPServer {
+	if parsed, err := ParseLogseqMode(string(mode)); err != nil {
+		logger.Error("Falling back to general mode", zap.Error(err))
+		mode = ModeGeneral
+	} else {
+		mode = parsed
+	}
+
+	s := server.NewMCPServer("yalms", Version)
 	ms := &MCPServer{
 		server: s,
 		client: client,
 		logger: logger,
 		mode:   mode,
+
+		batchConcurrency: DefaultBatchConcurrency,
+		maxQueryTimeout:  DefaultMaxQueryTimeout,
+		knownTools:       make(map[string]bool),
+		keyStyle:         KeyStyleSnake,
+	}
+	for _, opt := range opts {
+		opt(ms)
 	}
 
 	ms.registerTools()
+	ms.warnUnknownTools()
 	return ms
 }
 
+// addTool registers a tool unless the allow/deny filters exclude it
+func (s *MCPServer) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if s.mode == ModeJournal && !journalTools[tool.Name] {
+		return
+	}
+	s.knownTools[tool.Name] = true
+	if s.denyTools[tool.Name] {
+		return
+	}
+	if len(s.allowTools) > 0 && !s.allowTools[tool.Name] {
+		return
+	}
+	if s.readOnly && (tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint) {
+		return
+	}
+	s.server.AddTool(tool, handler)
+}
+
+func (s *MCPServer) warnUnknownTools() {
+	for _, filter := range []map[string]bool{s.allowTools, s.denyTools} {
+		for name := range filter {
+			if !s.knownTools[name] {
+				s.logger.Warn("Unknown tool in tool filter", zap.String("tool", name), zap.String("mode", string(s.mode)))
+			}
+		}
+	}
+}
+
+// batchItemError identifies a failed item of a batch tool by its position and identifier
+type batchItemError struct {
+	Index      int    `json:"index"`
+	Identifier string `json:"identifier"`
+	Error      string `json:"error"`
+}
+
+// batchFailureResult reports a partially failed batch as an error result carrying a
+// {succeeded, failed} JSON payload, so clients can retry exactly the failed items
+func batchFailureResult(summary string, succeeded any, failed []batchItemError) *mcp.CallToolResult {
+	payload, _ := json.MarshalIndent(map[string]any{
+		"succeeded": succeeded,
+		"failed":    failed,
+	}, "", "  ")
+	return mcp.NewToolResultError(fmt.Sprintf("%s\n%s", summary, string(payload)))
+}
+
 func (s *MCPServer) Serve() error {
 	return server.ServeStdio(s.server)
 }
 
+// GraphResourceURI identifies the Logseq graph in resource update notifications
+const GraphResourceURI = "logseq://graph"
+
+// Watch polls the graph's latest update time until ctx is done and sends a
+// notifications/resources/updated message to all clients whenever it changes.
+// Logseq's HTTP API has no push mechanism, so this is polling rather than a subscription.
+// It returns immediately unless WithWatchInterval was set.
+func (s *MCPServer) Watch(ctx context.Context) {
+	if s.watchInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last, err := s.client.GetLatestUpdatedAt()
+	known := err == nil
+	if err != nil {
+		s.logger.Warn("Watch could not read the graph's latest update", zap.Error(err))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		latest, err := s.client.GetLatestUpdatedAt()
+		if err != nil {
+			s.logger.Warn("Watch could not read the graph's latest update", zap.Error(err))
+			continue
+		}
+		if known && latest != last {
+			s.logger.Debug("Graph changed", zap.Int64("updated_at", latest))
+			s.server.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{
+				"uri":        GraphResourceURI,
+				"updated_at": latest,
+			})
+		}
+		last, known = latest, true
+	}
+}
+
 func (s *MCPServer) GetServer() *server.MCPServer {
 	return s.server
 }
 
+func (s *MCPServer) HandleResolveAssetPath(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleResolveAssetPath(ctx, req)
+}
+
+func (s *MCPServer) HandleServerInfo(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleServerInfo(ctx, req)
+}
+
+func (s *MCPServer) HandleGetAppConfig(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetAppConfig(ctx, req)
+}
+
 func (s *MCPServer) HandleQuery(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleQuery(ctx, req)
 }
@@ -56,6 +394,10 @@ func (s *MCPServer) HandleListNamespaces(ctx context.Context, req mcp.CallToolRe
 	return s.handleListNamespaces(ctx, req)
 }
 
+func (s *MCPServer) HandleAppendToJournal(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleAppendToJournal(ctx, req)
+}
+
 func (s *MCPServer) HandleGetDailyJournal(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleGetDailyJournal(ctx, req)
 }
@@ -68,6 +410,186 @@ func (s *MCPServer) HandleReadPage(ctx context.Context, req mcp.CallToolRequest)
 	return s.handleReadPage(ctx, req)
 }
 
+func (s *MCPServer) HandleGetEntityByID(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetEntityByID(ctx, req)
+}
+
+func (s *MCPServer) HandleUpsertEntity(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleUpsertEntity(ctx, req)
+}
+
+func (s *MCPServer) HandleFindBlocksByProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleFindBlocksByProperty(ctx, req)
+}
+
+func (s *MCPServer) HandleCollapsePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleCollapsePage(ctx, req)
+}
+
+func (s *MCPServer) HandleCreatePageTree(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleCreatePageTree(ctx, req)
+}
+
+func (s *MCPServer) HandleAppendToBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleAppendToBlock(ctx, req)
+}
+
+func (s *MCPServer) HandlePrependToBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handlePrependToBlock(ctx, req)
+}
+
+func (s *MCPServer) HandleGetTags(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetTags(ctx, req)
+}
+
+func (s *MCPServer) HandlePageExists(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handlePageExists(ctx, req)
+}
+
+func (s *MCPServer) HandleGetBlockFormat(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetBlockFormat(ctx, req)
+}
+
+func (s *MCPServer) HandleSearchAll(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleSearchAll(ctx, req)
+}
+
+func (s *MCPServer) HandleCount(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleCount(ctx, req)
+}
+
+func (s *MCPServer) HandleExportGraph(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleExportGraph(ctx, req)
+}
+
+func (s *MCPServer) HandleImportGraph(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleImportGraph(ctx, req)
+}
+
+func (s *MCPServer) HandleGetBlockContext(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetBlockContext(ctx, req)
+}
+
+func (s *MCPServer) HandlePreviewLinks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handlePreviewLinks(ctx, req)
+}
+
+func (s *MCPServer) HandleListProperties(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleListProperties(ctx, req)
+}
+
+func (s *MCPServer) HandleReplaceInPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleReplaceInPage(ctx, req)
+}
+
+func (s *MCPServer) HandleReplaceEverywhere(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleReplaceEverywhere(ctx, req)
+}
+
+func (s *MCPServer) HandleAddAlias(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleAddAlias(ctx, req)
+}
+
+func (s *MCPServer) HandleRemoveAlias(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleRemoveAlias(ctx, req)
+}
+
+func (s *MCPServer) HandleFindDuplicatePages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleFindDuplicatePages(ctx, req)
+}
+
+func (s *MCPServer) HandleFindOrphans(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleFindOrphans(ctx, req)
+}
+
+func (s *MCPServer) HandleInsertBlockRef(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleInsertBlockRef(ctx, req)
+}
+
+func (s *MCPServer) HandleInsertEmbed(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleInsertEmbed(ctx, req)
+}
+
+func (s *MCPServer) HandleRawAPI(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleRawAPI(ctx, req)
+}
+
+func (s *MCPServer) HandleGetCurrentPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetCurrentPage(ctx, req)
+}
+
+func (s *MCPServer) HandleGetCurrentBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetCurrentBlock(ctx, req)
+}
+
+func (s *MCPServer) HandleOpenPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleOpenPage(ctx, req)
+}
+
+func (s *MCPServer) HandleScrollToBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleScrollToBlock(ctx, req)
+}
+
+func (s *MCPServer) HandleGetPageAssets(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetPageAssets(ctx, req)
+}
+
+func (s *MCPServer) HandleListTasks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleListTasks(ctx, req)
+}
+
+func (s *MCPServer) HandleToggleTask(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleToggleTask(ctx, req)
+}
+
+func (s *MCPServer) HandleSetSchedule(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleSetSchedule(ctx, req)
+}
+
+func (s *MCPServer) HandleAgenda(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleAgenda(ctx, req)
+}
+
+func (s *MCPServer) HandleGetParentNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetParentNamespace(ctx, req)
+}
+
+func (s *MCPServer) HandleGetSiblingPages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetSiblingPages(ctx, req)
+}
+
+func (s *MCPServer) HandleTagNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleTagNamespace(ctx, req)
+}
+
+func (s *MCPServer) HandleCreateJournalPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleCreateJournalPage(ctx, req)
+}
+
+func (s *MCPServer) HandleValidateGraph(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleValidateGraph(ctx, req)
+}
+
+func (s *MCPServer) HandleRecentPages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleRecentPages(ctx, req)
+}
+
+func (s *MCPServer) HandleReadPages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleReadPages(ctx, req)
+}
+
+func (s *MCPServer) HandleGetPageOutline(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetPageOutline(ctx, req)
+}
+
+func (s *MCPServer) HandleGetPageSize(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetPageSize(ctx, req)
+}
+
+func (s *MCPServer) HandleGetPageProperties(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetPageProperties(ctx, req)
+}
+
 func (s *MCPServer) HandleUpdatePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleUpdatePage(ctx, req)
 }
@@ -80,6 +602,14 @@ func (s *MCPServer) HandleCreatePages(ctx context.Context, req mcp.CallToolReque
 	return s.handleCreatePages(ctx, req)
 }
 
+func (s *MCPServer) HandleUpdateEntities(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleUpdateEntities(ctx, req)
+}
+
+func (s *MCPServer) HandleImportCSV(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleImportCSV(ctx, req)
+}
+
 func (s *MCPServer) HandleDeletePages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleDeletePages(ctx, req)
 }
@@ -92,6 +622,14 @@ func (s *MCPServer) HandleReadNamespace(ctx context.Context, req mcp.CallToolReq
 	return s.handleReadNamespace(ctx, req)
 }
 
+func (s *MCPServer) HandleDescribeClass(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleDescribeClass(ctx, req)
+}
+
+func (s *MCPServer) HandleExportNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleExportNamespace(ctx, req)
+}
+
 func (s *MCPServer) HandleCreateNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleCreateNamespace(ctx, req)
 }
@@ -100,10 +638,34 @@ func (s *MCPServer) HandleReadBlock(ctx context.Context, req mcp.CallToolRequest
 	return s.handleReadBlock(ctx, req)
 }
 
+func (s *MCPServer) HandleApplyTemplate(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleApplyTemplate(ctx, req)
+}
+
+func (s *MCPServer) HandleReorderBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleReorderBlock(ctx, req)
+}
+
+func (s *MCPServer) HandleMoveBlocks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleMoveBlocks(ctx, req)
+}
+
+func (s *MCPServer) HandleReadBlocks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleReadBlocks(ctx, req)
+}
+
+func (s *MCPServer) HandleGetChildren(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleGetChildren(ctx, req)
+}
+
 func (s *MCPServer) HandleUpdateBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleUpdateBlock(ctx, req)
 }
 
+func (s *MCPServer) HandleSetTaskState(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleSetTaskState(ctx, req)
+}
+
 func (s *MCPServer) HandleDeleteBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleDeleteBlock(ctx, req)
 }
@@ -120,18 +682,42 @@ func (s *MCPServer) HandleAddTag(ctx context.Context, req mcp.CallToolRequest) (
 	return s.handleAddTag(ctx, req)
 }
 
+func (s *MCPServer) HandleAddTags(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleAddTags(ctx, req)
+}
+
 func (s *MCPServer) HandleRemoveTag(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleRemoveTag(ctx, req)
 }
 
+func (s *MCPServer) HandleRemoveTags(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleRemoveTags(ctx, req)
+}
+
 func (s *MCPServer) HandleRemoveProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleRemoveProperty(ctx, req)
 }
 
+func (s *MCPServer) HandleClearProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleClearProperty(ctx, req)
+}
+
+func (s *MCPServer) HandleRenameProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleRenameProperty(ctx, req)
+}
+
+func (s *MCPServer) HandleRenamePropertyEverywhere(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleRenamePropertyEverywhere(ctx, req)
+}
+
 func (s *MCPServer) HandleUpsertProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleUpsertProperty(ctx, req)
 }
 
+func (s *MCPServer) HandleAddRelationship(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleAddRelationship(ctx, req)
+}
+
 func (s *MCPServer) HandleCreateEntity(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return s.handleCreateEntity(ctx, req)
 }
@@ -144,139 +730,399 @@ func (s *MCPServer) HandleCreateBlockTree(ctx context.Context, req mcp.CallToolR
 	return s.handleCreateBlockTree(ctx, req)
 }
 
+func (s *MCPServer) HandleFindBrokenRefs(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleFindBrokenRefs(ctx, req)
+}
+
 func ToSnakeCase(s string) string {
 	return toSnakeCase(s)
 }
 
+func NormalizeKey(style KeyStyle, key string) string {
+	return normalizeKey(style, key)
+}
+
 func (s *MCPServer) registerTools() {
+	kc := s.keyCase()
+
 	// Graph Tools
-	s.server.AddTool(mcp.NewTool("read_graph_info",
+	s.addTool(mcp.NewTool("read_graph_info",
 		mcp.WithDescription("Get information about the current graph"),
+		mcp.WithReadOnlyHintAnnotation(true),
 	), s.handleReadGraphInfo)
 
-	s.server.AddTool(mcp.NewTool("query",
+	s.addTool(mcp.NewTool("resolve_asset_path",
+		mcp.WithDescription("Resolve an asset referenced in a block (e.g. '![img](../assets/x.png)' or 'x.png') to its absolute path on disk inside the graph directory."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("path", mcp.Required(), mcp.Description("The asset link, relative path or filename")),
+	), s.handleResolveAssetPath)
+
+	s.addTool(mcp.NewTool("server_info",
+		mcp.WithDescription("Describe this yalms server: name, version, mode (general or ontological, which determines the available tools), transport and Logseq API URL."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleServerInfo)
+
+	s.addTool(mcp.NewTool("get_app_config",
+		mcp.WithDescription("Get the Logseq user settings (e.g. preferred date format, workflow TODO/DOING vs NOW/LATER, block format) as JSON."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleGetAppConfig)
+
+	s.addTool(mcp.NewTool("query",
 		mcp.WithDescription("Execute an advanced Datalog query against the Logseq database. Recommended for complex data retrieval and filtering. Examples: '[:find (pull ?p [*]) :where [?p :block/name]]' (all pages), '[:find (pull ?b [*]) :where [?b :block/content ?c] [(clojure.string/includes? ?c \"term\")]]' (blocks containing 'term')."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("query", mcp.Required(), mcp.Description("The Datalog query string (e.g., '[:find (pull ?b [*]) :where ...]')")),
+		mcp.WithNumber("timeout_seconds", mcp.Description(fmt.Sprintf("Optional deadline for a query known to be slow, capped at %d seconds. Values <= 0 use the server's regular timeout.", int(s.maxQueryTimeout.Seconds())))),
 	), s.handleQuery)
 
-	s.server.AddTool(mcp.NewTool("list_namespaces",
+	s.addTool(mcp.NewTool("count",
+		mcp.WithDescription("Count matching pages/Instances without fetching them, to decide whether a full query is worth it. Pass exactly one of 'tag', 'property' (optionally with 'value') or a raw aggregate 'query' such as '[:find (count ?p) :where [?p :block/name]]'. Returns a single integer."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("tag", mcp.Description("Count pages tagged with this tag/Class")),
+		mcp.WithString("property", mcp.Description("Count pages having this property (normalized to "+kc+" in ontological mode)")),
+		mcp.WithString("value", mcp.Description("Only count pages whose 'property' equals this value")),
+		mcp.WithString("query", mcp.Description("A Datalog query whose :find clause is a single aggregate like (count ?x)")),
+	), s.handleCount)
+
+	s.addTool(mcp.NewTool("list_properties",
+		mcp.WithDescription("List every property key used in the graph with the number of pages and blocks using it, most used first. Use this to reuse existing attribute names instead of inventing variants. Keys are shown as stored ("+kc+" for keys written in ontological mode)."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleListProperties)
+
+	s.addTool(mcp.NewTool("list_namespaces",
 		mcp.WithDescription("List all existing namespaces/Classes in the graph."),
+		mcp.WithReadOnlyHintAnnotation(true),
 	), s.handleListNamespaces)
 
-	s.server.AddTool(mcp.NewTool("get_daily_journal",
+	s.addTool(mcp.NewTool("get_daily_journal",
 		mcp.WithDescription("Retrieve today's journal page details."),
+		mcp.WithReadOnlyHintAnnotation(true),
 	), s.handleGetDailyJournal)
 
+	s.addTool(mcp.NewTool("get_current_page",
+		mcp.WithDescription("Get the page/entity the user currently has open in Logseq. Use it for requests about 'this page' instead of asking for a name."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleGetCurrentPage)
+
+	s.addTool(mcp.NewTool("get_current_block",
+		mcp.WithDescription("Get the block/entry the user is currently editing in Logseq, including its content and properties. Use it for requests about 'this block'."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleGetCurrentBlock)
+
+	// Navigation only changes the UI, but under --read-only it is a no-op so the tools stay listed
+	s.addTool(mcp.NewTool("open_page",
+		mcp.WithDescription("Open a page/entity in the Logseq UI, e.g. to show the user what was just created or found."),
+		mcp.WithReadOnlyHintAnnotation(s.readOnly),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The name or UUID of the page")),
+	), s.handleOpenPage)
+
+	s.addTool(mcp.NewTool("scroll_to_block",
+		mcp.WithDescription("Open the page of a block/entry in the Logseq UI and scroll to the block."),
+		mcp.WithReadOnlyHintAnnotation(s.readOnly),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry")),
+	), s.handleScrollToBlock)
+
+	s.addTool(mcp.NewTool("append_to_journal",
+		mcp.WithDescription("Append a block to the journal page of a date (today by default), creating the page if needed. The page name follows the graph's preferred date format."),
+		mcp.WithString("content", mcp.Required(), mcp.Description("The content of the block")),
+		mcp.WithString("date", mcp.Description("The journal date as YYYY-MM-DD. Defaults to today.")),
+	), s.handleAppendToJournal)
+
+	s.addTool(mcp.NewTool("create_journal_page",
+		mcp.WithDescription("Create the journal page of a date (today by default) without adding content. The page name follows the graph's preferred date format. An existing journal page is returned unchanged."),
+		mcp.WithString("date", mcp.Description("The journal date as YYYY-MM-DD. Defaults to today.")),
+	), s.handleCreateJournalPage)
+
 	// Page/Entity Tools
 	if s.mode == ModeOntological {
-		s.server.AddTool(mcp.NewTool("read_entity",
+		s.addTool(mcp.NewTool("read_entity",
 			mcp.WithDescription("Retrieve structured data for an Instance (Particular). Use this to inspect record Attributes (data) and Relationships (links)."),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the Instance (Particular)")),
 		), s.handleReadPage)
 
-		s.server.AddTool(mcp.NewTool("update_entity",
-			mcp.WithDescription("Modify Instance Attributes or Relationships. Ensures data integrity by normalizing property keys to snake_case."),
+		s.addTool(mcp.NewTool("get_entity_attributes",
+			mcp.WithDescription("Retrieve only the Attributes and Relationships of an Instance as a JSON object, without page metadata."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the Instance (Particular)")),
+			mcp.WithBoolean("display", mcp.Description("Convert snake_case keys back to a readable display form (e.g. 'published_date' -> 'published date')")),
+		), s.handleGetPageProperties)
+
+		s.addTool(mcp.NewTool("update_entity",
+			mcp.WithDescription("Modify Instance Attributes or Relationships. Ensures data integrity by normalizing property keys to "+kc+"."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the Instance")),
 			mcp.WithString("properties", mcp.Required(), mcp.Description("JSON string of updated Attributes (data) or Relationships (page links)")),
 		), s.handleUpdatePage)
 
-		s.server.AddTool(mcp.NewTool("delete_entity",
+		s.addTool(mcp.NewTool("update_entities",
+			mcp.WithDescription("Modify Attributes or Relationships of multiple Instances at once. Property keys are normalized to "+kc+". Prefer this over repeated update_entity calls when reconciling bulk data."),
+			mcp.WithString("entities", mcp.Required(), mcp.Description("JSON array of objects with 'uuid' (UUID or name of the Instance) and 'properties' (object of Attributes or Relationships)")),
+		), s.handleUpdateEntities)
+
+		s.addTool(mcp.NewTool("delete_entity",
 			mcp.WithDescription("Permanently remove an Instance record from the database."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the Instance")),
 		), s.handleDeletePage)
 	}
 
-	if s.mode == ModeGeneral {
-		s.server.AddTool(mcp.NewTool("read_page",
+	if s.mode != ModeOntological {
+		s.addTool(mcp.NewTool("read_page",
 			mcp.WithDescription("Get page details. Returns the page properties and metadata."),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the page")),
 		), s.handleReadPage)
-	}
 
-	s.server.AddTool(mcp.NewTool("create_entity",
+		s.addTool(mcp.NewTool("get_page_properties",
+			mcp.WithDescription("Get only the properties of a page as a JSON object, without page metadata."),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the page")),
+			mcp.WithBoolean("display", mcp.Description("Convert snake_case keys back to a readable display form")),
+		), s.handleGetPageProperties)
+	}
+
+	s.addTool(mcp.NewTool("get_entity_by_id",
+		mcp.WithDescription("Retrieve the single Instance whose property matches a unique business identifier (e.g. 'isbn' = '978-0261102217'). Errors if no Instance or more than one Instance matches."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("key", mcp.Required(), mcp.Description("The identifying property key (normalized to "+kc+" in ontological mode)")),
+		mcp.WithString("value", mcp.Required(), mcp.Description("The identifying property value")),
+	), s.handleGetEntityByID)
+
+	s.addTool(mcp.NewTool("search_all",
+		mcp.WithDescription("Find anything mentioning a term: blocks whose content contains it and blocks or pages with a property value containing it (case-insensitive). Each hit has a match_type of 'content' or 'property'."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("term", mcp.Required(), mcp.Description("The text to search for")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum number of hits to return (default %d)", DefaultSearchLimit))),
+	), s.handleSearchAll)
+
+	s.addTool(mcp.NewTool("find_blocks_by_property",
+		mcp.WithDescription("Find blocks/entries (not pages) carrying a property with a given value (e.g. 'status' = 'blocked'). Returns each block's UUID, content and owning page."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("key", mcp.Required(), mcp.Description("The property key (normalized to "+kc+" in ontological mode)")),
+		mcp.WithString("value", mcp.Required(), mcp.Description("The property value to match")),
+	), s.handleFindBlocksByProperty)
+
+	s.addTool(mcp.NewTool("create_entity",
 		mcp.WithDescription("Create a new Instance (Particular). Instances represent unique database entries. Classes (Universals) should be added as tags (e.g. #Person). Attributes (data) and Relationships (links) should be added as properties. Always use the returned UUID for subsequent operations."),
 		mcp.WithString("name", mcp.Required(), mcp.Description("The specific name of the Instance (e.g. 'The Hobbit', 'Alice Smith')")),
 		mcp.WithString("namespace", mcp.Description("The optional Class or category (e.g., 'Person', 'Project').")),
-		mcp.WithString("properties", mcp.Description("JSON string of Attributes (e.g. 'published-date: 1937') or Relationships (e.g. 'author: [[J.R.R. Tolkien]]'). Keys will be converted to snake_case in ontological mode.")),
+		mcp.WithString("properties", mcp.Description("JSON string of Attributes (e.g. 'published-date: 1937') or Relationships (e.g. 'author: [[J.R.R. Tolkien]]'). Keys will be converted to "+kc+" in ontological mode.")),
+		mcp.WithString("custom_uuid", mcp.Description("UUID to give the new Instance instead of a generated one. Must not be in use. Older Logseq versions ignore it; the result says so.")),
+		mcp.WithBoolean("create_first_block", mcp.Description("Whether Logseq creates an empty first block on the new page (Logseq's default is true)")),
+		mcp.WithString("options", mcp.Description("JSON object of Logseq createPage options, e.g. '{\"createFirstBlock\": false}' or '{\"journal\": true}'. Options Logseq does not know are ignored.")),
 	), s.handleCreateEntity)
 
-	if s.mode == ModeGeneral {
-		s.server.AddTool(mcp.NewTool("create_pages",
+	s.addTool(mcp.NewTool("create_page_tree",
+		mcp.WithDescription("Create a page or Instance and insert a structured tree of blocks into it in one call. Example tree: '[{\"content\": \"Root\", \"children\": [{\"content\": \"Child\"}]}]'. Returns the page UUID and the UUIDs of the created blocks."),
+		mcp.WithString("name", mcp.Required(), mcp.Description("The name of the page (e.g. 'The Hobbit')")),
+		mcp.WithString("namespace", mcp.Description("The optional Class or namespace to create the page under")),
+		mcp.WithString("properties", mcp.Description("JSON string of page properties. Keys will be converted to "+kc+" in ontological mode.")),
+		mcp.WithString("tree", mcp.Required(), mcp.Description("JSON array of BlockContent objects. Use nested 'children' to represent the outline hierarchy.")),
+	), s.handleCreatePageTree)
+
+	s.addTool(mcp.NewTool("upsert_entity",
+		mcp.WithDescription("Create an Instance if none matches a unique key property, otherwise update the matching Instance. Use this to reconcile external data idempotently. Errors if more than one Instance matches."),
+		mcp.WithString("match_key", mcp.Required(), mcp.Description("The identifying property key (e.g. 'isbn')")),
+		mcp.WithString("match_value", mcp.Required(), mcp.Description("The identifying property value")),
+		mcp.WithString("name", mcp.Required(), mcp.Description("The name to use if a new Instance has to be created")),
+		mcp.WithString("namespace", mcp.Description("The optional Class or category for a newly created Instance")),
+		mcp.WithString("properties", mcp.Description("JSON string of Attributes or Relationships to set. Keys will be converted to "+kc+" in ontological mode.")),
+	), s.handleUpsertEntity)
+
+	s.addTool(mcp.NewTool("import_csv",
+		mcp.WithDescription("Import CSV rows as Instances. Each row becomes one page titled by the name column; all other columns become Attributes (normalized to "+kc+" in ontological mode). Quoted fields are supported."),
+		mcp.WithString("csv", mcp.Required(), mcp.Description("The CSV text, including a header row")),
+		mcp.WithString("namespace", mcp.Required(), mcp.Description("The Class or namespace to create the Instances under (e.g. 'Person')")),
+		mcp.WithString("name_column", mcp.Required(), mcp.Description("The header of the column holding each Instance's name")),
+		mcp.WithString("tag", mcp.Description("Optional tag (Class) to add to each created Instance")),
+		mcp.WithString("delimiter", mcp.Description("Optional single-character field delimiter (default ',')")),
+	), s.handleImportCSV)
+
+	if s.mode != ModeOntological {
+		s.addTool(mcp.NewTool("create_pages",
 			mcp.WithDescription("Create multiple pages. Use create_entity for ontological items."),
-			mcp.WithString("pages", mcp.Required(), mcp.Description("JSON array of objects with 'name' and optional 'properties'")),
+			mcp.WithString("pages", mcp.Required(), mcp.Description("JSON array of objects with 'name' and optional 'properties', 'custom_uuid' and 'create_first_block'")),
+			mcp.WithString("options", mcp.Description("JSON object of Logseq createPage options applied to every page, e.g. '{\"createFirstBlock\": false}' or '{\"journal\": true}'. Options Logseq does not know are ignored.")),
 		), s.handleCreatePages)
 
-		s.server.AddTool(mcp.NewTool("update_page",
+		s.addTool(mcp.NewTool("update_page",
 			mcp.WithDescription("Update page properties. Use this to modify entity attributes."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the page")),
 			mcp.WithString("properties", mcp.Required(), mcp.Description("JSON string of properties to update")),
 		), s.handleUpdatePage)
 
-		s.server.AddTool(mcp.NewTool("delete_page",
+		s.addTool(mcp.NewTool("delete_page",
 			mcp.WithDescription("Permanently delete a page/entity."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the page")),
 		), s.handleDeletePage)
 
-		s.server.AddTool(mcp.NewTool("delete_pages",
+		s.addTool(mcp.NewTool("delete_pages",
 			mcp.WithDescription("Permanently delete multiple pages/entities."),
 			mcp.WithString("uuids", mcp.Required(), mcp.Description("JSON array of page UUIDs or names to delete")),
 		), s.handleDeletePages)
 	}
 
-	s.server.AddTool(mcp.NewTool("rename_page",
+	s.addTool(mcp.NewTool("page_exists",
+		mcp.WithDescription("Cheaply check whether a page or Instance exists before deciding to create or update it. Returns {exists, uuid}; a missing page is not an error."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The UUID or name of the page")),
+	), s.handlePageExists)
+
+	s.addTool(mcp.NewTool("preview_links",
+		mcp.WithDescription("Preview which [[linked]] pages writing some content would auto-create, without creating anything. Returns {existing, would_create}; namespaced links are expanded into their parent pages. Use this to catch typos in links before inserting content."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("content", mcp.Description("The block content to check")),
+		mcp.WithString("properties", mcp.Description("Optional JSON object of properties whose values are checked as well")),
+	), s.handlePreviewLinks)
+
+	s.addTool(mcp.NewTool("recent_pages",
+		mcp.WithDescription("List the most recently edited pages/Instances, newest first, with their name, UUID and last edit time."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum number of pages to return (default %d)", DefaultRecentPagesLimit))),
+	), s.handleRecentPages)
+
+	s.addTool(mcp.NewTool("read_pages",
+		mcp.WithDescription("Read several pages/Instances by name or UUID in one call. Results keep the input order; missing pages are marked with found=false."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("names", mcp.Required(), mcp.Description("JSON array of page names or UUIDs")),
+	), s.handleReadPages)
+
+	s.addTool(mcp.NewTool("get_page_outline",
+		mcp.WithDescription("Get a table of contents for a page or Instance: the first line and UUID of each block down to 'depth' levels. Cheaper than a full read; use the UUIDs to drill in."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The UUID or name of the page")),
+		mcp.WithNumber("depth", mcp.Description("How many outline levels to include (default 1, top-level blocks only)")),
+	), s.handleGetPageOutline)
+
+	s.addTool(mcp.NewTool("get_page_assets",
+		mcp.WithDescription("List the assets (images, PDFs, audio/video) referenced from a page or Instance via ![..](..) or {{pdf/video/audio ..}}. Returns [{ref, block, path}], where path is the absolute file path inside the graph (omitted for remote URLs)."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The UUID or name of the page")),
+	), s.handleGetPageAssets)
+
+	s.addTool(mcp.NewTool("collapse_page",
+		mcp.WithDescription("Collapse or expand all blocks of a page or Instance at once, e.g. to present a tidy outline. Reports how many blocks were toggled."),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The UUID or name of the page")),
+		mcp.WithBoolean("collapsed", mcp.Required(), mcp.Description("true to collapse, false to expand")),
+		mcp.WithNumber("depth", mcp.Description("The outline level to toggle (default 1, top-level blocks; 2 keeps top-level blocks open and toggles their children)")),
+	), s.handleCollapsePage)
+
+	s.addTool(mcp.NewTool("get_page_size",
+		mcp.WithDescription("Get the size of a page or Instance ({block_count, word_count, char_count}) without fetching its content. Use it to decide whether to read the full page or query selectively."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The UUID or name of the page")),
+	), s.handleGetPageSize)
+
+	s.addTool(mcp.NewTool("replace_in_page",
+		mcp.WithDescription("Find and replace text in every block of a page or Instance, e.g. to fix a misspelled term throughout. Text inside [[links]], ((block refs)) and id:: lines is left alone unless include_refs is set. Reports how many blocks were changed."),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The UUID or name of the page")),
+		mcp.WithString("find", mcp.Required(), mcp.Description("The text to search for (matched literally, not as a regex)")),
+		mcp.WithString("replace", mcp.Description("The replacement text (empty removes the matches)")),
+		mcp.WithBoolean("case_sensitive", mcp.Description("Match case exactly (default true)")),
+		mcp.WithBoolean("whole_word", mcp.Description("Only replace matches that are not part of a longer word")),
+		mcp.WithBoolean("include_refs", mcp.Description("Also rewrite text inside [[links]], ((block refs)) and id:: lines")),
+	), s.handleReplaceInPage)
+
+	s.addTool(mcp.NewTool("rename_page",
 		mcp.WithDescription("Rename a page. Note: This may break ontological references if not handled carefully."),
 		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the page")),
 		mcp.WithString("new_name", mcp.Required(), mcp.Description("The new name of the page")),
 	), s.handleRenamePage)
 
 	// Namespace Tools
-	s.server.AddTool(mcp.NewTool("read_namespace",
+	s.addTool(mcp.NewTool("read_namespace",
 		mcp.WithDescription("List all Instances within a specific Class or namespace hierarchy."),
+		mcp.WithReadOnlyHintAnnotation(true),
 		mcp.WithString("namespace", mcp.Required(), mcp.Description("The Class or category to list")),
+		mcp.WithBoolean("recursive", mcp.Description("Include all nested descendants (e.g. 'A/B/C' when listing 'A'), each with a 'depth' field. Defaults to direct children only.")),
+		mcp.WithNumber("limit", mcp.Description(fmt.Sprintf("Maximum number of Instances to return (default %d)", DefaultNamespaceLimit))),
+		mcp.WithNumber("offset", mcp.Description("Number of Instances to skip, for paging through large Classes (default 0)")),
 	), s.handleReadNamespace)
 
-	if s.mode == ModeGeneral {
-		s.server.AddTool(mcp.NewTool("create_namespace",
+	s.addTool(mcp.NewTool("get_parent_namespace",
+		mcp.WithDescription("Get the namespace parent of a page/Instance, e.g. the page 'A/B' for 'A/B/C'. Reports top-level pages as having no parent."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The UUID or name of the page")),
+	), s.handleGetParentNamespace)
+
+	s.addTool(mcp.NewTool("get_sibling_pages",
+		mcp.WithDescription("List the other pages/Instances in the same namespace as a page, e.g. 'A/B/D' for 'A/B/C' (\"other projects in this area\"). Top-level pages have no namespace and get an empty list."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("nameOrUUID", mcp.Required(), mcp.Description("The UUID or name of the page")),
+	), s.handleGetSiblingPages)
+
+	s.addTool(mcp.NewTool("export_namespace",
+		mcp.WithDescription("Export all Instances of a Class or namespace as a table. Each Instance becomes a row with its name, UUID and Attributes as columns."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("namespace", mcp.Required(), mcp.Description("The Class or category to export")),
+		mcp.WithString("format", mcp.Description("Output format: 'json' (default) or 'csv'")),
+	), s.handleExportNamespace)
+
+	s.addTool(mcp.NewTool("export_graph",
+		mcp.WithDescription("Export pages with their properties and nested block trees as one JSON document, e.g. for backup or migration. This is heavy on large graphs: page through it with 'limit' and 'offset' (pages are sorted by name). The first line summarizes the page and byte count."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithBoolean("include_journals", mcp.Description("Include journal pages (default true)")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of pages to export (default: all)")),
+		mcp.WithNumber("offset", mcp.Description("Number of pages to skip (default 0)")),
+	), s.handleExportGraph)
+
+	s.addTool(mcp.NewTool("import_graph",
+		mcp.WithDescription("Restore pages and block trees from an export_graph dump. Existing pages get the exported blocks appended unless skip_existing is set. Imported blocks get new UUIDs, so ((block refs)) between pages of the dump dangle afterwards; their number is reported."),
+		mcp.WithString("data", mcp.Required(), mcp.Description("The JSON document returned by export_graph (the summary line may be included)")),
+		mcp.WithBoolean("skip_existing", mcp.Description("Leave pages that already exist untouched instead of appending to them")),
+	), s.handleImportGraph)
+
+	if s.mode != ModeOntological {
+		s.addTool(mcp.NewTool("create_namespace",
 			mcp.WithDescription("Create a new namespace or category level. Defines a high-level grouping."),
 			mcp.WithString("namespace", mcp.Required(), mcp.Description("The namespace name (e.g. 'work/project')")),
+			mcp.WithString("options", mcp.Description("JSON object of Logseq createPage options, e.g. '{\"createFirstBlock\": false}' or '{\"journal\": true}'. Options Logseq does not know are ignored.")),
 		), s.handleCreateNamespace)
 	}
 
+	if s.mode == ModeOntological {
+		s.addTool(mcp.NewTool("describe_class",
+			mcp.WithDescription(fmt.Sprintf("Infer the schema of a Class: which Attributes and Relationships its Instances have and how often. Instances are found by tag and by namespace; at most %d are inspected.", DescribeClassSampleSize)),
+			mcp.WithReadOnlyHintAnnotation(true),
+			mcp.WithString("class", mcp.Required(), mcp.Description("The Class (tag or namespace), e.g. 'Book'")),
+		), s.handleDescribeClass)
+	}
+
 	// Block Tools
 	if s.mode == ModeOntological {
-		s.server.AddTool(mcp.NewTool("read_entry",
+		s.addTool(mcp.NewTool("read_entry",
 			mcp.WithDescription("Read a specific entry (block) within an Instance outline."),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the entry")),
 		), s.handleReadBlock)
 
-		s.server.AddTool(mcp.NewTool("update_entry",
-			mcp.WithDescription("Modify an entry (block). In ontological mode, properties are normalized to snake_case."),
+		s.addTool(mcp.NewTool("update_entry",
+			mcp.WithDescription("Modify an entry (block). In ontological mode, properties are normalized to "+kc+"."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the entry")),
 			mcp.WithString("content", mcp.Required(), mcp.Description("The updated content")),
 			mcp.WithString("properties", mcp.Description("JSON string of updated entry Attributes or Relationships")),
 		), s.handleUpdateBlock)
 
-		s.server.AddTool(mcp.NewTool("remove_entry",
+		s.addTool(mcp.NewTool("remove_entry",
 			mcp.WithDescription("Permanently remove an entry from an Instance outline."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the entry")),
 		), s.handleDeleteBlock)
 
-		s.server.AddTool(mcp.NewTool("append_entry_to_entity",
+		s.addTool(mcp.NewTool("append_entry_to_entity",
 			mcp.WithDescription("Append a new bullet point to an Instance. Use this to add data entries or notes in a clean outliner format. Do NOT use for bulk data; prefer create_entry_tree for structured trees."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the Instance page")),
 			mcp.WithString("content", mcp.Required(), mcp.Description("The content of the bullet point")),
 		), s.handleAppendBlock)
 
-		s.server.AddTool(mcp.NewTool("create_entry",
-			mcp.WithDescription("Insert an entry (block). Properties are normalized to snake_case."),
-			mcp.WithString("parent_uuid", mcp.Required(), mcp.Description("The UUID of the parent entry or Instance page")),
+		s.addTool(mcp.NewTool("create_entry",
+			mcp.WithDescription("Insert an entry (block). Properties are normalized to "+kc+"."),
+			mcp.WithString("parent_uuid", mcp.Required(), mcp.Description("The UUID of the parent entry or Instance page. An Instance name is also accepted and resolves to the root of that page.")),
 			mcp.WithString("content", mcp.Required(), mcp.Description("The content of the entry")),
 			mcp.WithString("properties", mcp.Description("JSON string of entry Attributes or Relationships")),
 			mcp.WithBoolean("sibling", mcp.Description("Insert as sibling instead of child")),
 			mcp.WithBoolean("before", mcp.Description("Insert before the reference entry (only if sibling=true)")),
+			mcp.WithString("custom_uuid", mcp.Description("UUID to give the new entry instead of a generated one, e.g. to keep references stable across export/import. Must not be in use.")),
 		), s.handleCreateBlock)
 
-		s.server.AddTool(mcp.NewTool("create_entry_tree",
+		s.addTool(mcp.NewTool("create_entry_tree",
 			mcp.WithDescription("Insert a structured tree of entries. Preferred for complex data structures. This forces an outliner-style hierarchy. Example tree: '[{\"content\": \"Root\", \"children\": [{\"content\": \"Child\"}]}]'"),
 			mcp.WithString("parent_uuid", mcp.Required(), mcp.Description("The UUID of the parent entry or page")),
 			mcp.WithString("tree", mcp.Required(), mcp.Description("JSON array of BlockContent objects. Use nested 'children' to represent the outline hierarchy.")),
@@ -285,45 +1131,47 @@ func (s *MCPServer) registerTools() {
 		), s.handleCreateBlockTree)
 	}
 
-	if s.mode == ModeGeneral {
-		s.server.AddTool(mcp.NewTool("read_block",
+	if s.mode != ModeOntological {
+		s.addTool(mcp.NewTool("read_block",
 			mcp.WithDescription("Get block details, including content and nested properties."),
+			mcp.WithReadOnlyHintAnnotation(true),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block")),
 		), s.handleReadBlock)
 
-		s.server.AddTool(mcp.NewTool("append_block",
+		s.addTool(mcp.NewTool("append_block",
 			mcp.WithDescription("Append a block to the end of a page/entity."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the page")),
 			mcp.WithString("content", mcp.Required(), mcp.Description("The content of the block")),
 		), s.handleAppendBlock)
 
-		s.server.AddTool(mcp.NewTool("update_block",
+		s.addTool(mcp.NewTool("update_block",
 			mcp.WithDescription("Update existing block content or properties."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block")),
 			mcp.WithString("content", mcp.Required(), mcp.Description("The new content")),
 			mcp.WithString("properties", mcp.Description("JSON string of properties")),
 		), s.handleUpdateBlock)
 
-		s.server.AddTool(mcp.NewTool("remove_block",
+		s.addTool(mcp.NewTool("remove_block",
 			mcp.WithDescription("Permanently remove a block."),
 			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block")),
 		), s.handleDeleteBlock)
 
-		s.server.AddTool(mcp.NewTool("remove_blocks",
+		s.addTool(mcp.NewTool("remove_blocks",
 			mcp.WithDescription("Permanently remove multiple blocks."),
 			mcp.WithString("uuids", mcp.Required(), mcp.Description("JSON array of block UUIDs to delete")),
 		), s.handleDeleteBlocks)
 
-		s.server.AddTool(mcp.NewTool("create_block",
+		s.addTool(mcp.NewTool("create_block",
 			mcp.WithDescription("Insert a block."),
-			mcp.WithString("parent_uuid", mcp.Required(), mcp.Description("The UUID of the parent block or page")),
+			mcp.WithString("parent_uuid", mcp.Required(), mcp.Description("The UUID of the parent block or page. A page name is also accepted and resolves to the root of that page.")),
 			mcp.WithString("content", mcp.Required(), mcp.Description("The content of the block")),
 			mcp.WithString("properties", mcp.Description("JSON string of block-level properties")),
 			mcp.WithBoolean("sibling", mcp.Description("Insert as sibling instead of child")),
 			mcp.WithBoolean("before", mcp.Description("Insert before the reference block (only if sibling=true)")),
+			mcp.WithString("custom_uuid", mcp.Description("UUID to give the new block instead of a generated one, e.g. to keep references stable across export/import. Must not be in use.")),
 		), s.handleCreateBlock)
 
-		s.server.AddTool(mcp.NewTool("create_block_tree",
+		s.addTool(mcp.NewTool("create_block_tree",
 			mcp.WithDescription("Insert a structured tree of blocks."),
 			mcp.WithString("parent_uuid", mcp.Required(), mcp.Description("The UUID of the parent block")),
 			mcp.WithString("tree", mcp.Required(), mcp.Description("JSON array of BlockContent objects. Use nested 'children' to represent the outline hierarchy.")),
@@ -332,31 +1180,246 @@ func (s *MCPServer) registerTools() {
 		), s.handleCreateBlockTree)
 	}
 
+	s.addTool(mcp.NewTool("insert_block_ref",
+		mcp.WithDescription("Insert a block/entry whose content is a ((block reference)) to another block/entry. The referenced block must exist; no pages are auto-created."),
+		mcp.WithString("parent_uuid", mcp.Required(), mcp.Description("The UUID of the parent block/entry or page. A page name is also accepted and resolves to the root of that page.")),
+		mcp.WithString("ref_uuid", mcp.Required(), mcp.Description("The UUID of the block/entry to reference")),
+		mcp.WithBoolean("sibling", mcp.Description("Insert as sibling instead of child")),
+		mcp.WithBoolean("before", mcp.Description("Insert before the parent block (only if sibling=true)")),
+	), s.handleInsertBlockRef)
+
+	s.addTool(mcp.NewTool("insert_embed",
+		mcp.WithDescription("Insert a block/entry that embeds another block/entry ({{embed ((uuid))}}) or a whole page ({{embed [[Page]]}}). The target must exist; no pages are auto-created."),
+		mcp.WithString("parent_uuid", mcp.Required(), mcp.Description("The UUID of the parent block/entry or page. A page name is also accepted and resolves to the root of that page.")),
+		mcp.WithString("target", mcp.Required(), mcp.Description("The UUID of the block/entry to embed (type 'block') or the name of the page to embed (type 'page')")),
+		mcp.WithString("type", mcp.Required(), mcp.Enum(logseq.EmbedBlock, logseq.EmbedPage), mcp.Description("What to embed: 'block' or 'page'")),
+		mcp.WithBoolean("sibling", mcp.Description("Insert as sibling instead of child")),
+		mcp.WithBoolean("before", mcp.Description("Insert before the parent block (only if sibling=true)")),
+	), s.handleInsertEmbed)
+
+	s.addTool(mcp.NewTool("get_block_format",
+		mcp.WithDescription("Get the content format of a block/entry ('markdown' or 'org'). Check it before writing raw property or heading syntax into an org-mode graph."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry")),
+	), s.handleGetBlockFormat)
+
+	s.addTool(mcp.NewTool("append_to_block",
+		mcp.WithDescription("Append a line to the end of an existing block/entry's content without replacing it. Properties are kept. Use update_block to replace the content instead."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry")),
+		mcp.WithString("content", mcp.Required(), mcp.Description("The text to append as a new line")),
+	), s.handleAppendToBlock)
+
+	s.addTool(mcp.NewTool("prepend_to_block",
+		mcp.WithDescription("Insert text at the start of an existing block/entry's content without replacing it (e.g. a timestamp). Properties are kept. Use set_task_state for task markers."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry")),
+		mcp.WithString("content", mcp.Required(), mcp.Description("The text to prepend")),
+		mcp.WithBoolean("same_line", mcp.Description("Prepend on the first line separated by a space instead of as a new first line")),
+	), s.handlePrependToBlock)
+
+	s.addTool(mcp.NewTool("set_task_state",
+		mcp.WithDescription("Set or clear a block's task marker (TODO, DOING, DONE, NOW, LATER). Any existing marker is replaced; use 'none' to turn the block back into a plain bullet."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry")),
+		mcp.WithString("state", mcp.Required(), mcp.Description("One of TODO, DOING, DONE, NOW, LATER or none")),
+	), s.handleSetTaskState)
+
+	s.addTool(mcp.NewTool("toggle_task",
+		mcp.WithDescription("Check off or reopen a task: DONE becomes TODO, any other marker (TODO, DOING, NOW, LATER) becomes DONE. The rest of the block, including its properties, is kept. Fails if the block has no task marker."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the task block/entry")),
+	), s.handleToggleTask)
+
+	s.addTool(mcp.NewTool("set_schedule",
+		mcp.WithDescription("Set the SCHEDULED or DEADLINE date of a task block/entry, e.g. 'SCHEDULED: <2026-01-18 Sun 10:00>'. An existing timestamp of the same type is replaced."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry")),
+		mcp.WithString("date", mcp.Required(), mcp.Description("The date as YYYY-MM-DD")),
+		mcp.WithString("time", mcp.Description("Optional time of day as HH:MM (24h)")),
+		mcp.WithString("type", mcp.Required(), mcp.Enum(logseq.ScheduleScheduled, logseq.ScheduleDeadline), mcp.Description("'scheduled' or 'deadline'")),
+	), s.handleSetSchedule)
+
+	s.addTool(mcp.NewTool("agenda",
+		mcp.WithDescription(fmt.Sprintf("List the blocks/entries scheduled or due between two dates, e.g. for 'what is on my plate this week'. Returns [{date, items: [{uuid, content, page, marker, type}]}] in date order, where type is 'scheduled' or 'deadline'. The range may span at most %d days.", logseq.MaxAgendaDays)),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("from", mcp.Required(), mcp.Description("First date of the range as YYYY-MM-DD")),
+		mcp.WithString("to", mcp.Required(), mcp.Description("Last date of the range as YYYY-MM-DD (inclusive)")),
+	), s.handleAgenda)
+
+	s.addTool(mcp.NewTool("list_tasks",
+		mcp.WithDescription("List task blocks/entries across the graph, e.g. for a daily standup. Returns [{uuid, content, page, marker}], sorted by page. Defaults to open tasks (TODO, DOING, NOW, LATER)."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("state", mcp.Description("Only list tasks with this marker: TODO, DOING, NOW, LATER or DONE. Several may be given comma-separated (e.g. 'TODO,DOING').")),
+	), s.handleListTasks)
+
+	s.addTool(mcp.NewTool("read_blocks",
+		mcp.WithDescription("Read several blocks/entries by UUID in one call, e.g. to hydrate UUIDs returned by a query. Results keep the input order; missing blocks are marked with found=false."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("uuids", mcp.Required(), mcp.Description("JSON array of block UUIDs")),
+	), s.handleReadBlocks)
+
+	s.addTool(mcp.NewTool("get_children",
+		mcp.WithDescription("List the direct children of a block/entry as a JSON array of {uuid, content}. Use this to target updates at specific child blocks."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the parent block/entry")),
+	), s.handleGetChildren)
+
+	s.addTool(mcp.NewTool("get_block_context",
+		mcp.WithDescription("Get a block/entry together with its surrounding siblings, e.g. to understand the context of a ((block ref)). Returns a JSON array of {uuid, content, target} in outline order; the window is clamped at the first and last sibling."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry")),
+		mcp.WithNumber("radius", mcp.Required(), mcp.Description("How many preceding and following siblings to include (0 returns only the block)")),
+	), s.handleGetBlockContext)
+
+	s.addTool(mcp.NewTool("reorder_block",
+		mcp.WithDescription("Move a block/entry one position up or down among its siblings. Does nothing if it is already first (up) or last (down)."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry to move")),
+		mcp.WithString("direction", mcp.Required(), mcp.Description("Either 'up' or 'down'")),
+	), s.handleReorderBlock)
+
+	s.addTool(mcp.NewTool("move_blocks",
+		mcp.WithDescription("Move several blocks/entries in one call. Operations are applied strictly in the given order, so later operations may target blocks moved earlier."),
+		mcp.WithString("operations", mcp.Required(), mcp.Description("JSON array of moves: [{\"uuid\": \"...\", \"target_uuid\": \"...\", \"sibling\": true, \"before\": false}]. Without 'sibling' the block becomes a child of the target.")),
+	), s.handleMoveBlocks)
+
+	s.addTool(mcp.NewTool("apply_template",
+		mcp.WithDescription("Instantiate a template page: copy its block tree under a target block/page, replacing {{var}} placeholders with the given values. Placeholders without a value are left intact."),
+		mcp.WithString("template_page", mcp.Required(), mcp.Description("The name or UUID of the template page (e.g. 'Meeting Template')")),
+		mcp.WithString("target_uuid", mcp.Required(), mcp.Description("The UUID of the block/page to insert the blocks under")),
+		mcp.WithString("variables", mcp.Description("JSON object of placeholder values, e.g. '{\"date\": \"2026-01-18\"}'")),
+	), s.handleApplyTemplate)
+
 	// Tag/Property Tools
-	s.server.AddTool(mcp.NewTool("add_tag",
+	s.addTool(mcp.NewTool("add_tag",
 		mcp.WithDescription("Add a #tag for discoverability (Classes/Universals). If the target is a page and has no entries, a new empty block will be created to hold the tag."),
 		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
 		mcp.WithString("tag", mcp.Required(), mcp.Description("The tag to add (e.g. 'Project' or '#Project')")),
 	), s.handleAddTag)
 
-	s.server.AddTool(mcp.NewTool("remove_tag",
+	s.addTool(mcp.NewTool("get_tags",
+		mcp.WithDescription("List the tags (Classes/Universals) applied to a block/entry or page/entity: #tags in its content plus its tags:: property, deduped."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or the UUID or name of the page/entity")),
+	), s.handleGetTags)
+
+	s.addTool(mcp.NewTool("add_tags",
+		mcp.WithDescription("Add several #tags (Classes/Universals) at once in a single update. Tags already present are skipped."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
+		mcp.WithString("tags", mcp.Required(), mcp.Description("JSON array of tags to add (e.g. '[\"Person\", \"#Author\"]')")),
+	), s.handleAddTags)
+
+	s.addTool(mcp.NewTool("tag_namespace",
+		mcp.WithDescription("Apply a #tag (Class/Universal) to every page directly under a namespace, e.g. classify all 'people/...' Instances as #Person. Pages already carrying the tag are left unchanged."),
+		mcp.WithString("namespace", mcp.Required(), mcp.Description("The namespace whose pages to tag (e.g. 'people')")),
+		mcp.WithString("tag", mcp.Required(), mcp.Description("The tag to add (e.g. 'Person' or '#Person')")),
+	), s.handleTagNamespace)
+
+	s.addTool(mcp.NewTool("remove_tag",
 		mcp.WithDescription("Remove a discovery tag (Class/Universal)."),
 		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
 		mcp.WithString("tag", mcp.Required(), mcp.Description("The tag to remove (e.g. 'Project' or '#Project')")),
 	), s.handleRemoveTag)
 
-	s.server.AddTool(mcp.NewTool("remove_property",
+	s.addTool(mcp.NewTool("remove_tags",
+		mcp.WithDescription("Remove several discovery tags (Classes/Universals) at once in a single update. Tags that are not present are ignored."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
+		mcp.WithString("tags", mcp.Required(), mcp.Description("JSON array of tags to remove (e.g. '[\"Person\", \"#Author\"]')")),
+	), s.handleRemoveTags)
+
+	s.addTool(mcp.NewTool("add_alias",
+		mcp.WithDescription("Add an alternate name to a page/entity via its alias:: property, keeping any existing aliases. Aliases feed Logseq search and linking, e.g. to reconcile 'JRR Tolkien' with 'J.R.R. Tolkien'."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the page/entity")),
+		mcp.WithString("alias", mcp.Required(), mcp.Description("The alternate name to add")),
+	), s.handleAddAlias)
+
+	s.addTool(mcp.NewTool("remove_alias",
+		mcp.WithDescription("Remove an alternate name from a page/entity's alias:: property, keeping the other aliases."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID or name of the page/entity")),
+		mcp.WithString("alias", mcp.Required(), mcp.Description("The alternate name to remove")),
+	), s.handleRemoveAlias)
+
+	s.addTool(mcp.NewTool("remove_property",
 		mcp.WithDescription("Remove a specific property/attribute/relationship."),
 		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
 		mcp.WithString("key", mcp.Required(), mcp.Description("The property key to remove")),
 	), s.handleRemoveProperty)
 
-	s.server.AddTool(mcp.NewTool("add_property",
+	s.addTool(mcp.NewTool("clear_property",
+		mcp.WithDescription("Blank out a property/attribute value while keeping the key (sets it to an empty string). Use remove_property to delete the key entirely."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
+		mcp.WithString("key", mcp.Required(), mcp.Description("The property key to clear")),
+	), s.handleClearProperty)
+
+	s.addTool(mcp.NewTool("rename_property",
+		mcp.WithDescription("Rename a property/attribute key on a single block or page, keeping its value (e.g. 'published_date' -> 'publication_date'). Fails if the old key is missing or the new key is already set."),
+		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
+		mcp.WithString("old_key", mcp.Required(), mcp.Description("The current property key")),
+		mcp.WithString("new_key", mcp.Required(), mcp.Description("The new property key")),
+	), s.handleRenameProperty)
+
+	s.addTool(mcp.NewTool("rename_property_everywhere",
+		mcp.WithDescription("Rename a property/attribute key on EVERY page that uses it (schema migration). This is destructive and slow; it requires 'confirm: true'."),
+		mcp.WithString("old_key", mcp.Required(), mcp.Description("The current property key")),
+		mcp.WithString("new_key", mcp.Required(), mcp.Description("The new property key")),
+		mcp.WithBoolean("confirm", mcp.Required(), mcp.Description("Must be true to perform the rename")),
+	), s.handleRenamePropertyEverywhere)
+
+	s.addTool(mcp.NewTool("replace_everywhere",
+		mcp.WithDescription("Find and replace text in EVERY block of the graph. This is destructive; it requires 'confirm: true'. Use 'dry_run: true' first to list the blocks that would change. Text inside [[links]], ((block refs)) and id:: lines is left alone unless include_refs is set."),
+		mcp.WithString("find", mcp.Required(), mcp.Description("The text to search for (matched literally, not as a regex)")),
+		mcp.WithString("replace", mcp.Description("The replacement text (empty removes the matches)")),
+		mcp.WithBoolean("case_sensitive", mcp.Description("Match case exactly (default true)")),
+		mcp.WithBoolean("whole_word", mcp.Description("Only replace matches that are not part of a longer word")),
+		mcp.WithBoolean("include_refs", mcp.Description("Also rewrite text inside [[links]], ((block refs)) and id:: lines")),
+		mcp.WithBoolean("dry_run", mcp.Description("Only list the blocks that would change, with their new content")),
+		mcp.WithBoolean("confirm", mcp.Description("Must be true to perform the replace (not needed for a dry run)")),
+	), s.handleReplaceEverywhere)
+
+	s.addTool(mcp.NewTool("add_property",
 		mcp.WithDescription("Add or update a specific property/attribute (data) or relationship (link)."),
 		mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
 		mcp.WithString("key", mcp.Required(), mcp.Description("The property key to add or update")),
 		mcp.WithString("value", mcp.Required(), mcp.Description("The property value (use [[Page Name]] for relationships)")),
 	), s.handleUpsertProperty)
+
+	if s.mode == ModeOntological {
+		s.addTool(mcp.NewTool("add_relationship",
+			mcp.WithDescription("Add or update a Relationship (link to other Instances). Unlike add_property, the value MUST consist only of page links such as '[[Alice Smith]]' or '[[A]], [[B]]'; plain literals are rejected. Use add_property for Attributes (data)."),
+			mcp.WithString("uuid", mcp.Required(), mcp.Description("The UUID of the block/entry or page/entity")),
+			mcp.WithString("key", mcp.Required(), mcp.Description("The relationship key (normalized to "+kc+")")),
+			mcp.WithString("value", mcp.Required(), mcp.Description("One or more page links, e.g. '[[J.R.R. Tolkien]]'")),
+			mcp.WithString("class", mcp.Description("Optional Class to tag each linked Instance with (e.g. 'Person')")),
+		), s.handleAddRelationship)
+	}
+
+	// Maintenance Tools
+	s.addTool(mcp.NewTool("find_duplicate_pages",
+		mcp.WithDescription("Find pages/Instances whose names only differ in case or surrounding whitespace (e.g. 'Tolkien' and 'tolkien '), so they can be reconciled. Returns only actual collisions, as groups of {name, uuid}."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), s.handleFindDuplicatePages)
+
+	s.addTool(mcp.NewTool("find_orphans",
+		mcp.WithDescription("List orphan pages: pages no block links to and whose own blocks link to nothing. Useful for graph hygiene; journal pages are skipped unless include_journals is set."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithBoolean("include_journals", mcp.Description("Also report orphaned journal pages (default false)")),
+	), s.handleFindOrphans)
+
+	s.addTool(mcp.NewTool("find_broken_refs",
+		mcp.WithDescription("Scan the graph for ((uuid)) block references whose target block no longer exists. Returns the referencing block UUID, its page, and the dangling ref."),
+		mcp.WithString("repair", mcp.Description("Optional repair mode. Use 'strip' to remove dangling refs from the referencing blocks' content.")),
+	), s.handleFindBrokenRefs)
+
+	s.addTool(mcp.NewTool("validate_graph",
+		mcp.WithDescription(fmt.Sprintf("One-shot referential integrity report: ((uuid)) refs whose block is gone, [[links]] to pages that do not exist, and namespaced pages whose parent namespace page is missing. Returns {valid, <section>_count, <section> samples}. Each section lists at most sample_limit samples (default %d, max %d). Use find_broken_refs to repair block refs.", DefaultValidationSampleSize, MaxValidationSampleSize)),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithNumber("sample_limit", mcp.Description("Maximum number of samples per section")),
+	), s.handleValidateGraph)
+
+	if s.rawAPI {
+		// With --read-only the handler only allows known read methods, so the tool stays available
+		s.addTool(mcp.NewTool("raw_api",
+			mcp.WithDescription("Escape hatch: call any Logseq API method (e.g. 'logseq.Editor.getPageLinkedReferences') and return its raw JSON response. Prefer the dedicated tools; this bypasses their validation, link handling and key normalization."),
+			mcp.WithReadOnlyHintAnnotation(s.readOnly),
+			mcp.WithString("method", mcp.Required(), mcp.Description("The fully qualified Logseq API method, e.g. 'logseq.Editor.getBlock'")),
+			mcp.WithString("args", mcp.Description("JSON array of positional arguments (default [])")),
+		), s.handleRawAPI)
+	}
 }
 
 // Handlers
@@ -369,30 +1432,128 @@ func parseArguments(req mcp.CallToolRequest, target any) error {
 	return json.Unmarshal(argBytes, target)
 }
 
+// invalidArguments reports arguments that do not match the tool definition, e.g. a number passed for a string.
+func invalidArguments(err error) *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments provided: %v. Please check the tool definition and try again.", err))
+}
+
+// requireString returns a tool error naming field when value is empty, or nil otherwise.
+// hint completes the sentence "Please provide ...".
+func requireString(field, value, hint string) *mcp.CallToolResult {
+	if value != "" {
+		return nil
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("The '%s' argument is required. Please provide %s.", field, hint))
+}
+
+// requireJSON decodes the JSON-encoded argument field into target. It returns a tool error naming
+// field when value is empty or not valid JSON, or nil otherwise. hint describes the expected shape.
+func requireJSON(field, value string, target any, hint string) *mcp.CallToolResult {
+	if res := requireString(field, value, hint); res != nil {
+		return res
+	}
+	if err := json.Unmarshal([]byte(value), target); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("The '%s' argument is not valid JSON (%v). Please provide %s.", field, err, hint))
+	}
+	return nil
+}
+
 func (s *MCPServer) handleReadGraphInfo(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	s.logger.Debug("handleReadGraphInfo", zap.Any("req", req))
 	graph, err := s.client.GetGraph()
 	if err != nil {
 		s.logger.Error("handleReadGraphInfo failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		if errors.Is(err, logseq.ErrNoGraphOpen) {
+			return mcp.NewToolResultError("No graph is currently open in Logseq. Please open a graph and try again."), nil
+		}
 		return mcp.NewToolResultError("Could not retrieve graph information. Please ensure Logseq is running and the HTTP API is enabled in settings."), nil
 	}
 	return mcp.NewToolResultText(fmt.Sprintf("Graph: %s\nPath: %s", graph.Name, graph.Path)), nil
 }
 
+func (s *MCPServer) handleResolveAssetPath(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleResolveAssetPath", zap.Any("req", req))
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("path", args.Path, "the link or filename as it appears in the block"); res != nil {
+		return res, nil
+	}
+
+	path, err := s.client.ResolveAssetPath(args.Path)
+	if err != nil {
+		s.logger.Error("handleResolveAssetPath failed", zap.String("path", args.Path), zap.Error(err))
+		if errors.Is(err, logseq.ErrPathEscapesGraph) {
+			return mcp.NewToolResultError(fmt.Sprintf("The path '%s' points outside the graph directory and cannot be resolved.", args.Path)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not resolve the asset path: %v. Please ensure Logseq is running with a graph open.", err)), nil
+	}
+	return mcp.NewToolResultText(path), nil
+}
+
+func (s *MCPServer) handleServerInfo(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleServerInfo", zap.Any("req", req))
+	info := map[string]any{
+		"name":       "yalms",
+		"version":    Version,
+		"mode":       s.mode,
+		"transport":  "stdio",
+		"logseq_url": s.client.APIURL(),
+	}
+	jsonInfo, _ := json.MarshalIndent(info, "", "  ")
+	return mcp.NewToolResultText(string(jsonInfo)), nil
+}
+
+func (s *MCPServer) handleGetAppConfig(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetAppConfig", zap.Any("req", req))
+	configs, err := s.client.GetUserConfigs()
+	if err != nil {
+		s.logger.Error("handleGetAppConfig failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		if errors.Is(err, logseq.ErrUnsupported) {
+			return mcp.NewToolResultError("This Logseq version does not expose its user configuration over the HTTP API. Please upgrade Logseq to use this tool."), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the app configuration: %v. Please ensure Logseq is running.", err)), nil
+	}
+
+	jsonConfigs, _ := json.MarshalIndent(configs, "", "  ")
+	return mcp.NewToolResultText(string(jsonConfigs)), nil
+}
+
 func (s *MCPServer) handleQuery(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	s.logger.Debug("handleQuery", zap.Any("req", req))
 	var args struct {
-		Query string `json:"query"`
+		Query          string  `json:"query"`
+		TimeoutSeconds float64 `json:"timeout_seconds"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.Query == "" {
-		return mcp.NewToolResultError("A query string is required. Please provide a valid Datalog query (e.g., '[:find (pull ?p [*]) :where [?p :block/name]]')."), nil
+	if res := requireString("query", args.Query, "a valid Datalog query (e.g., '[:find (pull ?p [*]) :where [?p :block/name]]')"); res != nil {
+		return res, nil
+	}
+
+	var timeout time.Duration
+	if args.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(args.TimeoutSeconds*float64(time.Second)), s.maxQueryTimeout)
 	}
-	results, err := s.client.Query(args.Query)
+	results, err := s.client.QueryWithTimeout(timeout, args.Query)
 	if err != nil {
-		s.logger.Error("handleQuery failed", zap.Error(err))
+		s.logger.Error("handleQuery failed", zap.Duration("timeout", timeout), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return mcp.NewToolResultError(fmt.Sprintf("The query timed out: %v. Please narrow the query, or pass a larger timeout_seconds (at most %d).", err, int(s.maxQueryTimeout.Seconds()))), nil
+		}
 		return mcp.NewToolResultError(fmt.Sprintf("The query failed: %v. Please check your Datalog syntax or ensure the requested entities exist.", err)), nil
 	}
 
@@ -400,11 +1561,67 @@ func (s *MCPServer) handleQuery(ctx context.Context, req mcp.CallToolRequest) (*
 	return mcp.NewToolResultText(string(jsonResults)), nil
 }
 
+func (s *MCPServer) handleCount(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCount", zap.Any("req", req))
+	var args struct {
+		Tag      string `json:"tag"`
+		Property string `json:"property"`
+		Value    string `json:"value"`
+		Query    string `json:"query"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	filters := 0
+	for _, f := range []string{args.Tag, args.Property, args.Query} {
+		if f != "" {
+			filters++
+		}
+	}
+	if filters != 1 {
+		return mcp.NewToolResultError("Exactly one of 'tag', 'property' or 'query' is required. Please choose a single filter."), nil
+	}
+	if args.Value != "" && args.Property == "" {
+		return mcp.NewToolResultError("'value' can only be used together with 'property'."), nil
+	}
+
+	var count int
+	var err error
+	switch {
+	case args.Tag != "":
+		count, err = s.client.CountPagesByTag(args.Tag)
+	case args.Property != "":
+		key := args.Property
+		if s.mode == ModeOntological {
+			key = s.normalizeKey(key)
+		}
+		count, err = s.client.CountPagesByProperty(key, args.Value)
+	default:
+		count, err = s.client.CountQuery(args.Query)
+	}
+	if err != nil {
+		s.logger.Error("handleCount failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("The count failed: %v. Please check the filter or make sure the query's :find clause is a single aggregate like (count ?x).", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprint(count)), nil
+}
+
 func (s *MCPServer) handleListNamespaces(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	s.logger.Debug("handleListNamespaces", zap.Any("req", req))
 	namespaces, err := s.client.ListNamespaces()
 	if err != nil {
 		s.logger.Error("handleListNamespaces failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		if errors.Is(err, logseq.ErrQueryAPIDisabled) {
+			return mcp.NewToolResultError(fmt.Sprintf("Could not list namespaces: %v.", err)), nil
+		}
 		return mcp.NewToolResultError("Could not list namespaces. This may happen if the graph is empty or the API is unreachable."), nil
 	}
 
@@ -417,6 +1634,9 @@ func (s *MCPServer) handleGetDailyJournal(ctx context.Context, req mcp.CallToolR
 	page, err := s.client.GetDailyJournal()
 	if err != nil {
 		s.logger.Error("handleGetDailyJournal failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
 		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the daily journal page: %v. Please check if Logseq is running.", err)), nil
 	}
 	if page == nil {
@@ -427,649 +1647,3314 @@ func (s *MCPServer) handleGetDailyJournal(ctx context.Context, req mcp.CallToolR
 	return mcp.NewToolResultText(string(jsonResults)), nil
 }
 
-func (s *MCPServer) handleReadPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleReadPage", zap.Any("req", req))
-	var args struct {
-		UUID string `json:"uuid"`
-	}
-	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
-	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A UUID or page name is required. Please provide the unique identifier for the page you wish to read."), nil
-	}
-	page, err := s.client.GetPage(args.UUID)
+func (s *MCPServer) handleGetCurrentPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetCurrentPage", zap.Any("req", req))
+	page, err := s.client.GetCurrentPage()
 	if err != nil {
-		s.logger.Error("handleReadPage failed", zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the page: %v. Please ensure the UUID or name is correct and the page exists.", err)), nil
+		s.logger.Error("handleGetCurrentPage failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the current page: %v. Please check if Logseq is running.", err)), nil
 	}
 	if page == nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Page not found: '%s'. Please double-check the name or UUID. For namespaced pages, use the full path like 'Projects/MyTask'.", args.UUID)), nil
+		return mcp.NewToolResultError("No page is open in Logseq right now (e.g. the journals view is shown). Please ask the user to open the page, or provide its name."), nil
 	}
 
 	jsonPage, _ := json.MarshalIndent(page, "", "  ")
 	return mcp.NewToolResultText(string(jsonPage)), nil
 }
 
-func (s *MCPServer) handleCreatePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return s.handleCreateEntity(ctx, req)
+func (s *MCPServer) handleGetCurrentBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetCurrentBlock", zap.Any("req", req))
+	block, err := s.client.GetCurrentBlock()
+	if err != nil {
+		s.logger.Error("handleGetCurrentBlock failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the current block: %v. Please check if Logseq is running.", err)), nil
+	}
+	if block == nil {
+		return mcp.NewToolResultError("No block is being edited in Logseq right now. Please ask the user to click into the block, or provide its UUID."), nil
+	}
+
+	jsonBlock, _ := json.MarshalIndent(block, "", "  ")
+	return mcp.NewToolResultText(string(jsonBlock)), nil
 }
 
-func (s *MCPServer) handleCreateEntity(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleCreateEntity", zap.Any("req", req))
+func (s *MCPServer) handleOpenPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleOpenPage", zap.Any("req", req))
 	var args struct {
-		Name       string `json:"name"`
-		Namespace  string `json:"namespace"`
-		Properties string `json:"properties"`
+		NameOrUUID string `json:"nameOrUUID"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
-	}
-	if args.Name == "" {
-		return mcp.NewToolResultError("A name is required to create an entity. Please provide a title for the new page."), nil
+		return invalidArguments(err), nil
 	}
-
-	var props map[string]any
-	if args.Properties != "" {
-		if err := json.Unmarshal([]byte(args.Properties), &props); err != nil {
-			return mcp.NewToolResultError("The properties provided are not valid JSON. Please check your formatting and try again."), nil
-		}
-	} else {
-		props = make(map[string]any)
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the name or UUID of the page to open"); res != nil {
+		return res, nil
 	}
-
-	fullName := args.Name
-	if args.Namespace != "" {
-		fullName = args.Namespace + "/" + args.Name
+	if s.readOnly {
+		return mcp.NewToolResultText(fmt.Sprintf("Not opening '%s': the server runs with --read-only, which disables UI navigation.", args.NameOrUUID)), nil
 	}
 
-	if s.mode == ModeOntological {
-		// Qualified Name: Construct the title
-		if args.Namespace != "" {
-			fullName = args.Namespace + "/" + args.Name
+	page, err := s.client.OpenPage(args.NameOrUUID)
+	if err != nil {
+		s.logger.Error("handleOpenPage failed", zap.String("nameOrUUID", args.NameOrUUID), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
 		}
-
-		// Snake_Case: Convert all keys in the properties dict to snake_case
-		props = toSnakeCaseKeys(props)
+		return mcp.NewToolResultError(fmt.Sprintf("Could not open the page: %v. Please check if Logseq is running.", err)), nil
 	}
-
-	page, err := s.client.CreatePage(fullName, props, nil)
-	if err != nil {
-		s.logger.Error("handleCreateEntity failed", zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to create the entity: %v. Please ensure the name is valid and doesn't contain forbidden characters.", err)), nil
+	if page == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Page not found: '%s'. Please double-check the name or UUID.", args.NameOrUUID)), nil
 	}
-
-	return mcp.NewToolResultText(fmt.Sprintf("Entity created successfully: %s (UUID: %s). You should use this UUID for any further updates to this entity.", page.Name, page.UUID)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Opened page '%s'.", page.OriginalName)), nil
 }
 
-func toSnakeCaseKeys(m map[string]any) map[string]any {
-	newMap := make(map[string]any)
-	for k, v := range m {
-		newKey := toSnakeCase(k)
-		newMap[newKey] = v
+func (s *MCPServer) handleScrollToBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleScrollToBlock", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the UUID of the block to scroll to"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+	if s.readOnly {
+		return mcp.NewToolResultText(fmt.Sprintf("Not scrolling to %s: the server runs with --read-only, which disables UI navigation.", args.UUID)), nil
 	}
-	return newMap
-}
 
-func toSnakeCase(s string) string {
-	var res strings.Builder
-	for i, r := range s {
-		if i > 0 && r >= 'A' && r <= 'Z' {
-			res.WriteRune('_')
+	page, err := s.client.ScrollToBlock(args.UUID)
+	if err != nil {
+		s.logger.Error("handleScrollToBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
 		}
-		res.WriteRune(unicode.ToLower(r))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not scroll to the block: %v. Please check if Logseq is running.", err)), nil
 	}
-	return res.String()
+	if page == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Block not found: '%s'. Please double-check the UUID.", args.UUID)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Scrolled to block %s on page '%s'.", args.UUID, page.OriginalName)), nil
 }
 
-func (s *MCPServer) handleCreatePages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleCreatePages", zap.Any("req", req))
+func (s *MCPServer) handleAppendToJournal(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleAppendToJournal", zap.Any("req", req))
 	var args struct {
-		Pages string `json:"pages"`
+		Content string `json:"content"`
+		Date    string `json:"date"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
-	}
-	if args.Pages == "" {
-		return mcp.NewToolResultError("A list of pages (JSON array) is required. Please provide the names and optional properties for the pages you wish to create."), nil
+		return invalidArguments(err), nil
 	}
-
-	type PageReq struct {
-		Name       string         `json:"name"`
-		Properties map[string]any `json:"properties,omitempty"`
-	}
-
-	var pageReqs []PageReq
-	if err := json.Unmarshal([]byte(args.Pages), &pageReqs); err != nil {
-		return mcp.NewToolResultError("The pages list provided is not valid JSON. Please check your formatting and ensure it is a JSON array of page objects."), nil
+	if res := requireString("content", args.Content, "the text of the block to add to the journal"); res != nil {
+		return res, nil
 	}
 
-	count := 0
-	var errs []string
-
-	for _, req := range pageReqs {
-		if _, err := s.client.CreatePage(req.Name, req.Properties, nil); err != nil {
-			s.logger.Error("Failed to create page in handleCreatePages", zap.String("name", req.Name), zap.Error(err))
-			errs = append(errs, fmt.Sprintf("%s: %v", req.Name, err))
-		} else {
-			count++
+	var date time.Time
+	if args.Date != "" {
+		var err error
+		date, err = time.Parse("2006-01-02", args.Date)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid date '%s'. Please use the YYYY-MM-DD format (e.g., '2026-01-18').", args.Date)), nil
 		}
 	}
 
-	if len(errs) > 0 {
-		return mcp.NewToolResultError(fmt.Sprintf("Created %d pages, but failed for: %v. Please ensure all page names are valid.", count, errs)), nil
+	block, err := s.client.AppendToJournal(date, args.Content)
+	if err != nil {
+		s.logger.Error("handleAppendToJournal failed", zap.String("date", args.Date), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to append to the journal: %v. Please check if Logseq is running.", err)), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Successfully created %d pages.", count)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Block appended to journal (UUID: %s).", block.UUID)), nil
 }
 
-func (s *MCPServer) handleUpdatePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleUpdatePage", zap.Any("req", req))
+func (s *MCPServer) handleCreateJournalPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCreateJournalPage", zap.Any("req", req))
 	var args struct {
-		UUID       string `json:"uuid"`
-		Properties string `json:"properties"`
+		Date string `json:"date"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
-	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A UUID or page name is required. Please provide the unique identifier for the page you wish to update."), nil
-	}
-	if args.Properties == "" {
-		return mcp.NewToolResultError("Updated properties (JSON string) are required. Please provide the attributes you wish to modify."), nil
+		return invalidArguments(err), nil
 	}
 
-	var props map[string]any
-	if err := json.Unmarshal([]byte(args.Properties), &props); err != nil {
-		return mcp.NewToolResultError("The properties provided are not valid JSON. Please check your formatting and try again."), nil
+	var date time.Time
+	if args.Date != "" {
+		var err error
+		date, err = time.Parse("2006-01-02", args.Date)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid date '%s'. Please use the YYYY-MM-DD format (e.g., '2026-01-18').", args.Date)), nil
+		}
 	}
 
-	// First get the page to get its UUID
-	page, err := s.client.GetPage(args.UUID)
+	page, created, err := s.client.EnsureJournalPage(date)
 	if err != nil {
-		s.logger.Error("handleUpdatePage failed to get page", zap.String("uuid", args.UUID), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the page to update: %v. Please ensure the UUID is correct.", err)), nil
-	}
-	if page == nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Page not found: '%s'. Please double-check the name or UUID.", args.UUID)), nil
+		s.logger.Error("handleCreateJournalPage failed", zap.String("date", args.Date), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		if errors.Is(err, logseq.ErrNotAJournalPage) {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot create the journal page: %v. Please rename the existing page first.", err)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create the journal page: %v. Please check if Logseq is running.", err)), nil
 	}
 
-	updatedPage, err := s.client.UpdatePage(page.UUID, props)
-	if err != nil {
-		s.logger.Error("handleUpdatePage failed", zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to update the page: %v. Please ensure the properties are valid for this entity.", err)), nil
+	if !created {
+		return mcp.NewToolResultText(fmt.Sprintf("Journal page already existed: %s (UUID: %s). It was left unchanged.", page.Name, page.UUID)), nil
 	}
-
-	return mcp.NewToolResultText(fmt.Sprintf("Page updated successfully: %s", updatedPage.UUID)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Journal page created: %s (UUID: %s).", page.Name, page.UUID)), nil
 }
 
-func (s *MCPServer) handleDeletePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleDeletePage", zap.Any("req", req))
+func (s *MCPServer) handleReadPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleReadPage", zap.Any("req", req))
 	var args struct {
 		UUID string `json:"uuid"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A UUID or page name is required. Please provide the identifier for the page you wish to delete."), nil
+	if res := requireString("uuid", args.UUID, "the unique identifier for the page you wish to read"); res != nil {
+		return res, nil
 	}
-	if err := s.client.DeletePage(args.UUID); err != nil {
-		s.logger.Error("handleDeletePage failed", zap.String("uuid", args.UUID), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to delete the page: %v. Please ensure the identifier is correct.", err)), nil
+	page, err := s.client.ResolvePage(args.UUID)
+	if err != nil {
+		s.logger.Error("handleReadPage failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the page: %v. Please ensure the UUID or name is correct and the page exists.", err)), nil
 	}
-	return mcp.NewToolResultText(fmt.Sprintf("Page successfully deleted: %s", args.UUID)), nil
+	if page == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Page not found: '%s'. Please double-check the name or UUID. For namespaced pages, use the full path like 'Projects/MyTask'.", args.UUID)), nil
+	}
+
+	jsonPage, _ := json.MarshalIndent(page, "", "  ")
+	return mcp.NewToolResultText(string(jsonPage)), nil
 }
 
-func (s *MCPServer) handleDeletePages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleDeletePages", zap.Any("req", req))
+func (s *MCPServer) handleGetEntityByID(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetEntityByID", zap.Any("req", req))
 	var args struct {
-		UUIDs string `json:"uuids"`
+		Key   string `json:"key"`
+		Value string `json:"value"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUIDs == "" {
-		return mcp.NewToolResultError("A list of UUIDs or names is required. Please provide a JSON array of page identifiers to delete."), nil
+	if res := requireString("key", args.Key, "the name of the identifying attribute"); res != nil {
+		return res, nil
 	}
-
-	var uuids []string
-	if err := json.Unmarshal([]byte(args.UUIDs), &uuids); err != nil {
-		return mcp.NewToolResultError("The list of identifiers provided is not valid JSON. Please check your formatting and ensure it is a JSON array of strings."), nil
+	if res := requireString("value", args.Value, "the identifier to look up"); res != nil {
+		return res, nil
 	}
 
-	count := 0
-	var errs []string
+	key := args.Key
+	if s.mode == ModeOntological {
+		key = s.normalizeKey(key)
+	}
 
-	for _, uuid := range uuids {
-		if err := s.client.DeletePage(uuid); err != nil {
-			s.logger.Error("Failed to delete page in handleDeletePages", zap.String("uuid", uuid), zap.Error(err))
-			errs = append(errs, fmt.Sprintf("%s: %v", uuid, err))
-		} else {
-			count++
+	page, err := s.client.FindPageByUniqueProperty(key, args.Value)
+	if err != nil {
+		s.logger.Error("handleGetEntityByID failed", zap.String("key", key), zap.Error(err))
+		if errors.Is(err, logseq.ErrAmbiguousMatch) {
+			return mcp.NewToolResultError(fmt.Sprintf("Ambiguous identifier: %v. The property '%s' is not unique; please use a more specific key or the page UUID.", err, key)), nil
 		}
+		if errors.Is(err, logseq.ErrInvalidPropertyKey) {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot look up the entity: %v. Please use a plain property key such as 'isbn'.", err)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not look up the entity: %v. Please ensure Logseq is running.", err)), nil
 	}
-
-	if len(errs) > 0 {
-		return mcp.NewToolResultError(fmt.Sprintf("Deleted %d pages, but failed for: %v. Please verify the remaining identifiers are correct.", count, errs)), nil
+	if page == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("No entity found with %s = '%s'. Please double-check the key and value.", key, args.Value)), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Successfully deleted %d pages.", count)), nil
+	jsonPage, _ := json.MarshalIndent(page, "", "  ")
+	return mcp.NewToolResultText(string(jsonPage)), nil
 }
 
-func (s *MCPServer) handleRenamePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleRenamePage", zap.Any("req", req))
+func (s *MCPServer) handleSearchAll(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleSearchAll", zap.Any("req", req))
 	var args struct {
-		UUID    string `json:"uuid"`
-		NewName string `json:"new_name"`
+		Term  string `json:"term"`
+		Limit int    `json:"limit"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
+	}
+	if res := requireString("term", strings.TrimSpace(args.Term), "the text to look for"); res != nil {
+		return res, nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A UUID or current page name is required. Please provide the identifier for the page you wish to rename."), nil
+	if args.Limit < 0 {
+		return mcp.NewToolResultError("The 'limit' parameter must not be negative."), nil
 	}
-	if args.NewName == "" {
-		return mcp.NewToolResultError("A new name is required. Please provide the target title for the page."), nil
+	if args.Limit == 0 {
+		args.Limit = DefaultSearchLimit
 	}
 
-	// Resolve UUID if name provided
-	page, err := s.client.GetPage(args.UUID)
+	hits, err := s.client.SearchAll(args.Term, args.Limit)
 	if err != nil {
-		s.logger.Error("handleRenamePage failed to get page", zap.String("uuid", args.UUID), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the page to rename: %v. Please ensure the current identifier is correct.", err)), nil
-	}
-	if page == nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Page not found: '%s'. Please double-check the current name or UUID.", args.UUID)), nil
+		s.logger.Error("handleSearchAll failed", zap.String("term", args.Term), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not search the graph: %v. Please ensure Logseq is running.", err)), nil
 	}
 
-	if err := s.client.RenamePage(page.UUID, args.NewName); err != nil {
-		s.logger.Error("handleRenamePage failed", zap.String("uuid", page.UUID), zap.String("new_name", args.NewName), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to rename the page: %v. Please ensure the new name is valid and not already in use.", err)), nil
-	}
-	return mcp.NewToolResultText(fmt.Sprintf("Page successfully renamed to: %s", args.NewName)), nil
+	jsonHits, _ := json.MarshalIndent(hits, "", "  ")
+	return mcp.NewToolResultText(string(jsonHits)), nil
 }
 
-func (s *MCPServer) handleReadNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleReadNamespace", zap.Any("req", req))
+func (s *MCPServer) handleFindBlocksByProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleFindBlocksByProperty", zap.Any("req", req))
 	var args struct {
-		Namespace string `json:"namespace"`
+		Key   string `json:"key"`
+		Value string `json:"value"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
+	}
+	if res := requireString("key", args.Key, "the name of the property to match"); res != nil {
+		return res, nil
 	}
-	if args.Namespace == "" {
-		return mcp.NewToolResultError("A namespace name is required. Please provide the category (e.g., 'Projects') you wish to list."), nil
+	if res := requireString("value", args.Value, "the value to match"); res != nil {
+		return res, nil
 	}
 
-	pages, err := s.client.GetNamespacePages(args.Namespace)
+	key := args.Key
+	if s.mode == ModeOntological {
+		key = s.normalizeKey(key)
+	}
+
+	blocks, err := s.client.FindBlocksByProperty(key, args.Value)
 	if err != nil {
-		s.logger.Error("handleReadNamespace failed", zap.String("namespace", args.Namespace), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve pages for namespace '%s': %v. Please ensure the namespace exists.", args.Namespace, err)), nil
+		s.logger.Error("handleFindBlocksByProperty failed", zap.String("key", key), zap.Error(err))
+		if errors.Is(err, logseq.ErrInvalidPropertyKey) {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot search blocks: %v. Please use a plain property key such as 'status'.", err)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not search blocks: %v. Please ensure Logseq is running.", err)), nil
 	}
 
-	jsonPages, _ := json.MarshalIndent(pages, "", "  ")
-	return mcp.NewToolResultText(string(jsonPages)), nil
-}
+	type blockMatch struct {
+		UUID    string `json:"uuid"`
+		Content string `json:"content"`
+		Page    string `json:"page"`
+	}
+	matches := make([]blockMatch, len(blocks))
+	for i, b := range blocks {
+		matches[i] = blockMatch{UUID: b.UUID, Content: b.Content, Page: b.Page.Name}
+	}
+
+	jsonMatches, _ := json.MarshalIndent(matches, "", "  ")
+	return mcp.NewToolResultText(string(jsonMatches)), nil
+}
+
+func (s *MCPServer) handlePageExists(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handlePageExists", zap.Any("req", req))
+	var args struct {
+		NameOrUUID string `json:"nameOrUUID"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the identifier of the page to check"); res != nil {
+		return res, nil
+	}
+
+	page, err := s.client.ResolvePage(args.NameOrUUID)
+	if err != nil {
+		s.logger.Error("handlePageExists failed", zap.String("page", args.NameOrUUID), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not check the page: %v. Please ensure Logseq is running.", err)), nil
+	}
+
+	result := struct {
+		Exists bool   `json:"exists"`
+		UUID   string `json:"uuid,omitempty"`
+	}{Exists: page != nil}
+	if page != nil {
+		result.UUID = page.UUID
+	}
+
+	jsonResult, _ := json.Marshal(result)
+	return mcp.NewToolResultText(string(jsonResult)), nil
+}
+
+func (s *MCPServer) handlePreviewLinks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handlePreviewLinks", zap.Any("req", req))
+	var args struct {
+		Content    string `json:"content"`
+		Properties string `json:"properties"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if args.Content == "" && args.Properties == "" {
+		return mcp.NewToolResultError("Content or properties are required. Please provide the text whose links you wish to preview."), nil
+	}
+
+	var props map[string]any
+	if args.Properties != "" {
+		if res := requireJSON("properties", args.Properties, &props, "a JSON object mapping property keys to values"); res != nil {
+			return res, nil
+		}
+	}
+
+	preview, err := s.client.PreviewLinkedPages(args.Content, props)
+	if err != nil {
+		s.logger.Error("handlePreviewLinks failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not check the linked pages: %v. Please ensure Logseq is running.", err)), nil
+	}
+
+	jsonPreview, _ := json.MarshalIndent(preview, "", "  ")
+	return mcp.NewToolResultText(string(jsonPreview)), nil
+}
+
+func (s *MCPServer) handleExportGraph(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleExportGraph", zap.Any("req", req))
+	var args struct {
+		IncludeJournals *bool `json:"include_journals"`
+		Limit           int   `json:"limit"`
+		Offset          int   `json:"offset"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if args.Limit < 0 || args.Offset < 0 {
+		return mcp.NewToolResultError("The 'limit' and 'offset' parameters must not be negative."), nil
+	}
+
+	opts := logseq.ExportOptions{IncludeJournals: true, Offset: args.Offset, Limit: args.Limit}
+	if args.IncludeJournals != nil {
+		opts.IncludeJournals = *args.IncludeJournals
+	}
+
+	export, err := s.client.ExportGraph(opts)
+	if err != nil {
+		s.logger.Error("handleExportGraph failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not export the graph: %v. Please ensure Logseq is running, or export fewer pages at a time with 'limit'.", err)), nil
+	}
+
+	jsonExport, _ := json.Marshal(export)
+	summary := fmt.Sprintf("Exported %d of %d pages (%d bytes).", len(export.Pages), export.Total, len(jsonExport))
+	return mcp.NewToolResultText(summary + "\n" + string(jsonExport)), nil
+}
+
+func (s *MCPServer) handleImportGraph(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleImportGraph", zap.Any("req", req))
+	var args struct {
+		Data         string `json:"data"`
+		SkipExisting bool   `json:"skip_existing"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	// Accept the export_graph output as-is, including its summary line
+	data := strings.TrimSpace(args.Data)
+	if !strings.HasPrefix(data, "{") {
+		if _, rest, ok := strings.Cut(data, "\n"); ok {
+			data = rest
+		}
+	}
+	var export logseq.GraphExport
+	if data == "" || json.Unmarshal([]byte(data), &export) != nil {
+		return mcp.NewToolResultError("The data provided is not a valid export. Please pass the JSON document returned by export_graph."), nil
+	}
+
+	type importedPage struct {
+		Name   string `json:"name"`
+		UUID   string `json:"uuid"`
+		Status string `json:"status"` // created, merged or skipped
+	}
+	items := make([]importedPage, len(export.Pages))
+	results := logseq.RunBatch(len(export.Pages), s.batchConcurrency, func(i int) error {
+		page, status, err := s.client.ImportPage(export.Pages[i], args.SkipExisting)
+		if err != nil {
+			return err
+		}
+		items[i] = importedPage{Name: export.Pages[i].Name, UUID: page.UUID, Status: status}
+		return nil
+	})
+
+	counts := make(map[string]int)
+	succeeded := []importedPage{}
+	var failed []batchItemError
+	for i, err := range results {
+		if err != nil {
+			s.logger.Error("Failed to import page in handleImportGraph", zap.String("name", export.Pages[i].Name), zap.Error(err))
+			failed = append(failed, batchItemError{Index: i, Identifier: export.Pages[i].Name, Error: err.Error()})
+			continue
+		}
+		counts[items[i].Status]++
+		succeeded = append(succeeded, items[i])
+	}
+
+	summary := fmt.Sprintf("Imported %d pages: %d created, %d merged, %d skipped.", len(export.Pages), counts[logseq.ImportCreated], counts[logseq.ImportMerged], counts[logseq.ImportSkipped])
+	if refs := export.InternalRefs(); refs > 0 {
+		summary += fmt.Sprintf(" %d block reference(s) between pages of the dump now dangle because imported blocks get new UUIDs.", refs)
+	}
+	if len(failed) > 0 {
+		return batchFailureResult(fmt.Sprintf("%d of %d pages could not be imported. %s", len(failed), len(export.Pages), summary), succeeded, failed), nil
+	}
+
+	jsonItems, _ := json.MarshalIndent(succeeded, "", "  ")
+	return mcp.NewToolResultText(summary + "\n" + string(jsonItems)), nil
+}
+
+func (s *MCPServer) handleListProperties(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleListProperties", zap.Any("req", req))
+
+	properties, err := s.client.ListAllProperties()
+	if err != nil {
+		s.logger.Error("handleListProperties failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not list the properties: %v. Please check if Logseq is running.", err)), nil
+	}
+
+	jsonProps, _ := json.MarshalIndent(properties, "", "  ")
+	return mcp.NewToolResultText(string(jsonProps)), nil
+}
+
+func (s *MCPServer) handleRecentPages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRecentPages", zap.Any("req", req))
+	var args struct {
+		Limit int `json:"limit"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if args.Limit < 0 {
+		return mcp.NewToolResultError("The 'limit' parameter must not be negative."), nil
+	}
+	if args.Limit == 0 {
+		args.Limit = DefaultRecentPagesLimit
+	}
+
+	pages, err := s.client.GetRecentlyEditedPages(args.Limit)
+	if err != nil {
+		s.logger.Error("handleRecentPages failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not list recently edited pages: %v. Please check if Logseq is running.", err)), nil
+	}
+
+	type recentPage struct {
+		Name      string `json:"name"`
+		UUID      string `json:"uuid"`
+		UpdatedAt string `json:"updated_at"`
+	}
+	recent := make([]recentPage, len(pages))
+	for i, p := range pages {
+		name := p.OriginalName
+		if name == "" {
+			name = p.Name
+		}
+		recent[i] = recentPage{Name: name, UUID: p.UUID, UpdatedAt: time.UnixMilli(p.UpdatedAt).UTC().Format(time.RFC3339)}
+	}
+
+	jsonRecent, _ := json.MarshalIndent(recent, "", "  ")
+	return mcp.NewToolResultText(string(jsonRecent)), nil
+}
+
+func (s *MCPServer) handleReadPages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleReadPages", zap.Any("req", req))
+	var args struct {
+		Names string `json:"names"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	var names []string
+	if res := requireJSON("names", args.Names, &names, "a JSON array of page names or UUIDs"); res != nil {
+		return res, nil
+	}
+
+	pages, err := s.client.GetPages(names)
+	if err != nil {
+		s.logger.Error("handleReadPages failed", zap.Strings("names", names), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the pages: %v.", err)), nil
+	}
+
+	type pageResult struct {
+		Name  string       `json:"name"`
+		Found bool         `json:"found"`
+		Page  *logseq.Page `json:"page,omitempty"`
+	}
+	results := make([]pageResult, len(names))
+	for i, name := range names {
+		results[i] = pageResult{Name: name, Found: pages[i] != nil, Page: pages[i]}
+	}
+
+	jsonResults, _ := json.MarshalIndent(results, "", "  ")
+	return mcp.NewToolResultText(string(jsonResults)), nil
+}
+
+func (s *MCPServer) handleGetPageOutline(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetPageOutline", zap.Any("req", req))
+	var args struct {
+		NameOrUUID string `json:"nameOrUUID"`
+		Depth      int    `json:"depth"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the unique identifier for the page you wish to outline"); res != nil {
+		return res, nil
+	}
+	if args.Depth < 0 {
+		return mcp.NewToolResultError("The 'depth' parameter must be at least 1."), nil
+	}
+
+	outline, err := s.client.GetPageOutline(args.NameOrUUID, args.Depth)
+	if err != nil {
+		s.logger.Error("handleGetPageOutline failed", zap.String("page", args.NameOrUUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not outline the page: %v. Please ensure the UUID or name is correct and the page exists.", err)), nil
+	}
+
+	jsonOutline, _ := json.MarshalIndent(outline, "", "  ")
+	return mcp.NewToolResultText(string(jsonOutline)), nil
+}
+
+func (s *MCPServer) handleGetPageAssets(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetPageAssets", zap.Any("req", req))
+	var args struct {
+		NameOrUUID string `json:"nameOrUUID"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the unique identifier for the page whose assets you wish to list"); res != nil {
+		return res, nil
+	}
+
+	assets, err := s.client.GetPageAssets(args.NameOrUUID)
+	if err != nil {
+		s.logger.Error("handleGetPageAssets failed", zap.String("page", args.NameOrUUID), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not list the page assets: %v. Please ensure the UUID or name is correct and the page exists.", err)), nil
+	}
+
+	jsonAssets, _ := json.MarshalIndent(assets, "", "  ")
+	return mcp.NewToolResultText(string(jsonAssets)), nil
+}
+
+func (s *MCPServer) handleCollapsePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCollapsePage", zap.Any("req", req))
+	var args struct {
+		NameOrUUID string `json:"nameOrUUID"`
+		Collapsed  *bool  `json:"collapsed"`
+		Depth      int    `json:"depth"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the unique identifier for the page you wish to collapse or expand"); res != nil {
+		return res, nil
+	}
+	if args.Collapsed == nil {
+		return mcp.NewToolResultError("The 'collapsed' parameter is required. Please pass true to collapse or false to expand."), nil
+	}
+	if args.Depth < 0 {
+		return mcp.NewToolResultError("The 'depth' parameter must be at least 1."), nil
+	}
+
+	count, err := s.client.CollapsePage(args.NameOrUUID, *args.Collapsed, args.Depth)
+	if err != nil {
+		s.logger.Error("handleCollapsePage failed", zap.String("page", args.NameOrUUID), zap.Int("toggled", count), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not toggle the page's blocks after %d block(s): %v. Please ensure the UUID or name is correct and the page exists.", count, err)), nil
+	}
+
+	action := "Collapsed"
+	if !*args.Collapsed {
+		action = "Expanded"
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s %d block(s) on page %s", action, count, args.NameOrUUID)), nil
+}
+
+func (s *MCPServer) handleReplaceInPage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleReplaceInPage", zap.Any("req", req))
+	var args struct {
+		NameOrUUID    string `json:"nameOrUUID"`
+		Find          string `json:"find"`
+		Replace       string `json:"replace"`
+		CaseSensitive *bool  `json:"case_sensitive"`
+		WholeWord     bool   `json:"whole_word"`
+		IncludeRefs   bool   `json:"include_refs"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the identifier of the page to edit"); res != nil {
+		return res, nil
+	}
+	if res := requireString("find", args.Find, "the text you wish to replace"); res != nil {
+		return res, nil
+	}
+
+	opts := logseq.ReplaceOptions{
+		Find:          args.Find,
+		Replace:       args.Replace,
+		CaseSensitive: args.CaseSensitive == nil || *args.CaseSensitive,
+		WholeWord:     args.WholeWord,
+		IncludeRefs:   args.IncludeRefs,
+	}
+	changed, err := s.client.ReplaceInPage(args.NameOrUUID, opts)
+	if err != nil {
+		s.logger.Error("handleReplaceInPage failed", zap.String("page", args.NameOrUUID), zap.Int("changed", changed), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not replace the text after changing %d block(s): %v. Please ensure the UUID or name is correct and the page exists.", changed, err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Replaced '%s' with '%s' in %d block(s) on page %s", args.Find, args.Replace, changed, args.NameOrUUID)), nil
+}
+
+func (s *MCPServer) handleGetPageSize(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetPageSize", zap.Any("req", req))
+	var args struct {
+		NameOrUUID string `json:"nameOrUUID"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the unique identifier for the page you wish to measure"); res != nil {
+		return res, nil
+	}
+
+	size, err := s.client.GetPageSize(args.NameOrUUID)
+	if err != nil {
+		s.logger.Error("handleGetPageSize failed", zap.String("page", args.NameOrUUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not measure the page: %v. Please ensure the UUID or name is correct and the page exists.", err)), nil
+	}
+
+	jsonSize, _ := json.MarshalIndent(size, "", "  ")
+	return mcp.NewToolResultText(string(jsonSize)), nil
+}
+
+func (s *MCPServer) handleGetPageProperties(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetPageProperties", zap.Any("req", req))
+	var args struct {
+		UUID    string `json:"uuid"`
+		Display bool   `json:"display"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the unique identifier for the page you wish to inspect"); res != nil {
+		return res, nil
+	}
+	page, err := s.client.ResolvePage(args.UUID)
+	if err != nil {
+		s.logger.Error("handleGetPageProperties failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the page: %v. Please ensure the UUID or name is correct and the page exists.", err)), nil
+	}
+	if page == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Page not found: '%s'. Please double-check the name or UUID.", args.UUID)), nil
+	}
+
+	props := make(map[string]any, len(page.Properties))
+	for k, v := range page.Properties {
+		if args.Display {
+			k = fromSnakeCase(k)
+		}
+		props[k] = v
+	}
+
+	jsonProps, _ := json.MarshalIndent(props, "", "  ")
+	return mcp.NewToolResultText(string(jsonProps)), nil
+}
+
+func (s *MCPServer) handleCreatePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.handleCreateEntity(ctx, req)
+}
+
+func (s *MCPServer) handleCreateEntity(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCreateEntity", zap.Any("req", req))
+	var args struct {
+		Name             string `json:"name"`
+		Namespace        string `json:"namespace"`
+		Properties       string `json:"properties"`
+		CustomUUID       string `json:"custom_uuid"`
+		CreateFirstBlock *bool  `json:"create_first_block"`
+		Options          string `json:"options"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("name", args.Name, "a title for the new page"); res != nil {
+		return res, nil
+	}
+	var rawOptions map[string]any
+	if args.Options != "" {
+		if res := requireJSON("options", args.Options, &rawOptions, "a JSON object of createPage options"); res != nil {
+			return res, nil
+		}
+	}
+	options, err := s.pageCreateOptions(rawOptions, args.CustomUUID, args.CreateFirstBlock)
+	if err != nil {
+		s.logger.Error("handleCreateEntity rejected options", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Cannot create the entity: %v. Please provide an unused UUID such as 6571c5b8-1f2a-4c3d-9e8f-0a1b2c3d4e5f, or omit custom_uuid.", err)), nil
+	}
+
+	var props map[string]any
+	if args.Properties != "" {
+		if res := requireJSON("properties", args.Properties, &props, "a JSON object mapping property keys to values"); res != nil {
+			return res, nil
+		}
+	} else {
+		props = make(map[string]any)
+	}
+
+	fullName := args.Name
+	if args.Namespace != "" {
+		fullName = args.Namespace + "/" + args.Name
+	}
+
+	if s.mode == ModeOntological {
+		// Qualified Name: Construct the title
+		if args.Namespace != "" {
+			fullName = args.Namespace + "/" + args.Name
+		}
+
+		// Normalize all keys in the properties dict to the configured key style
+		props = s.normalizeKeys(props)
+	}
+
+	page, created, err := s.client.EnsurePage(fullName, s.coerceProperties(props), options)
+	if err != nil {
+		s.logger.Error("handleCreateEntity failed", zap.Error(err))
+		if errors.Is(err, logseq.ErrInvalidPageName) {
+			return mcp.NewToolResultError(fmt.Sprintf("The name '%s' cannot be used: %v. Please choose a different name.", fullName, err)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create the entity: %v. Please ensure Logseq is running.", err)), nil
+	}
+
+	if !created {
+		if len(props) > 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("Entity already existed: %s (UUID: %s). It was not recreated; the supplied properties were merged into the existing page.", page.Name, page.UUID)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Entity already existed: %s (UUID: %s). It was left unchanged.", page.Name, page.UUID)), nil
+	}
+	msg := fmt.Sprintf("Entity created successfully: %s (UUID: %s). You should use this UUID for any further updates to this entity.", page.Name, page.UUID)
+	if customUUID, _ := options["customUUID"].(string); customUUID != "" && !strings.EqualFold(page.UUID, customUUID) {
+		msg += fmt.Sprintf(" Note: this Logseq version ignored custom_uuid '%s'.", customUUID)
+	}
+	return mcp.NewToolResultText(msg), nil
+}
+
+// pageCreateOptions builds the createPage options bag from a copy of the raw options argument
+// and the optional custom_uuid and create_first_block arguments, which take precedence. A
+// customUUID from either source must be well-formed and unused. It returns nil when nothing is set. Logseq honors createFirstBlock in all versions; customUUID
+// is only honored by newer releases.
+func (s *MCPServer) pageCreateOptions(base map[string]any, customUUID string, createFirstBlock *bool) (map[string]any, error) {
+	var options map[string]any
+	if len(base) > 0 {
+		options = make(map[string]any, len(base))
+		for k, v := range base {
+			options[k] = v
+		}
+	}
+	if createFirstBlock != nil {
+		if options == nil {
+			options = make(map[string]any)
+		}
+		options["createFirstBlock"] = *createFirstBlock
+	}
+	if customUUID == "" {
+		// A customUUID passed through options gets the same checks as custom_uuid
+		raw, ok := options["customUUID"]
+		if !ok {
+			return options, nil
+		}
+		if customUUID, ok = raw.(string); !ok {
+			return nil, fmt.Errorf("options.customUUID must be a string, got %v", raw)
+		}
+	}
+	if !logseq.IsUUID(customUUID) {
+		return nil, fmt.Errorf("custom_uuid '%s' is not a well-formed UUID", customUUID)
+	}
+	existing, err := s.client.GetPage(customUUID)
+	if err != nil {
+		return nil, fmt.Errorf("could not check whether custom_uuid '%s' is in use: %w", customUUID, err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("custom_uuid '%s' is already in use by the page '%s'", customUUID, existing.OriginalName)
+	}
+	if options == nil {
+		options = make(map[string]any)
+	}
+	options["customUUID"] = strings.ToLower(customUUID)
+	return options, nil
+}
+
+func (s *MCPServer) handleCreatePageTree(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCreatePageTree", zap.Any("req", req))
+	var args struct {
+		Name       string `json:"name"`
+		Namespace  string `json:"namespace"`
+		Properties string `json:"properties"`
+		Tree       string `json:"tree"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("name", args.Name, "a title for the new page"); res != nil {
+		return res, nil
+	}
+
+	props := make(map[string]any)
+	if args.Properties != "" {
+		if res := requireJSON("properties", args.Properties, &props, "a JSON object mapping property keys to values"); res != nil {
+			return res, nil
+		}
+	}
+	var batch []logseq.BlockContent
+	if res := requireJSON("tree", args.Tree, &batch, "a JSON array of BlockContent objects, using nested 'children' for the hierarchy"); res != nil {
+		return res, nil
+	}
+
+	fullName := args.Name
+	if args.Namespace != "" {
+		fullName = args.Namespace + "/" + args.Name
+	}
+
+	if s.mode == ModeOntological {
+		props = s.normalizeKeys(props)
+		var transformTree func([]logseq.BlockContent)
+		transformTree = func(blocks []logseq.BlockContent) {
+			for i := range blocks {
+				blocks[i].Properties = s.normalizeKeys(blocks[i].Properties)
+				if len(blocks[i].Children) > 0 {
+					transformTree(blocks[i].Children)
+				}
+			}
+		}
+		transformTree(batch)
+	}
+
+	s.coerceTree(batch)
+	page, err := s.client.CreatePage(fullName, s.coerceProperties(props), nil)
+	if err != nil {
+		s.logger.Error("handleCreatePageTree failed to create page", zap.String("name", fullName), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create the page: %v. Please ensure the name is valid.", err)), nil
+	}
+
+	blocks, err := s.client.InsertBatchBlock(page.UUID, batch, nil)
+	if err != nil {
+		s.logger.Error("handleCreatePageTree failed to insert tree", zap.String("page", page.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("The page %s (UUID: %s) was created, but inserting the block tree failed: %v. Please retry with create_block_tree targeting the page UUID.", page.Name, page.UUID, err)), nil
+	}
+
+	result := map[string]any{
+		"page_uuid":   page.UUID,
+		"block_uuids": collectBlockUUIDs(blocks),
+	}
+	jsonResult, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(jsonResult)), nil
+}
+
+// collectBlockUUIDs lists the UUIDs of the blocks and any expanded children, in document order
+func collectBlockUUIDs(blocks []logseq.Block) []string {
+	uuids := []string{}
+	for _, b := range blocks {
+		if b.UUID != "" {
+			uuids = append(uuids, b.UUID)
+		}
+		uuids = append(uuids, collectBlockUUIDs(logseq.NestedBlocks(b))...)
+	}
+	return uuids
+}
+
+func (s *MCPServer) handleUpsertEntity(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleUpsertEntity", zap.Any("req", req))
+	var args struct {
+		MatchKey   string `json:"match_key"`
+		MatchValue string `json:"match_value"`
+		Name       string `json:"name"`
+		Namespace  string `json:"namespace"`
+		Properties string `json:"properties"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("match_key", args.MatchKey, "the identifying property to match existing Instances on"); res != nil {
+		return res, nil
+	}
+	if res := requireString("match_value", args.MatchValue, "the value of the identifying property"); res != nil {
+		return res, nil
+	}
+	if res := requireString("name", args.Name, "a title for the page"); res != nil {
+		return res, nil
+	}
+
+	props := make(map[string]any)
+	if args.Properties != "" {
+		if res := requireJSON("properties", args.Properties, &props, "a JSON object mapping property keys to values"); res != nil {
+			return res, nil
+		}
+	}
+
+	matchKey := args.MatchKey
+	if s.mode == ModeOntological {
+		matchKey = s.normalizeKey(matchKey)
+		props = s.normalizeKeys(props)
+	}
+	// The identifying property is always written so later upserts match again
+	props[matchKey] = args.MatchValue
+
+	existing, err := s.client.FindPageByUniqueProperty(matchKey, args.MatchValue)
+	if err != nil {
+		s.logger.Error("handleUpsertEntity lookup failed", zap.String("key", matchKey), zap.Error(err))
+		if errors.Is(err, logseq.ErrAmbiguousMatch) {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot upsert: %v. Please resolve the duplicates or use a more specific key.", err)), nil
+		}
+		if errors.Is(err, logseq.ErrInvalidPropertyKey) {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot upsert: %v. Please use a plain property key for match_key.", err)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not look up existing entities: %v. Please ensure Logseq is running.", err)), nil
+	}
+
+	if existing != nil {
+		if _, err := s.client.UpdatePage(existing.UUID, s.coerceProperties(props)); err != nil {
+			s.logger.Error("handleUpsertEntity update failed", zap.String("uuid", existing.UUID), zap.Error(err))
+			return mcp.NewToolResultError(fmt.Sprintf("Found the entity %s but failed to update it: %v.", existing.UUID, err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Entity updated: %s (UUID: %s)", existing.Name, existing.UUID)), nil
+	}
+
+	fullName := args.Name
+	if args.Namespace != "" {
+		fullName = args.Namespace + "/" + args.Name
+	}
+	page, err := s.client.CreatePage(fullName, s.coerceProperties(props), nil)
+	if err != nil {
+		s.logger.Error("handleUpsertEntity create failed", zap.String("name", fullName), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("No existing entity matched, but creating '%s' failed: %v.", fullName, err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Entity created: %s (UUID: %s). You should use this UUID for any further updates to this entity.", page.Name, page.UUID)), nil
+}
+
+// notUUIDMessage explains that a strict block UUID was expected, e.g. when a page name was passed by mistake
+func notUUIDMessage(value string) string {
+	return fmt.Sprintf("'%s' doesn't look like a block UUID (expected 36 characters like '6571c5b8-1f2a-4c3d-9e8f-0a1b2c3d4e5f'). To work with a page by name, use the page tools instead.", value)
+}
+
+// unauthorizedMessage is returned when the Logseq API rejects the configured token
+const unauthorizedMessage = "Logseq rejected the API token (401 Unauthorized). Please check your LOGSEQ_TOKEN matches an authorization token configured in Logseq's HTTP API server settings."
+
+// normalizeKey applies the configured key style in ontological mode. Callers check the mode.
+func (s *MCPServer) normalizeKey(key string) string {
+	return normalizeKey(s.keyStyle, key)
+}
+
+func (s *MCPServer) normalizeKeys(m map[string]any) map[string]any {
+	newMap := make(map[string]any)
+	for k, v := range m {
+		newKey := s.normalizeKey(k)
+		newMap[newKey] = v
+	}
+	return newMap
+}
+
+// coerceValue applies logseq.CoercePropertyValue to a user-supplied property value when WithPropertyTypeCoercion is set
+func (s *MCPServer) coerceValue(v any) any {
+	if !s.coercePropertyTypes {
+		return v
+	}
+	return logseq.CoercePropertyValue(v)
+}
+
+// coerceProperties applies coerceValue to every value of user-supplied properties
+func (s *MCPServer) coerceProperties(m map[string]any) map[string]any {
+	if !s.coercePropertyTypes || len(m) == 0 {
+		return m
+	}
+	coerced := make(map[string]any, len(m))
+	for k, v := range m {
+		coerced[k] = s.coerceValue(v)
+	}
+	return coerced
+}
+
+// coerceTree applies coerceProperties to the properties of every block of a tree
+func (s *MCPServer) coerceTree(blocks []logseq.BlockContent) {
+	for i := range blocks {
+		blocks[i].Properties = s.coerceProperties(blocks[i].Properties)
+		s.coerceTree(blocks[i].Children)
+	}
+}
+
+// keyCase names the configured key style for tool descriptions
+func (s *MCPServer) keyCase() string {
+	switch s.keyStyle {
+	case KeyStyleKebab:
+		return "kebab-case"
+	case KeyStyleCamel:
+		return "camelCase"
+	case KeyStyleNone:
+		return "their given casing"
+	default:
+		return "snake_case"
+	}
+}
+
+func normalizeKey(style KeyStyle, key string) string {
+	switch style {
+	case KeyStyleKebab:
+		return toKebabCase(key)
+	case KeyStyleCamel:
+		return toCamelCase(key)
+	case KeyStyleNone:
+		return key
+	default:
+		return toSnakeCase(key)
+	}
+}
+
+func toSnakeCase(s string) string {
+	var res strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			res.WriteRune('_')
+		}
+		res.WriteRune(unicode.ToLower(r))
+	}
+	return res.String()
+}
+
+// toKebabCase works like toSnakeCase but joins words with '-', Logseq's convention for built-in properties
+func toKebabCase(s string) string {
+	var res strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '_':
+			res.WriteRune('-')
+		case i > 0 && r >= 'A' && r <= 'Z':
+			res.WriteRune('-')
+			res.WriteRune(unicode.ToLower(r))
+		default:
+			res.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return res.String()
+}
+
+// toCamelCase drops '_', '-' and ' ' separators and capitalizes the following letter. The first letter is lowercased.
+func toCamelCase(s string) string {
+	var res strings.Builder
+	upper := false
+	for _, r := range s {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			upper = res.Len() > 0
+		case upper:
+			res.WriteRune(unicode.ToUpper(r))
+			upper = false
+		case res.Len() == 0:
+			res.WriteRune(unicode.ToLower(r))
+		default:
+			res.WriteRune(r)
+		}
+	}
+	return res.String()
+}
+
+// fromSnakeCase turns a snake_case key into a space separated display form
+func fromSnakeCase(s string) string {
+	return strings.ReplaceAll(s, "_", " ")
+}
+
+func (s *MCPServer) handleCreatePages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCreatePages", zap.Any("req", req))
+	var args struct {
+		Pages   string `json:"pages"`
+		Options string `json:"options"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	type PageReq struct {
+		Name             string         `json:"name"`
+		Properties       map[string]any `json:"properties,omitempty"`
+		CustomUUID       string         `json:"custom_uuid,omitempty"`
+		CreateFirstBlock *bool          `json:"create_first_block,omitempty"`
+	}
+
+	var pageReqs []PageReq
+	if res := requireJSON("pages", args.Pages, &pageReqs, "a JSON array of page objects with a 'name' and optional 'properties'"); res != nil {
+		return res, nil
+	}
+	var rawOptions map[string]any
+	if args.Options != "" {
+		if res := requireJSON("options", args.Options, &rawOptions, "a JSON object of createPage options"); res != nil {
+			return res, nil
+		}
+	}
+
+	type PageResult struct {
+		Name   string `json:"name"`
+		UUID   string `json:"uuid,omitempty"`
+		Status string `json:"status"` // created, existed or error
+		Error  string `json:"error,omitempty"`
+	}
+
+	items := make([]PageResult, len(pageReqs))
+	results := logseq.RunBatch(len(pageReqs), s.batchConcurrency, func(i int) error {
+		items[i].Name = pageReqs[i].Name
+		options, err := s.pageCreateOptions(rawOptions, pageReqs[i].CustomUUID, pageReqs[i].CreateFirstBlock)
+		if err != nil {
+			return err
+		}
+		page, created, err := s.client.EnsurePage(pageReqs[i].Name, s.coerceProperties(pageReqs[i].Properties), options)
+		if err != nil {
+			return err
+		}
+		if page != nil {
+			items[i].UUID = page.UUID
+		}
+		if created {
+			items[i].Status = "created"
+		} else {
+			items[i].Status = "existed"
+		}
+		return nil
+	})
+
+	created, existed := 0, 0
+	succeeded := []PageResult{}
+	var failed []batchItemError
+	for i, err := range results {
+		if err != nil {
+			s.logger.Error("Failed to create page in handleCreatePages", zap.String("name", pageReqs[i].Name), zap.Error(err))
+			items[i].Status = "error"
+			items[i].Error = err.Error()
+			failed = append(failed, batchItemError{Index: i, Identifier: pageReqs[i].Name, Error: err.Error()})
+			continue
+		}
+		if items[i].Status == "created" {
+			created++
+		} else {
+			existed++
+		}
+		succeeded = append(succeeded, items[i])
+	}
+
+	summary := fmt.Sprintf("Created %d pages, %d already existed, %d failed.", created, existed, len(failed))
+
+	if len(failed) > 0 {
+		return batchFailureResult(summary+" Please ensure all page names are valid.", succeeded, failed), nil
+	}
+
+	jsonItems, _ := json.MarshalIndent(items, "", "  ")
+	return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary, string(jsonItems))), nil
+}
+
+func (s *MCPServer) handleImportCSV(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleImportCSV", zap.Any("req", req))
+	var args struct {
+		CSV        string `json:"csv"`
+		Namespace  string `json:"namespace"`
+		NameColumn string `json:"name_column"`
+		Tag        string `json:"tag"`
+		Delimiter  string `json:"delimiter"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("csv", args.CSV, "the data including a header row"); res != nil {
+		return res, nil
+	}
+	if res := requireString("namespace", args.Namespace, "the Class (e.g., 'Person') the rows should be created under"); res != nil {
+		return res, nil
+	}
+	if res := requireString("name_column", args.NameColumn, "the header of the column holding each Instance's name"); res != nil {
+		return res, nil
+	}
+
+	opts := logseq.CSVImportOptions{
+		Namespace:  args.Namespace,
+		NameColumn: args.NameColumn,
+		Tag:        args.Tag,
+	}
+	if args.Delimiter != "" {
+		runes := []rune(args.Delimiter)
+		if len(runes) != 1 {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid delimiter '%s'. Please provide a single character such as ',' or ';'.", args.Delimiter)), nil
+		}
+		opts.Delimiter = runes[0]
+	}
+	if s.mode == ModeOntological {
+		opts.KeyFunc = s.normalizeKey
+	}
+	if s.coercePropertyTypes {
+		opts.ValueFunc = logseq.CoercePropertyValue
+	}
+
+	result, err := s.client.ImportCSV(args.CSV, opts)
+	if err != nil {
+		s.logger.Error("handleImportCSV failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to import the CSV: %v. Please check the header row and delimiter.", err)), nil
+	}
+
+	jsonResult, _ := json.MarshalIndent(result, "", "  ")
+	if len(result.Errors) > 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Imported %d rows (%d created, %d already existed) with %d row errors:\n%s", result.Created+result.Existed, result.Created, result.Existed, len(result.Errors), string(jsonResult))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully imported %d rows into '%s': %d created, %d already existed.", result.Created+result.Existed, args.Namespace, result.Created, result.Existed)), nil
+}
+
+func (s *MCPServer) handleUpdatePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleUpdatePage", zap.Any("req", req))
+	var args struct {
+		UUID       string `json:"uuid"`
+		Properties string `json:"properties"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the unique identifier for the page you wish to update"); res != nil {
+		return res, nil
+	}
+
+	var props map[string]any
+	if res := requireJSON("properties", args.Properties, &props, "a JSON object mapping property keys to values"); res != nil {
+		return res, nil
+	}
+
+	// First get the page to get its UUID
+	page, err := s.client.GetPage(args.UUID)
+	if err != nil {
+		s.logger.Error("handleUpdatePage failed to get page", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the page to update: %v. Please ensure the UUID is correct.", err)), nil
+	}
+	if page == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Page not found: '%s'. Please double-check the name or UUID.", args.UUID)), nil
+	}
+
+	updatedPage, err := s.client.UpdatePage(page.UUID, s.coerceProperties(props))
+	if err != nil {
+		s.logger.Error("handleUpdatePage failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to update the page: %v. Please ensure the properties are valid for this entity.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Page updated successfully: %s", updatedPage.UUID)), nil
+}
+
+func (s *MCPServer) handleUpdateEntities(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleUpdateEntities", zap.Any("req", req))
+	var args struct {
+		Entities string `json:"entities"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	type EntityReq struct {
+		UUID       string         `json:"uuid"`
+		Properties map[string]any `json:"properties"`
+	}
+
+	var entityReqs []EntityReq
+	if res := requireJSON("entities", args.Entities, &entityReqs, "a JSON array of objects with 'uuid' and 'properties'"); res != nil {
+		return res, nil
+	}
+
+	count := 0
+	var errs []string
+
+	for _, req := range entityReqs {
+		if req.UUID == "" {
+			errs = append(errs, "missing uuid")
+			continue
+		}
+		if len(req.Properties) == 0 {
+			errs = append(errs, fmt.Sprintf("%s: no properties provided", req.UUID))
+			continue
+		}
+
+		page, err := s.client.GetPage(req.UUID)
+		if err != nil {
+			s.logger.Error("Failed to get page in handleUpdateEntities", zap.String("uuid", req.UUID), zap.Error(err))
+			errs = append(errs, fmt.Sprintf("%s: %v", req.UUID, err))
+			continue
+		}
+		if page == nil {
+			errs = append(errs, fmt.Sprintf("%s: not found", req.UUID))
+			continue
+		}
+
+		props := req.Properties
+		if s.mode == ModeOntological {
+			props = s.normalizeKeys(props)
+		}
+
+		if _, err := s.client.UpdatePage(page.UUID, s.coerceProperties(props)); err != nil {
+			s.logger.Error("Failed to update page in handleUpdateEntities", zap.String("uuid", req.UUID), zap.Error(err))
+			errs = append(errs, fmt.Sprintf("%s: %v", req.UUID, err))
+		} else {
+			count++
+		}
+	}
+
+	if len(errs) > 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Updated %d entities, but failed for: %v. Please verify the remaining identifiers and properties.", count, errs)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully updated %d entities.", count)), nil
+}
+
+func (s *MCPServer) handleDeletePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleDeletePage", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the page you wish to delete"); res != nil {
+		return res, nil
+	}
+	if err := s.client.DeletePage(args.UUID); err != nil {
+		s.logger.Error("handleDeletePage failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to delete the page: %v. Please ensure the identifier is correct.", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Page successfully deleted: %s", args.UUID)), nil
+}
+
+func (s *MCPServer) handleDeletePages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleDeletePages", zap.Any("req", req))
+	var args struct {
+		UUIDs string `json:"uuids"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	var uuids []string
+	if res := requireJSON("uuids", args.UUIDs, &uuids, "a JSON array of identifiers"); res != nil {
+		return res, nil
+	}
+
+	results := logseq.RunBatch(len(uuids), s.batchConcurrency, func(i int) error {
+		return s.client.DeletePage(uuids[i])
+	})
+
+	succeeded := []string{}
+	var failed []batchItemError
+
+	for i, err := range results {
+		if err != nil {
+			s.logger.Error("Failed to delete page in handleDeletePages", zap.String("uuid", uuids[i]), zap.Error(err))
+			failed = append(failed, batchItemError{Index: i, Identifier: uuids[i], Error: err.Error()})
+		} else {
+			succeeded = append(succeeded, uuids[i])
+		}
+	}
+	count := len(succeeded)
+
+	if len(failed) > 0 {
+		return batchFailureResult(fmt.Sprintf("Deleted %d pages, %d failed. Please verify the failed identifiers are correct.", count, len(failed)), succeeded, failed), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully deleted %d pages.", count)), nil
+}
+
+func (s *MCPServer) handleRenamePage(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRenamePage", zap.Any("req", req))
+	var args struct {
+		UUID    string `json:"uuid"`
+		NewName string `json:"new_name"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the page you wish to rename"); res != nil {
+		return res, nil
+	}
+	if res := requireString("new_name", args.NewName, "the target title for the page"); res != nil {
+		return res, nil
+	}
+
+	// Resolve UUID if name provided
+	page, err := s.client.GetPage(args.UUID)
+	if err != nil {
+		s.logger.Error("handleRenamePage failed to get page", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the page to rename: %v. Please ensure the current identifier is correct.", err)), nil
+	}
+	if page == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Page not found: '%s'. Please double-check the current name or UUID.", args.UUID)), nil
+	}
+
+	if err := s.client.RenamePage(page.UUID, args.NewName); err != nil {
+		s.logger.Error("handleRenamePage failed", zap.String("uuid", page.UUID), zap.String("new_name", args.NewName), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to rename the page: %v. Please ensure the new name is valid and not already in use.", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Page successfully renamed to: %s", args.NewName)), nil
+}
+
+func (s *MCPServer) handleGetParentNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetParentNamespace", zap.Any("req", req))
+	var args struct {
+		NameOrUUID string `json:"nameOrUUID"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the name or UUID of the page whose parent you wish to find"); res != nil {
+		return res, nil
+	}
+
+	parentName, parent, err := s.client.GetParentNamespace(args.NameOrUUID)
+	if err != nil {
+		s.logger.Error("handleGetParentNamespace failed", zap.String("page", args.NameOrUUID), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not resolve the parent namespace: %v. Please ensure the UUID or name is correct and the page exists.", err)), nil
+	}
+	if parentName == "" {
+		return mcp.NewToolResultText(fmt.Sprintf("'%s' is a top-level page and has no namespace parent.", args.NameOrUUID)), nil
+	}
+	if parent == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("The parent namespace '%s' does not exist as a page.", parentName)), nil
+	}
+
+	jsonPage, _ := json.MarshalIndent(parent, "", "  ")
+	return mcp.NewToolResultText(string(jsonPage)), nil
+}
+
+func (s *MCPServer) handleGetSiblingPages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetSiblingPages", zap.Any("req", req))
+	var args struct {
+		NameOrUUID string `json:"nameOrUUID"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("nameOrUUID", args.NameOrUUID, "the name or UUID of the page whose siblings you wish to list"); res != nil {
+		return res, nil
+	}
+
+	namespace, siblings, err := s.client.GetSiblingPages(args.NameOrUUID)
+	if err != nil {
+		s.logger.Error("handleGetSiblingPages failed", zap.String("page", args.NameOrUUID), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not list the sibling pages: %v. Please ensure the UUID or name is correct and the page exists.", err)), nil
+	}
+
+	jsonPages, _ := json.MarshalIndent(siblings, "", "  ")
+	if namespace == "" {
+		return mcp.NewToolResultText(fmt.Sprintf("'%s' is a top-level page and has no namespace, so it has no siblings.\n%s", args.NameOrUUID, jsonPages)), nil
+	}
+	return mcp.NewToolResultText(string(jsonPages)), nil
+}
+
+func (s *MCPServer) handleReadNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleReadNamespace", zap.Any("req", req))
+	var args struct {
+		Namespace string `json:"namespace"`
+		Recursive bool   `json:"recursive"`
+		Limit     int    `json:"limit"`
+		Offset    int    `json:"offset"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("namespace", args.Namespace, "the category (e.g., 'Projects') you wish to list"); res != nil {
+		return res, nil
+	}
+	if args.Limit < 0 || args.Offset < 0 {
+		return mcp.NewToolResultError("The 'limit' and 'offset' parameters must not be negative."), nil
+	}
+	if args.Limit == 0 {
+		args.Limit = DefaultNamespaceLimit
+	}
+
+	var pages []logseq.Page
+	var err error
+	if args.Recursive {
+		pages, err = s.client.GetNamespaceTree(args.Namespace)
+	} else {
+		pages, err = s.client.GetNamespacePages(args.Namespace)
+	}
+	if err != nil {
+		s.logger.Error("handleReadNamespace failed", zap.String("namespace", args.Namespace), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve pages for namespace '%s': %v. Please ensure the namespace exists.", args.Namespace, err)), nil
+	}
+
+	// Sort by name so that pages are stable across calls
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].Name < pages[j].Name })
+
+	total := len(pages)
+	start := min(args.Offset, total)
+	end := min(start+args.Limit, total)
+
+	result := map[string]any{
+		"total":  total,
+		"offset": args.Offset,
+		"limit":  args.Limit,
+		"pages":  append([]logseq.Page{}, pages[start:end]...),
+	}
+	jsonResult, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(jsonResult)), nil
+}
+
+func (s *MCPServer) handleDescribeClass(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleDescribeClass", zap.Any("req", req))
+	var args struct {
+		Class string `json:"class"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("class", args.Class, "the Class (e.g., 'Book') you wish to describe"); res != nil {
+		return res, nil
+	}
+
+	tagged, err := s.client.FindPagesByTag(args.Class)
+	if err != nil {
+		s.logger.Error("handleDescribeClass failed", zap.String("class", args.Class), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not find Instances of '%s': %v.", args.Class, err)), nil
+	}
+	namespaced, err := s.client.GetNamespacePages(args.Class)
+	if err != nil {
+		s.logger.Error("handleDescribeClass failed", zap.String("class", args.Class), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not find Instances of '%s': %v.", args.Class, err)), nil
+	}
+
+	seen := make(map[string]bool)
+	var instances []logseq.Page
+	for _, p := range append(tagged, namespaced...) {
+		if !seen[p.UUID] {
+			seen[p.UUID] = true
+			instances = append(instances, p)
+		}
+	}
+	sort.SliceStable(instances, func(i, j int) bool { return instances[i].Name < instances[j].Name })
+
+	sampled := len(instances) > DescribeClassSampleSize
+	if sampled {
+		instances = instances[:DescribeClassSampleSize]
+	}
+
+	attributes := make(map[string]int)
+	for _, p := range instances {
+		for k := range p.Properties {
+			attributes[s.normalizeKey(k)]++
+		}
+	}
+
+	result := map[string]any{
+		"class":      args.Class,
+		"instances":  len(seen),
+		"inspected":  len(instances),
+		"sampled":    sampled,
+		"attributes": attributes,
+	}
+	jsonResult, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(jsonResult)), nil
+}
+
+func (s *MCPServer) handleExportNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleExportNamespace", zap.Any("req", req))
+	var args struct {
+		Namespace string `json:"namespace"`
+		Format    string `json:"format"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("namespace", args.Namespace, "the category (e.g., 'Projects') you wish to export"); res != nil {
+		return res, nil
+	}
+	format := strings.ToLower(args.Format)
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		return mcp.NewToolResultError(fmt.Sprintf("Unsupported format: '%s'. Please use 'json' or 'csv'.", args.Format)), nil
+	}
+
+	pages, err := s.client.GetNamespacePages(args.Namespace)
+	if err != nil {
+		s.logger.Error("handleExportNamespace failed", zap.String("namespace", args.Namespace), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve pages for namespace '%s': %v. Please ensure the namespace exists.", args.Namespace, err)), nil
+	}
+
+	// Flatten pages into rows and collect the union of property keys for the header
+	var rows []map[string]string
+	var skipped []string
+	keySet := make(map[string]bool)
+	for _, p := range pages {
+		name := p.OriginalName
+		if name == "" {
+			name = p.Name
+		}
+		if name == "" {
+			skipped = append(skipped, p.UUID)
+			continue
+		}
+		row := map[string]string{"name": name, "uuid": p.UUID}
+		for k, v := range p.Properties {
+			if k == "name" || k == "uuid" {
+				continue
+			}
+			keySet[k] = true
+			row[k] = formatExportValue(v)
+		}
+		rows = append(rows, row)
+	}
+
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	header := append([]string{"name", "uuid"}, keys...)
+
+	var out string
+	if format == "csv" {
+		var sb strings.Builder
+		w := csv.NewWriter(&sb)
+		w.Write(header)
+		for _, row := range rows {
+			record := make([]string, len(header))
+			for i, col := range header {
+				record[i] = row[col] // Missing properties become empty cells
+			}
+			w.Write(record)
+		}
+		w.Flush()
+		out = sb.String()
+	} else {
+		if rows == nil {
+			rows = []map[string]string{}
+		}
+		jsonRows, _ := json.MarshalIndent(rows, "", "  ")
+		out = string(jsonRows)
+	}
+
+	if len(skipped) > 0 {
+		out += fmt.Sprintf("\nSkipped %d pages without a name: %v", len(skipped), skipped)
+	}
+	return mcp.NewToolResultText(out), nil
+}
+
+// formatExportValue renders a property value as a single table cell
+func formatExportValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []any:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, formatExportValue(item))
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		b, _ := json.Marshal(val)
+		return string(b)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func (s *MCPServer) handleCreateNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCreateNamespace", zap.Any("req", req))
+	var args struct {
+		Namespace string `json:"namespace"`
+		Options   string `json:"options"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("namespace", args.Namespace, "the name (e.g., 'work/project') for the new category"); res != nil {
+		return res, nil
+	}
+	var options map[string]any
+	if args.Options != "" {
+		if res := requireJSON("options", args.Options, &options, "a JSON object of createPage options"); res != nil {
+			return res, nil
+		}
+	}
+
+	// Creating a namespace is essentially creating a page with "/" in the name
+	page, err := s.client.CreatePage(args.Namespace, nil, options)
+	if err != nil {
+		s.logger.Error("handleCreateNamespace failed", zap.String("namespace", args.Namespace), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create the namespace page: %v. Please ensure the name is valid.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Namespace created successfully: %s (UUID: %s)", page.Name, page.UUID)), nil
+}
+
+func (s *MCPServer) handleReadBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleReadBlock", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the unique identifier for the block you wish to read"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+	block, err := s.client.GetBlock(args.UUID)
+	if err != nil {
+		s.logger.Error("handleReadBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the block: %v. Please ensure the UUID is correct.", err)), nil
+	}
+	if block == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Block not found: '%s'. Please double-check the UUID.", args.UUID)), nil
+	}
+
+	jsonBlock, _ := json.MarshalIndent(block, "", "  ")
+	return mcp.NewToolResultText(string(jsonBlock)), nil
+}
+
+func (s *MCPServer) handleReorderBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleReorderBlock", zap.Any("req", req))
+	var args struct {
+		UUID      string `json:"uuid"`
+		Direction string `json:"direction"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the block you wish to move"); res != nil {
+		return res, nil
+	}
+	direction := strings.ToLower(args.Direction)
+	if direction != "up" && direction != "down" {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid direction '%s'. Please use 'up' or 'down'.", args.Direction)), nil
+	}
+
+	moved, err := s.client.ReorderBlock(args.UUID, direction)
+	if err != nil {
+		s.logger.Error("handleReorderBlock failed", zap.String("uuid", args.UUID), zap.String("direction", direction), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to move the block: %v. Please ensure the UUID is correct.", err)), nil
+	}
+	if !moved {
+		edge := "first"
+		if direction == "down" {
+			edge = "last"
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Block %s is already the %s among its siblings; nothing was moved.", args.UUID, edge)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Block %s successfully moved %s.", args.UUID, direction)), nil
+}
+
+func (s *MCPServer) handleGetBlockContext(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetBlockContext", zap.Any("req", req))
+	var args struct {
+		UUID   string `json:"uuid"`
+		Radius int    `json:"radius"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the block whose context you wish to read"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+	if args.Radius < 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid radius %d. Please provide zero or a positive number of siblings.", args.Radius)), nil
+	}
+
+	blocks, err := s.client.GetBlockContext(args.UUID, args.Radius)
+	if err != nil {
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		s.logger.Error("handleGetBlockContext failed", zap.String("uuid", args.UUID), zap.Int("radius", args.Radius), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the block context: %v. Please ensure the UUID is correct.", err)), nil
+	}
+
+	type contextBlock struct {
+		UUID    string `json:"uuid"`
+		Content string `json:"content"`
+		Target  bool   `json:"target"`
+	}
+	result := make([]contextBlock, 0, len(blocks))
+	for _, b := range blocks {
+		result = append(result, contextBlock{UUID: b.UUID, Content: b.Content, Target: b.UUID == args.UUID})
+	}
+
+	jsonResult, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(jsonResult)), nil
+}
+
+func (s *MCPServer) handleMoveBlocks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleMoveBlocks", zap.Any("req", req))
+	var args struct {
+		Operations string `json:"operations"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	var ops []logseq.MoveOperation
+	if res := requireJSON("operations", args.Operations, &ops, "a JSON array of objects with 'uuid' and 'target_uuid'"); res != nil {
+		return res, nil
+	}
+	for i, op := range ops {
+		if op.UUID == "" || op.TargetUUID == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("Operation %d is missing 'uuid' or 'target_uuid'. No blocks were moved.", i)), nil
+		}
+	}
+
+	count, moveErrs := s.client.MoveBlocks(ops)
+	if len(moveErrs) > 0 {
+		var errs []string
+		for _, e := range moveErrs {
+			errs = append(errs, e.Error())
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Moved %d blocks, but failed for: %v", count, errs)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully moved %d blocks.", count)), nil
+}
+
+func (s *MCPServer) handleApplyTemplate(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleApplyTemplate", zap.Any("req", req))
+	var args struct {
+		TemplatePage string `json:"template_page"`
+		TargetUUID   string `json:"target_uuid"`
+		Variables    string `json:"variables"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("template_page", args.TemplatePage, "the name of the page holding the template blocks"); res != nil {
+		return res, nil
+	}
+	if res := requireString("target_uuid", args.TargetUUID, "the block or page under which to insert the template"); res != nil {
+		return res, nil
+	}
+
+	vars := make(map[string]string)
+	if args.Variables != "" {
+		if res := requireJSON("variables", args.Variables, &vars, "a JSON object mapping placeholder names to string values"); res != nil {
+			return res, nil
+		}
+	}
+
+	result, err := s.client.ApplyTemplate(args.TemplatePage, args.TargetUUID, vars)
+	if err != nil {
+		s.logger.Error("handleApplyTemplate failed", zap.String("template", args.TemplatePage), zap.String("target", args.TargetUUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to apply the template: %v. Please ensure the template page and target exist.", err)), nil
+	}
+
+	jsonResult, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(jsonResult)), nil
+}
+
+func (s *MCPServer) handleReadBlocks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleReadBlocks", zap.Any("req", req))
+	var args struct {
+		UUIDs string `json:"uuids"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	var uuids []string
+	if res := requireJSON("uuids", args.UUIDs, &uuids, "a JSON array of identifiers"); res != nil {
+		return res, nil
+	}
+
+	blocks, err := s.client.GetBlocks(uuids)
+	if err != nil {
+		s.logger.Error("handleReadBlocks failed", zap.Strings("uuids", uuids), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the blocks: %v.", err)), nil
+	}
+
+	type blockResult struct {
+		UUID  string        `json:"uuid"`
+		Found bool          `json:"found"`
+		Block *logseq.Block `json:"block,omitempty"`
+	}
+	results := make([]blockResult, len(uuids))
+	for i, uuid := range uuids {
+		results[i] = blockResult{UUID: uuid, Found: blocks[i] != nil, Block: blocks[i]}
+	}
+
+	jsonResults, _ := json.MarshalIndent(results, "", "  ")
+	return mcp.NewToolResultText(string(jsonResults)), nil
+}
+
+func (s *MCPServer) handleGetChildren(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetChildren", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the parent block"); res != nil {
+		return res, nil
+	}
+
+	children, err := s.client.GetBlockChildren(args.UUID)
+	if err != nil {
+		s.logger.Error("handleGetChildren failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the children: %v. Please ensure the UUID is correct.", err)), nil
+	}
+
+	type childSummary struct {
+		UUID    string `json:"uuid"`
+		Content string `json:"content"`
+	}
+	summaries := make([]childSummary, 0, len(children))
+	for _, child := range children {
+		summaries = append(summaries, childSummary{UUID: child.UUID, Content: child.Content})
+	}
+
+	jsonChildren, _ := json.MarshalIndent(summaries, "", "  ")
+	return mcp.NewToolResultText(string(jsonChildren)), nil
+}
+
+func (s *MCPServer) handleCreateBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCreateBlock", zap.Any("req", req))
+	var args struct {
+		ParentUUID string `json:"parent_uuid"`
+		Content    string `json:"content"`
+		Properties string `json:"properties"`
+		Sibling    bool   `json:"sibling"`
+		Before     bool   `json:"before"`
+		CustomUUID string `json:"custom_uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("parent_uuid", args.ParentUUID, "a valid identifier for where the block should be inserted"); res != nil {
+		return res, nil
+	}
+	if res := requireString("content", args.Content, "the text for the new block"); res != nil {
+		return res, nil
+	}
+
+	var props map[string]any
+	if args.Properties != "" {
+		if res := requireJSON("properties", args.Properties, &props, "a JSON object mapping property keys to values"); res != nil {
+			return res, nil
+		}
+	}
+
+	if s.mode == ModeOntological {
+		props = s.normalizeKeys(props)
+	}
+
+	parentUUID, errResult := s.resolveParentUUID(args.ParentUUID)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	options := make(map[string]any)
+	if args.Sibling {
+		options["sibling"] = true
+		if args.Before {
+			options["before"] = true
+		}
+	}
+	if args.CustomUUID != "" {
+		if !logseq.IsUUID(args.CustomUUID) {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid custom_uuid: '%s'. Please provide a well-formed UUID such as 6571c5b8-1f2a-4c3d-9e8f-0a1b2c3d4e5f.", args.CustomUUID)), nil
+		}
+		existing, err := s.client.GetBlock(args.CustomUUID)
+		if err != nil {
+			s.logger.Error("handleCreateBlock failed to check custom UUID", zap.String("custom_uuid", args.CustomUUID), zap.Error(err))
+			return mcp.NewToolResultError(fmt.Sprintf("Could not check whether custom_uuid '%s' is in use: %v. Please ensure Logseq is running.", args.CustomUUID, err)), nil
+		}
+		if existing != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("The custom_uuid '%s' is already in use by another block. Please choose a different UUID or omit it.", args.CustomUUID)), nil
+		}
+		options["customUUID"] = strings.ToLower(args.CustomUUID)
+	}
+
+	block, err := s.client.InsertBlock(parentUUID, args.Content, s.coerceProperties(props), options)
+	if err != nil {
+		s.logger.Error("handleCreateBlock failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to insert the block: %v. Please ensure the parent exists and the content is valid.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Block inserted successfully: %s. You can use this UUID to reference or update this block later.", block.UUID)), nil
+}
+
+// resolveParentUUID turns a parent given as a page name into the page's UUID, so blocks are inserted at its root.
+// UUIDs are returned unchanged. A non-nil result is the tool error to return.
+func (s *MCPServer) resolveParentUUID(parent string) (string, *mcp.CallToolResult) {
+	if logseq.IsUUID(parent) {
+		return parent, nil
+	}
+	page, err := s.client.GetPage(parent)
+	if err != nil {
+		s.logger.Error("Failed to resolve parent page", zap.String("page", parent), zap.Error(err))
+		return "", mcp.NewToolResultError(fmt.Sprintf("Could not resolve the parent page '%s': %v. Please ensure Logseq is running.", parent, err))
+	}
+	if page == nil {
+		return "", mcp.NewToolResultError(fmt.Sprintf("Parent page not found: '%s'. Please provide an existing page name or a block/page UUID.", parent))
+	}
+	return page.UUID, nil
+}
+
+func (s *MCPServer) handleInsertBlockRef(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleInsertBlockRef", zap.Any("req", req))
+	var args struct {
+		ParentUUID string `json:"parent_uuid"`
+		RefUUID    string `json:"ref_uuid"`
+		Sibling    bool   `json:"sibling"`
+		Before     bool   `json:"before"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("parent_uuid", args.ParentUUID, "a valid identifier for where the reference should be inserted"); res != nil {
+		return res, nil
+	}
+	if res := requireString("ref_uuid", args.RefUUID, "the UUID of the block you wish to reference"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.RefUUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.RefUUID)), nil
+	}
+
+	parentUUID, errResult := s.resolveParentUUID(args.ParentUUID)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	options := make(map[string]any)
+	if args.Sibling {
+		options["sibling"] = true
+		if args.Before {
+			options["before"] = true
+		}
+	}
+
+	block, err := s.client.InsertBlockRef(parentUUID, args.RefUUID, options)
+	if err != nil {
+		if errors.Is(err, logseq.ErrRefTargetNotFound) {
+			return mcp.NewToolResultError(fmt.Sprintf("The referenced block %s does not exist, so no reference was inserted. Please check the ref UUID.", args.RefUUID)), nil
+		}
+		s.logger.Error("handleInsertBlockRef failed", zap.String("ref", args.RefUUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to insert the block reference: %v. Please ensure the parent exists.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Block reference inserted successfully: %s", block.UUID)), nil
+}
+
+func (s *MCPServer) handleInsertEmbed(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleInsertEmbed", zap.Any("req", req))
+	var args struct {
+		ParentUUID string `json:"parent_uuid"`
+		Target     string `json:"target"`
+		Type       string `json:"type"`
+		Sibling    bool   `json:"sibling"`
+		Before     bool   `json:"before"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("parent_uuid", args.ParentUUID, "a valid identifier for where the embed should be inserted"); res != nil {
+		return res, nil
+	}
+	if res := requireString("target", args.Target, "the block UUID or page name you wish to embed"); res != nil {
+		return res, nil
+	}
+	switch args.Type {
+	case logseq.EmbedBlock:
+		if !logseq.IsUUID(args.Target) {
+			return mcp.NewToolResultError(notUUIDMessage(args.Target)), nil
+		}
+	case logseq.EmbedPage:
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid embed type '%s'. Please use 'block' or 'page'.", args.Type)), nil
+	}
+
+	parentUUID, errResult := s.resolveParentUUID(args.ParentUUID)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	options := make(map[string]any)
+	if args.Sibling {
+		options["sibling"] = true
+		if args.Before {
+			options["before"] = true
+		}
+	}
+
+	block, err := s.client.InsertEmbed(parentUUID, args.Target, args.Type, options)
+	if err != nil {
+		if errors.Is(err, logseq.ErrRefTargetNotFound) {
+			return mcp.NewToolResultError(fmt.Sprintf("The %s '%s' does not exist, so no embed was inserted. Please check the target.", args.Type, args.Target)), nil
+		}
+		s.logger.Error("handleInsertEmbed failed", zap.String("target", args.Target), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to insert the embed: %v. Please ensure the parent exists.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Embed inserted successfully: %s", block.UUID)), nil
+}
+
+func (s *MCPServer) handleCreateBlockTree(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleCreateBlockTree", zap.Any("req", req))
+	var args struct {
+		ParentUUID string `json:"parent_uuid"`
+		Tree       string `json:"tree"`
+		Sibling    bool   `json:"sibling"`
+		Before     bool   `json:"before"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("parent_uuid", args.ParentUUID, "a valid identifier for where the tree should be inserted"); res != nil {
+		return res, nil
+	}
+
+	var batch []logseq.BlockContent
+	if res := requireJSON("tree", args.Tree, &batch, "a JSON array of BlockContent objects, using nested 'children' for the hierarchy"); res != nil {
+		return res, nil
+	}
+
+	if s.mode == ModeOntological {
+		var transformTree func([]logseq.BlockContent)
+		transformTree = func(blocks []logseq.BlockContent) {
+			for i := range blocks {
+				blocks[i].Properties = s.normalizeKeys(blocks[i].Properties)
+				if len(blocks[i].Children) > 0 {
+					transformTree(blocks[i].Children)
+				}
+			}
+		}
+		transformTree(batch)
+	}
+
+	options := make(map[string]any)
+	if args.Sibling {
+		options["sibling"] = true
+		if args.Before {
+			options["before"] = true
+		}
+	}
+
+	s.coerceTree(batch)
+	blocks, err := s.client.InsertBatchBlock(args.ParentUUID, batch, options)
+	if err != nil {
+		s.logger.Error("handleCreateBlockTree failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to insert the block tree: %v. Please ensure the parent exists and the tree structure is valid.", err)), nil
+	}
+
+	// Create a summary of created blocks
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully inserted %d blocks into the tree.", len(blocks))), nil
+}
+
+func (s *MCPServer) handleAppendBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleAppendBlock", zap.Any("req", req))
+	var args struct {
+		UUID    string `json:"uuid"`
+		Content string `json:"content"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the page where the block should be appended"); res != nil {
+		return res, nil
+	}
+	if res := requireString("content", args.Content, "the text to append"); res != nil {
+		return res, nil
+	}
+
+	block, err := s.client.AppendBlockInPage(args.UUID, args.Content, nil)
+	if err != nil {
+		s.logger.Error("handleAppendBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to append the block: %v. Please ensure the page exists.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Block successfully appended to '%s'. New block UUID: %s", args.UUID, block.UUID)), nil
+}
+
+func (s *MCPServer) handleUpdateBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleUpdateBlock", zap.Any("req", req))
+	var args struct {
+		UUID       string `json:"uuid"`
+		Content    string `json:"content"`
+		Properties string `json:"properties"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the unique identifier for the block you wish to update"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
 
-func (s *MCPServer) handleCreateNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleCreateNamespace", zap.Any("req", req))
+	var props map[string]any
+	if args.Properties != "" {
+		if res := requireJSON("properties", args.Properties, &props, "a JSON object mapping property keys to values"); res != nil {
+			return res, nil
+		}
+	}
+
+	if s.mode == ModeOntological {
+		props = s.normalizeKeys(props)
+	}
+
+	block, err := s.client.UpdateBlock(args.UUID, args.Content, s.coerceProperties(props))
+	if err != nil {
+		s.logger.Error("handleUpdateBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to update the block: %v. Please ensure the UUID is correct and the block still exists.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Block updated successfully: %s", block.UUID)), nil
+}
+
+func (s *MCPServer) handleSetTaskState(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleSetTaskState", zap.Any("req", req))
+	var args struct {
+		UUID  string `json:"uuid"`
+		State string `json:"state"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the task block"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsTaskState(args.State) {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid task state: '%s'. Please use one of %v.", args.State, logseq.TaskStates)), nil
+	}
+
+	block, err := s.client.SetTaskState(args.UUID, args.State)
+	if err != nil {
+		s.logger.Error("handleSetTaskState failed", zap.String("uuid", args.UUID), zap.String("state", args.State), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to set the task state: %v. Please ensure the UUID is correct.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Task state of %s set to %s.", block.UUID, args.State)), nil
+}
+
+func (s *MCPServer) handleToggleTask(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleToggleTask", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the task block"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+
+	block, state, err := s.client.ToggleTask(args.UUID)
+	if err != nil {
+		if errors.Is(err, logseq.ErrNotATask) {
+			return mcp.NewToolResultError(fmt.Sprintf("Block %s has no task marker, so there is nothing to toggle. Use set_task_state to make it a task.", args.UUID)), nil
+		}
+		s.logger.Error("handleToggleTask failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to toggle the task: %v. Please ensure the UUID is correct.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Task %s is now %s.", block.UUID, state)), nil
+}
+
+func (s *MCPServer) handleSetSchedule(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleSetSchedule", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+		Date string `json:"date"`
+		Time string `json:"time"`
+		Type string `json:"type"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the task block"); res != nil {
+		return res, nil
+	}
+	if res := requireString("date", args.Date, "the date as YYYY-MM-DD"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+	if args.Type != logseq.ScheduleScheduled && args.Type != logseq.ScheduleDeadline {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid schedule type '%s'. Please use 'scheduled' or 'deadline'.", args.Type)), nil
+	}
+	if _, err := time.Parse("2006-01-02", args.Date); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid date '%s'. Please use the YYYY-MM-DD format (e.g. '2026-01-18').", args.Date)), nil
+	}
+	if args.Time != "" {
+		if _, err := time.Parse("15:04", args.Time); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid time '%s'. Please use the 24h HH:MM format (e.g. '09:30').", args.Time)), nil
+		}
+	}
+
+	block, err := s.client.SetSchedule(args.UUID, args.Type, args.Date, args.Time)
+	if err != nil {
+		s.logger.Error("handleSetSchedule failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to set the %s date: %v. Please ensure the UUID is correct.", args.Type, err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("The %s date of %s is set to %s.", args.Type, block.UUID, strings.TrimSpace(args.Date+" "+args.Time))), nil
+}
+
+func (s *MCPServer) handleAgenda(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleAgenda", zap.Any("req", req))
+	var args struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("from", args.From, "the first date of the range as YYYY-MM-DD"); res != nil {
+		return res, nil
+	}
+	if res := requireString("to", args.To, "the last date of the range as YYYY-MM-DD"); res != nil {
+		return res, nil
+	}
+	from, err := time.Parse("2006-01-02", args.From)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid 'from' date '%s'. Please use the YYYY-MM-DD format (e.g. '2026-01-18').", args.From)), nil
+	}
+	to, err := time.Parse("2006-01-02", args.To)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid 'to' date '%s'. Please use the YYYY-MM-DD format (e.g. '2026-01-25').", args.To)), nil
+	}
+	if to.Before(from) {
+		return mcp.NewToolResultError("The 'to' date is before the 'from' date. Please swap them."), nil
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > logseq.MaxAgendaDays {
+		return mcp.NewToolResultError(fmt.Sprintf("The range spans %d days, more than the maximum of %d. Please query a shorter range.", days, logseq.MaxAgendaDays)), nil
+	}
+
+	agenda, err := s.client.GetAgenda(from, to)
+	if err != nil {
+		s.logger.Error("handleAgenda failed", zap.String("from", args.From), zap.String("to", args.To), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not build the agenda: %v. Please check if Logseq is running.", err)), nil
+	}
+
+	jsonAgenda, _ := json.MarshalIndent(agenda, "", "  ")
+	return mcp.NewToolResultText(string(jsonAgenda)), nil
+}
+
+func (s *MCPServer) handleListTasks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleListTasks", zap.Any("req", req))
+	var args struct {
+		State string `json:"state"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	var states []string
+	for _, state := range strings.Split(args.State, ",") {
+		if state = strings.TrimSpace(state); state == "" {
+			continue
+		}
+		if strings.EqualFold(state, "none") || !logseq.IsTaskState(state) {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid task state: '%s'. Please use TODO, DOING, NOW, LATER or DONE.", state)), nil
+		}
+		states = append(states, strings.ToUpper(state))
+	}
+
+	tasks, err := s.client.ListTasks(states)
+	if err != nil {
+		s.logger.Error("handleListTasks failed", zap.Strings("states", states), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not list the tasks: %v. Please check if Logseq is running.", err)), nil
+	}
+
+	jsonTasks, _ := json.MarshalIndent(tasks, "", "  ")
+	return mcp.NewToolResultText(string(jsonTasks)), nil
+}
+
+func (s *MCPServer) handleGetBlockFormat(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetBlockFormat", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the block"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+
+	format, err := s.client.GetBlockFormat(args.UUID)
+	if err != nil {
+		s.logger.Error("handleGetBlockFormat failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not determine the block format: %v. Please ensure the UUID is correct.", err)), nil
+	}
+
+	jsonFormat, _ := json.Marshal(map[string]string{"uuid": args.UUID, "format": format})
+	return mcp.NewToolResultText(string(jsonFormat)), nil
+}
+
+func (s *MCPServer) handleAppendToBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleAppendToBlock", zap.Any("req", req))
+	var args struct {
+		UUID    string `json:"uuid"`
+		Content string `json:"content"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the block to append to"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+	if res := requireString("content", args.Content, "the text to append"); res != nil {
+		return res, nil
+	}
+
+	block, err := s.client.AppendToBlock(args.UUID, args.Content)
+	if err != nil {
+		s.logger.Error("handleAppendToBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to append to the block: %v. Please ensure the UUID is correct.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Content appended to block %s.", block.UUID)), nil
+}
+
+func (s *MCPServer) handlePrependToBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handlePrependToBlock", zap.Any("req", req))
+	var args struct {
+		UUID     string `json:"uuid"`
+		Content  string `json:"content"`
+		SameLine bool   `json:"same_line"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier of the block to prepend to"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+	if res := requireString("content", args.Content, "the text to prepend"); res != nil {
+		return res, nil
+	}
+
+	block, err := s.client.PrependToBlock(args.UUID, args.Content, args.SameLine)
+	if err != nil {
+		s.logger.Error("handlePrependToBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to prepend to the block: %v. Please ensure the UUID is correct.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Content prepended to block %s.", block.UUID)), nil
+}
+
+func (s *MCPServer) handleDeleteBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleDeleteBlock", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the block you wish to delete"); res != nil {
+		return res, nil
+	}
+	if !logseq.IsUUID(args.UUID) {
+		return mcp.NewToolResultError(notUUIDMessage(args.UUID)), nil
+	}
+	if err := s.client.DeleteBlock(args.UUID); err != nil {
+		s.logger.Error("handleDeleteBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to delete the block: %v. Please ensure the UUID is correct.", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Block successfully deleted: %s", args.UUID)), nil
+}
+
+func (s *MCPServer) handleDeleteBlocks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleDeleteBlocks", zap.Any("req", req))
+	var args struct {
+		UUIDs string `json:"uuids"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	var uuids []string
+	if res := requireJSON("uuids", args.UUIDs, &uuids, "a JSON array of identifiers"); res != nil {
+		return res, nil
+	}
+
+	results := logseq.RunBatch(len(uuids), s.batchConcurrency, func(i int) error {
+		return s.client.DeleteBlock(uuids[i])
+	})
+
+	succeeded := []string{}
+	var failed []batchItemError
+
+	for i, err := range results {
+		if err != nil {
+			s.logger.Error("Failed to delete block in handleDeleteBlocks", zap.String("uuid", uuids[i]), zap.Error(err))
+			failed = append(failed, batchItemError{Index: i, Identifier: uuids[i], Error: err.Error()})
+		} else {
+			succeeded = append(succeeded, uuids[i])
+		}
+	}
+	count := len(succeeded)
+
+	if len(failed) > 0 {
+		return batchFailureResult(fmt.Sprintf("Deleted %d blocks, %d failed. Please verify the failed UUIDs are correct.", count, len(failed)), succeeded, failed), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully deleted %d blocks.", count)), nil
+}
+
+func (s *MCPServer) handleAddTag(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleAddTag", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+		Tag  string `json:"tag"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the entity you wish to tag"); res != nil {
+		return res, nil
+	}
+	if res := requireString("tag", args.Tag, "the text for the tag you wish to add"); res != nil {
+		return res, nil
+	}
+
+	if err := s.client.AddTag(args.UUID, args.Tag); err != nil {
+		s.logger.Error("handleAddTag failed", zap.String("uuid", args.UUID), zap.String("tag", args.Tag), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to add the tag: %v. Please ensure the target exists and the tag format is valid.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Tag '%s' successfully added to %s.", args.Tag, args.UUID)), nil
+}
+
+func (s *MCPServer) handleTagNamespace(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleTagNamespace", zap.Any("req", req))
 	var args struct {
 		Namespace string `json:"namespace"`
+		Tag       string `json:"tag"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("namespace", args.Namespace, "the namespace whose pages you wish to tag"); res != nil {
+		return res, nil
+	}
+	if res := requireString("tag", args.Tag, "the text for the tag you wish to add"); res != nil {
+		return res, nil
+	}
+
+	pages, err := s.client.GetNamespacePages(args.Namespace)
+	if err != nil {
+		s.logger.Error("handleTagNamespace failed", zap.String("namespace", args.Namespace), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve pages for namespace '%s': %v. Please ensure the namespace exists.", args.Namespace, err)), nil
+	}
+	if len(pages) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No pages found in namespace '%s'. Nothing was tagged.", args.Namespace)), nil
+	}
+
+	results := logseq.RunBatch(len(pages), s.batchConcurrency, func(i int) error {
+		return s.client.AddTag(pages[i].UUID, args.Tag)
+	})
+
+	succeeded := []string{}
+	var failed []batchItemError
+	for i, err := range results {
+		name := pages[i].OriginalName
+		if name == "" {
+			name = pages[i].Name
+		}
+		if err != nil {
+			s.logger.Error("Failed to tag page in handleTagNamespace", zap.String("page", name), zap.String("tag", args.Tag), zap.Error(err))
+			failed = append(failed, batchItemError{Index: i, Identifier: name, Error: err.Error()})
+		} else {
+			succeeded = append(succeeded, name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return batchFailureResult(fmt.Sprintf("Tagged %d pages in '%s' with '%s', %d failed. Please retry the failed pages with add_tag.", len(succeeded), args.Namespace, args.Tag, len(failed)), succeeded, failed), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully tagged %d pages in '%s' with '%s'.", len(succeeded), args.Namespace, args.Tag)), nil
+}
+
+func (s *MCPServer) handleGetTags(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleGetTags", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the entity whose tags you want"); res != nil {
+		return res, nil
+	}
+
+	tags, err := s.client.GetTags(args.UUID)
+	if err != nil {
+		s.logger.Error("handleGetTags failed", zap.String("uuid", args.UUID), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not read the tags: %v. Please ensure the UUID or name is correct.", err)), nil
+	}
+
+	jsonTags, _ := json.MarshalIndent(tags, "", "  ")
+	return mcp.NewToolResultText(string(jsonTags)), nil
+}
+
+func (s *MCPServer) handleAddTags(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleAddTags", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+		Tags string `json:"tags"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the entity you wish to tag"); res != nil {
+		return res, nil
+	}
+
+	var tags []string
+	if res := requireJSON("tags", args.Tags, &tags, "a JSON array of tag strings"); res != nil {
+		return res, nil
+	}
+	if len(tags) == 0 {
+		return mcp.NewToolResultError("The list of tags is empty. Please provide at least one tag to add."), nil
+	}
+
+	if err := s.client.AddTags(args.UUID, tags); err != nil {
+		s.logger.Error("handleAddTags failed", zap.String("uuid", args.UUID), zap.Strings("tags", tags), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to add the tags: %v. Please ensure the target exists and the tag format is valid.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Tags %v successfully added to %s.", tags, args.UUID)), nil
+}
+
+func (s *MCPServer) handleRemoveTag(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRemoveTag", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+		Tag  string `json:"tag"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the entity from which to remove the tag"); res != nil {
+		return res, nil
 	}
-	if args.Namespace == "" {
-		return mcp.NewToolResultError("A namespace name is required. Please provide the name (e.g., 'work/project') for the new category."), nil
+	if res := requireString("tag", args.Tag, "the text for the tag you wish to remove"); res != nil {
+		return res, nil
 	}
 
-	// Creating a namespace is essentially creating a page with "/" in the name
-	page, err := s.client.CreatePage(args.Namespace, nil, nil)
-	if err != nil {
-		s.logger.Error("handleCreateNamespace failed", zap.String("namespace", args.Namespace), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to create the namespace page: %v. Please ensure the name is valid.", err)), nil
+	if err := s.client.RemoveTag(args.UUID, args.Tag); err != nil {
+		s.logger.Error("handleRemoveTag failed", zap.String("uuid", args.UUID), zap.String("tag", args.Tag), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to remove the tag: %v. Please ensure the entity exists and contains the specified tag.", err)), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Namespace created successfully: %s (UUID: %s)", page.Name, page.UUID)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Tag '%s' successfully removed from %s.", args.Tag, args.UUID)), nil
 }
 
-func (s *MCPServer) handleReadBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleReadBlock", zap.Any("req", req))
+func (s *MCPServer) handleRemoveTags(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRemoveTags", zap.Any("req", req))
 	var args struct {
 		UUID string `json:"uuid"`
+		Tags string `json:"tags"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A block UUID is required. Please provide the unique identifier for the block you wish to read."), nil
+	if res := requireString("uuid", args.UUID, "the identifier for the entity from which to remove the tags"); res != nil {
+		return res, nil
 	}
-	block, err := s.client.GetBlock(args.UUID)
-	if err != nil {
-		s.logger.Error("handleReadBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Could not retrieve the block: %v. Please ensure the UUID is correct.", err)), nil
+
+	var tags []string
+	if res := requireJSON("tags", args.Tags, &tags, "a JSON array of tag strings"); res != nil {
+		return res, nil
 	}
-	if block == nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Block not found: '%s'. Please double-check the UUID.", args.UUID)), nil
+
+	removed, err := s.client.RemoveTags(args.UUID, tags)
+	if err != nil {
+		s.logger.Error("handleRemoveTags failed", zap.String("uuid", args.UUID), zap.Strings("tags", tags), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to remove the tags: %v. Please ensure the entity exists.", err)), nil
 	}
 
-	jsonBlock, _ := json.MarshalIndent(block, "", "  ")
-	return mcp.NewToolResultText(string(jsonBlock)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Removed %d of %d tags from %s.", removed, len(tags), args.UUID)), nil
 }
 
-func (s *MCPServer) handleCreateBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleCreateBlock", zap.Any("req", req))
+func (s *MCPServer) handleAddAlias(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleAddAlias", zap.Any("req", req))
 	var args struct {
-		ParentUUID string `json:"parent_uuid"`
-		Content    string `json:"content"`
-		Properties string `json:"properties"`
-		Sibling    bool   `json:"sibling"`
-		Before     bool   `json:"before"`
+		UUID  string `json:"uuid"`
+		Alias string `json:"alias"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.ParentUUID == "" {
-		return mcp.NewToolResultError("A parent UUID (page or block) is required. Please provide a valid identifier for where the block should be inserted."), nil
+	if res := requireString("uuid", args.UUID, "the identifier for the entity to alias"); res != nil {
+		return res, nil
 	}
-	if args.Content == "" {
-		return mcp.NewToolResultError("Block content is required. Please provide the text for the new block."), nil
+	if res := requireString("alias", args.Alias, "the alternate name you wish to add"); res != nil {
+		return res, nil
 	}
 
-	var props map[string]any
-	if args.Properties != "" {
-		if err := json.Unmarshal([]byte(args.Properties), &props); err != nil {
-			return mcp.NewToolResultError("The properties provided are not valid JSON. Please check your formatting and try again."), nil
+	aliases, err := s.client.AddAlias(args.UUID, args.Alias)
+	if err != nil {
+		if errors.Is(err, logseq.ErrInvalidPageName) {
+			return mcp.NewToolResultError(fmt.Sprintf("The alias is not a valid page name: %v. Please choose a different alias.", err)), nil
 		}
+		s.logger.Error("handleAddAlias failed", zap.String("uuid", args.UUID), zap.String("alias", args.Alias), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to add the alias: %v. Please ensure the entity exists.", err)), nil
 	}
 
-	if s.mode == ModeOntological {
-		props = toSnakeCaseKeys(props)
-	}
+	return mcp.NewToolResultText(fmt.Sprintf("Aliases of %s: %s", args.UUID, strings.Join(aliases, ", "))), nil
+}
 
-	options := make(map[string]any)
-	if args.Sibling {
-		options["sibling"] = true
-		if args.Before {
-			options["before"] = true
-		}
+func (s *MCPServer) handleRemoveAlias(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRemoveAlias", zap.Any("req", req))
+	var args struct {
+		UUID  string `json:"uuid"`
+		Alias string `json:"alias"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the entity from which to remove the alias"); res != nil {
+		return res, nil
+	}
+	if res := requireString("alias", args.Alias, "the alternate name you wish to remove"); res != nil {
+		return res, nil
 	}
 
-	block, err := s.client.InsertBlock(args.ParentUUID, args.Content, props, options)
+	remaining, removed, err := s.client.RemoveAlias(args.UUID, args.Alias)
 	if err != nil {
-		s.logger.Error("handleCreateBlock failed", zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to insert the block: %v. Please ensure the parent exists and the content is valid.", err)), nil
+		s.logger.Error("handleRemoveAlias failed", zap.String("uuid", args.UUID), zap.String("alias", args.Alias), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to remove the alias: %v. Please ensure the entity exists.", err)), nil
 	}
-
-	return mcp.NewToolResultText(fmt.Sprintf("Block inserted successfully: %s. You can use this UUID to reference or update this block later.", block.UUID)), nil
+	if !removed {
+		return mcp.NewToolResultText(fmt.Sprintf("%s has no alias '%s'; nothing was removed.", args.UUID, args.Alias)), nil
+	}
+	if len(remaining) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("Removed alias '%s'; %s has no aliases left.", args.Alias, args.UUID)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Removed alias '%s'. Aliases of %s: %s", args.Alias, args.UUID, strings.Join(remaining, ", "))), nil
 }
 
-func (s *MCPServer) handleCreateBlockTree(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleCreateBlockTree", zap.Any("req", req))
+func (s *MCPServer) handleRemoveProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRemoveProperty", zap.Any("req", req))
 	var args struct {
-		ParentUUID string `json:"parent_uuid"`
-		Tree       string `json:"tree"`
-		Sibling    bool   `json:"sibling"`
-		Before     bool   `json:"before"`
+		UUID string `json:"uuid"`
+		Key  string `json:"key"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.ParentUUID == "" {
-		return mcp.NewToolResultError("A parent UUID (page or block) is required. Please provide a valid identifier for where the tree should be inserted."), nil
+	if res := requireString("uuid", args.UUID, "the identifier for the entity from which to remove the property"); res != nil {
+		return res, nil
 	}
-	if args.Tree == "" {
-		return mcp.NewToolResultError("A tree structure (JSON array) is required. Please provide a valid list of blocks and their nested children."), nil
+	if res := requireString("key", args.Key, "the name of the attribute you wish to remove"); res != nil {
+		return res, nil
 	}
 
-	var batch []logseq.BlockContent
-	if err := json.Unmarshal([]byte(args.Tree), &batch); err != nil {
-		return mcp.NewToolResultError("The tree structure provided is not valid JSON. Please check your formatting and ensure it matches the BlockContent structure."), nil
+	if err := s.client.RemoveProperty(args.UUID, args.Key); err != nil {
+		s.logger.Error("handleRemoveProperty failed", zap.String("uuid", args.UUID), zap.String("key", args.Key), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to remove the property: %v. Please ensure the entity exists and contains the specified attribute.", err)), nil
 	}
 
-	if s.mode == ModeOntological {
-		var transformTree func([]logseq.BlockContent)
-		transformTree = func(blocks []logseq.BlockContent) {
-			for i := range blocks {
-				blocks[i].Properties = toSnakeCaseKeys(blocks[i].Properties)
-				if len(blocks[i].Children) > 0 {
-					transformTree(blocks[i].Children)
-				}
-			}
-		}
-		transformTree(batch)
+	return mcp.NewToolResultText(fmt.Sprintf("Property '%s' successfully removed from %s.", args.Key, args.UUID)), nil
+}
+
+func (s *MCPServer) handleClearProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleClearProperty", zap.Any("req", req))
+	var args struct {
+		UUID string `json:"uuid"`
+		Key  string `json:"key"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the entity whose property you wish to clear"); res != nil {
+		return res, nil
+	}
+	if res := requireString("key", args.Key, "the name of the attribute you wish to clear"); res != nil {
+		return res, nil
 	}
 
-	options := make(map[string]any)
-	if args.Sibling {
-		options["sibling"] = true
-		if args.Before {
-			options["before"] = true
-		}
+	key := args.Key
+	if s.mode == ModeOntological {
+		key = s.normalizeKey(key)
 	}
 
-	blocks, err := s.client.InsertBatchBlock(args.ParentUUID, batch, options)
-	if err != nil {
-		s.logger.Error("handleCreateBlockTree failed", zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to insert the block tree: %v. Please ensure the parent exists and the tree structure is valid.", err)), nil
+	if err := s.client.UpsertProperty(args.UUID, key, ""); err != nil {
+		s.logger.Error("handleClearProperty failed", zap.String("uuid", args.UUID), zap.String("key", key), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to clear the property: %v. Please ensure the entity exists.", err)), nil
 	}
 
-	// Create a summary of created blocks
-	return mcp.NewToolResultText(fmt.Sprintf("Successfully inserted %d blocks into the tree.", len(blocks))), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Property '%s' cleared on %s (the key was kept with an empty value).", key, args.UUID)), nil
 }
 
-func (s *MCPServer) handleAppendBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleAppendBlock", zap.Any("req", req))
+func (s *MCPServer) handleRenameProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRenameProperty", zap.Any("req", req))
 	var args struct {
-		UUID    string `json:"uuid"`
-		Content string `json:"content"`
+		UUID   string `json:"uuid"`
+		OldKey string `json:"old_key"`
+		NewKey string `json:"new_key"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A page name or UUID is required. Please provide the identifier for the page where the block should be appended."), nil
+	if res := requireString("uuid", args.UUID, "the identifier for the entity whose property you wish to rename"); res != nil {
+		return res, nil
 	}
-	if args.Content == "" {
-		return mcp.NewToolResultError("Block content is required. Please provide the text to append."), nil
+	if res := requireString("old_key", args.OldKey, "the current name of the attribute"); res != nil {
+		return res, nil
+	}
+	if res := requireString("new_key", args.NewKey, "the new name of the attribute"); res != nil {
+		return res, nil
 	}
 
-	block, err := s.client.AppendBlockInPage(args.UUID, args.Content, nil)
-	if err != nil {
-		s.logger.Error("handleAppendBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to append the block: %v. Please ensure the page exists.", err)), nil
+	newKey := args.NewKey
+	if s.mode == ModeOntological {
+		newKey = s.normalizeKey(newKey)
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Block successfully appended to '%s'. New block UUID: %s", args.UUID, block.UUID)), nil
+	if err := s.client.RenameProperty(args.UUID, args.OldKey, newKey); err != nil {
+		s.logger.Error("handleRenameProperty failed", zap.String("uuid", args.UUID), zap.String("old_key", args.OldKey), zap.String("new_key", newKey), zap.Error(err))
+		if errors.Is(err, logseq.ErrPropertyNotFound) {
+			return mcp.NewToolResultError(fmt.Sprintf("The property '%s' does not exist on %s. Please check the existing property keys first.", args.OldKey, args.UUID)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to rename the property: %v.", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Property '%s' successfully renamed to '%s' on %s.", args.OldKey, newKey, args.UUID)), nil
 }
 
-func (s *MCPServer) handleUpdateBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleUpdateBlock", zap.Any("req", req))
+func (s *MCPServer) handleRenamePropertyEverywhere(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRenamePropertyEverywhere", zap.Any("req", req))
 	var args struct {
-		UUID       string `json:"uuid"`
-		Content    string `json:"content"`
-		Properties string `json:"properties"`
+		OldKey  string `json:"old_key"`
+		NewKey  string `json:"new_key"`
+		Confirm bool   `json:"confirm"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A block UUID is required. Please provide the unique identifier for the block you wish to update."), nil
+	if res := requireString("old_key", args.OldKey, "the current name of the attribute"); res != nil {
+		return res, nil
 	}
-
-	var props map[string]any
-	if args.Properties != "" {
-		if err := json.Unmarshal([]byte(args.Properties), &props); err != nil {
-			return mcp.NewToolResultError("The properties provided are not valid JSON. Please check your formatting."), nil
-		}
+	if res := requireString("new_key", args.NewKey, "the new name of the attribute"); res != nil {
+		return res, nil
+	}
+	if !args.Confirm {
+		return mcp.NewToolResultError("This renames the property on every page in the graph. Please set 'confirm' to true to proceed."), nil
 	}
 
+	newKey := args.NewKey
 	if s.mode == ModeOntological {
-		props = toSnakeCaseKeys(props)
+		newKey = s.normalizeKey(newKey)
 	}
 
-	block, err := s.client.UpdateBlock(args.UUID, args.Content, props)
+	count, renameErrs, err := s.client.RenamePropertyEverywhere(args.OldKey, newKey)
 	if err != nil {
-		s.logger.Error("handleUpdateBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to update the block: %v. Please ensure the UUID is correct and the block still exists.", err)), nil
+		s.logger.Error("handleRenamePropertyEverywhere failed", zap.String("old_key", args.OldKey), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not find pages using the property '%s': %v.", args.OldKey, err)), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Block updated successfully: %s", block.UUID)), nil
+	if len(renameErrs) > 0 {
+		var errs []string
+		for _, e := range renameErrs {
+			errs = append(errs, e.Error())
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Renamed '%s' to '%s' on %d pages, but failed for: %v", args.OldKey, newKey, count, errs)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Renamed '%s' to '%s' on %d pages.", args.OldKey, newKey, count)), nil
 }
 
-func (s *MCPServer) handleDeleteBlock(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleDeleteBlock", zap.Any("req", req))
+func (s *MCPServer) handleReplaceEverywhere(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleReplaceEverywhere", zap.Any("req", req))
 	var args struct {
-		UUID string `json:"uuid"`
+		Find          string `json:"find"`
+		Replace       string `json:"replace"`
+		CaseSensitive *bool  `json:"case_sensitive"`
+		WholeWord     bool   `json:"whole_word"`
+		IncludeRefs   bool   `json:"include_refs"`
+		DryRun        bool   `json:"dry_run"`
+		Confirm       bool   `json:"confirm"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A block UUID is required. Please provide the identifier for the block you wish to delete."), nil
+	if res := requireString("find", args.Find, "the text you wish to replace"); res != nil {
+		return res, nil
 	}
-	if err := s.client.DeleteBlock(args.UUID); err != nil {
-		s.logger.Error("handleDeleteBlock failed", zap.String("uuid", args.UUID), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to delete the block: %v. Please ensure the UUID is correct.", err)), nil
+	if !args.DryRun && !args.Confirm {
+		return mcp.NewToolResultError("This replaces the text in every block of the graph. Please set 'confirm' to true to proceed, or 'dry_run' to true to preview the changes."), nil
 	}
-	return mcp.NewToolResultText(fmt.Sprintf("Block successfully deleted: %s", args.UUID)), nil
+
+	opts := logseq.ReplaceOptions{
+		Find:          args.Find,
+		Replace:       args.Replace,
+		CaseSensitive: args.CaseSensitive == nil || *args.CaseSensitive,
+		WholeWord:     args.WholeWord,
+		IncludeRefs:   args.IncludeRefs,
+	}
+
+	if args.DryRun {
+		matches, err := s.client.FindReplaceMatches(opts)
+		if err != nil {
+			s.logger.Error("handleReplaceEverywhere failed", zap.String("find", args.Find), zap.Error(err))
+			if logseq.IsUnauthorized(err) {
+				return mcp.NewToolResultError(unauthorizedMessage), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("Could not search for '%s': %v. Please check if Logseq is running.", args.Find, err)), nil
+		}
+		jsonMatches, _ := json.MarshalIndent(matches, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Dry run: %d block(s) would change.\n%s", len(matches), jsonMatches)), nil
+	}
+
+	count, replaceErrs, err := s.client.ReplaceEverywhere(opts)
+	if err != nil {
+		s.logger.Error("handleReplaceEverywhere failed", zap.String("find", args.Find), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not search for '%s': %v. Please check if Logseq is running.", args.Find, err)), nil
+	}
+
+	if len(replaceErrs) > 0 {
+		var errs []string
+		for _, e := range replaceErrs {
+			errs = append(errs, e.Error())
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Replaced '%s' with '%s' in %d block(s), but failed for: %v", args.Find, args.Replace, count, errs)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Replaced '%s' with '%s' in %d block(s).", args.Find, args.Replace, count)), nil
 }
 
-func (s *MCPServer) handleDeleteBlocks(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleDeleteBlocks", zap.Any("req", req))
+func (s *MCPServer) handleUpsertProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleUpsertProperty", zap.Any("req", req))
 	var args struct {
-		UUIDs string `json:"uuids"`
+		UUID  string `json:"uuid"`
+		Key   string `json:"key"`
+		Value string `json:"value"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the entity to which to add/update the property"); res != nil {
+		return res, nil
 	}
-	if args.UUIDs == "" {
-		return mcp.NewToolResultError("A list of UUIDs is required. Please provide a JSON array of block identifiers to delete."), nil
+	if res := requireString("key", args.Key, "the name of the attribute you wish to add/update"); res != nil {
+		return res, nil
 	}
 
-	var uuids []string
-	if err := json.Unmarshal([]byte(args.UUIDs), &uuids); err != nil {
-		return mcp.NewToolResultError("The list of UUIDs provided is not valid JSON. Please check your formatting and ensure it is a JSON array of strings."), nil
+	key := args.Key
+	if s.mode == ModeOntological {
+		key = s.normalizeKey(key)
 	}
 
-	count := 0
-	var errs []string
+	if err := s.client.UpsertProperty(args.UUID, key, s.coerceValue(args.Value)); err != nil {
+		s.logger.Error("handleUpsertProperty failed", zap.String("uuid", args.UUID), zap.String("key", key), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to add/update the property: %v. Please ensure the entity exists.", err)), nil
+	}
 
-	for _, uuid := range uuids {
-		if err := s.client.DeleteBlock(uuid); err != nil {
-			s.logger.Error("Failed to delete block in handleDeleteBlocks", zap.String("uuid", uuid), zap.Error(err))
-			errs = append(errs, fmt.Sprintf("%s: %v", uuid, err))
-		} else {
-			count++
+	return mcp.NewToolResultText(fmt.Sprintf("Property '%s' successfully added/updated on %s.", key, args.UUID)), nil
+}
+
+func (s *MCPServer) handleFindDuplicatePages(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleFindDuplicatePages", zap.Any("req", req))
+
+	groups, err := s.client.FindDuplicatePages()
+	if err != nil {
+		s.logger.Error("handleFindDuplicatePages failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
 		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not scan for duplicate pages: %v. Please ensure Logseq is running.", err)), nil
 	}
 
-	if len(errs) > 0 {
-		return mcp.NewToolResultError(fmt.Sprintf("Deleted %d blocks, but failed for: %v. Please verify the remaining UUIDs are correct.", count, errs)), nil
+	type pageRef struct {
+		Name string `json:"name"`
+		UUID string `json:"uuid"`
+	}
+	result := make([][]pageRef, 0, len(groups))
+	for _, group := range groups {
+		refs := make([]pageRef, 0, len(group))
+		for _, p := range group {
+			name := p.OriginalName
+			if name == "" {
+				name = p.Name
+			}
+			refs = append(refs, pageRef{Name: name, UUID: p.UUID})
+		}
+		result = append(result, refs)
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Successfully deleted %d blocks.", count)), nil
+	jsonResult, _ := json.MarshalIndent(result, "", "  ")
+	return mcp.NewToolResultText(string(jsonResult)), nil
 }
 
-func (s *MCPServer) handleAddTag(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleAddTag", zap.Any("req", req))
+func (s *MCPServer) handleFindOrphans(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleFindOrphans", zap.Any("req", req))
 	var args struct {
-		UUID string `json:"uuid"`
-		Tag  string `json:"tag"`
+		IncludeJournals bool `json:"include_journals"`
+	}
+	if err := parseArguments(req, &args); err != nil {
+		return invalidArguments(err), nil
+	}
+
+	pages, err := s.client.FindOrphanPages()
+	if err != nil {
+		s.logger.Error("handleFindOrphans failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not scan for orphan pages: %v. Please ensure Logseq is running.", err)), nil
+	}
+
+	journalName := logseq.JournalNamePattern(s.client.JournalDateFormat())
+	names := []string{}
+	for _, p := range pages {
+		if !args.IncludeJournals && (p.Journal || logseq.IsJournalName(p.Name) || journalName.MatchString(p.Name)) {
+			continue
+		}
+		name := p.OriginalName
+		if name == "" {
+			name = p.Name
+		}
+		names = append(names, name)
+	}
+
+	jsonNames, _ := json.MarshalIndent(names, "", "  ")
+	return mcp.NewToolResultText(string(jsonNames)), nil
+}
+
+func (s *MCPServer) handleValidateGraph(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleValidateGraph", zap.Any("req", req))
+	var args struct {
+		SampleLimit int `json:"sample_limit"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A UUID or page name is required. Please provide the identifier for the entity you wish to tag."), nil
+	if args.SampleLimit < 0 {
+		return mcp.NewToolResultError("The 'sample_limit' argument must not be negative. Please omit it to use the default."), nil
 	}
-	if args.Tag == "" {
-		return mcp.NewToolResultError("A tag is required. Please provide the text for the tag you wish to add."), nil
+	if args.SampleLimit == 0 {
+		args.SampleLimit = DefaultValidationSampleSize
 	}
+	args.SampleLimit = min(args.SampleLimit, MaxValidationSampleSize)
 
-	if err := s.client.AddTag(args.UUID, args.Tag); err != nil {
-		s.logger.Error("handleAddTag failed", zap.String("uuid", args.UUID), zap.String("tag", args.Tag), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to add the tag: %v. Please ensure the target exists and the tag format is valid.", err)), nil
+	report, err := s.client.ValidateGraph(args.SampleLimit)
+	if err != nil {
+		s.logger.Error("handleValidateGraph failed", zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		if errors.Is(err, logseq.ErrQueryAPIDisabled) {
+			return mcp.NewToolResultError(fmt.Sprintf("Could not validate the graph: %v.", err)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Could not validate the graph: %v. Please ensure Logseq is running.", err)), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Tag '%s' successfully added to %s.", args.Tag, args.UUID)), nil
+	jsonReport, _ := json.MarshalIndent(report, "", "  ")
+	return mcp.NewToolResultText(string(jsonReport)), nil
 }
 
-func (s *MCPServer) handleRemoveTag(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleRemoveTag", zap.Any("req", req))
+func (s *MCPServer) handleFindBrokenRefs(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleFindBrokenRefs", zap.Any("req", req))
 	var args struct {
-		UUID string `json:"uuid"`
-		Tag  string `json:"tag"`
+		Repair string `json:"repair"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A UUID or page name is required. Please provide the identifier for the entity from which to remove the tag."), nil
+	if args.Repair != "" && args.Repair != "strip" {
+		return mcp.NewToolResultError(fmt.Sprintf("Unknown repair mode: '%s'. The only supported mode is 'strip'; omit it to only report broken references.", args.Repair)), nil
 	}
-	if args.Tag == "" {
-		return mcp.NewToolResultError("A tag is required. Please provide the text for the tag you wish to remove."), nil
+
+	broken, err := s.client.FindBrokenBlockRefs()
+	if err != nil {
+		s.logger.Error("handleFindBrokenRefs failed", zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Could not scan for broken block references: %v. Please ensure Logseq is running.", err)), nil
 	}
 
-	if err := s.client.RemoveTag(args.UUID, args.Tag); err != nil {
-		s.logger.Error("handleRemoveTag failed", zap.String("uuid", args.UUID), zap.String("tag", args.Tag), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to remove the tag: %v. Please ensure the entity exists and contains the specified tag.", err)), nil
+	if args.Repair == "strip" && len(broken) > 0 {
+		// Group dangling refs per block so each block is rewritten once
+		var order []string
+		refsByBlock := make(map[string][]string)
+		for _, b := range broken {
+			if _, ok := refsByBlock[b.BlockUUID]; !ok {
+				order = append(order, b.BlockUUID)
+			}
+			refsByBlock[b.BlockUUID] = append(refsByBlock[b.BlockUUID], b.Ref)
+		}
+
+		count := 0
+		var errs []string
+		for _, uuid := range order {
+			if err := s.client.StripBlockRefs(uuid, refsByBlock[uuid]); err != nil {
+				s.logger.Error("Failed to strip refs in handleFindBrokenRefs", zap.String("uuid", uuid), zap.Error(err))
+				errs = append(errs, fmt.Sprintf("%s: %v", uuid, err))
+			} else {
+				count++
+			}
+		}
+
+		jsonBroken, _ := json.MarshalIndent(broken, "", "  ")
+		if len(errs) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("Repaired %d blocks, but failed for: %v. Broken references found:\n%s", count, errs, string(jsonBroken))), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Stripped %d broken references from %d blocks:\n%s", len(broken), count, string(jsonBroken))), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Tag '%s' successfully removed from %s.", args.Tag, args.UUID)), nil
+	jsonBroken, _ := json.MarshalIndent(broken, "", "  ")
+	return mcp.NewToolResultText(string(jsonBroken)), nil
 }
 
-func (s *MCPServer) handleRemoveProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleRemoveProperty", zap.Any("req", req))
+func (s *MCPServer) handleRawAPI(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleRawAPI", zap.Any("req", req))
 	var args struct {
-		UUID string `json:"uuid"`
-		Key  string `json:"key"`
+		Method string `json:"method"`
+		Args   string `json:"args"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A UUID or page name is required. Please provide the identifier for the entity from which to remove the property."), nil
+	if res := requireString("method", args.Method, "a Logseq API method such as 'logseq.Editor.getBlock'"); res != nil {
+		return res, nil
 	}
-	if args.Key == "" {
-		return mcp.NewToolResultError("A property key is required. Please provide the name of the attribute you wish to remove."), nil
+	if s.readOnly && !isReadMethod(args.Method) {
+		return mcp.NewToolResultError(fmt.Sprintf("The method '%s' is not a known read method and the server runs with --read-only. Only logseq.Editor.get*, logseq.App.get*, logseq.DB.q, logseq.DB.datascriptQuery and a few other reads are allowed.", args.Method)), nil
+	}
+	callArgs := []any{}
+	if args.Args != "" {
+		if res := requireJSON("args", args.Args, &callArgs, "a JSON array of positional arguments"); res != nil {
+			return res, nil
+		}
 	}
 
-	if err := s.client.RemoveProperty(args.UUID, args.Key); err != nil {
-		s.logger.Error("handleRemoveProperty failed", zap.String("uuid", args.UUID), zap.String("key", args.Key), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to remove the property: %v. Please ensure the entity exists and contains the specified attribute.", err)), nil
+	resp, err := s.client.Call(args.Method, callArgs...)
+	if err != nil {
+		s.logger.Error("handleRawAPI failed", zap.String("method", args.Method), zap.Error(err))
+		if logseq.IsUnauthorized(err) {
+			return mcp.NewToolResultError(unauthorizedMessage), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("Calling %s failed: %v. Please check the method name and arguments.", args.Method, err)), nil
 	}
+	return mcp.NewToolResultText(string(resp)), nil
+}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Property '%s' successfully removed from %s.", args.Key, args.UUID)), nil
+// isRelationshipValue reports whether a property value consists only of [[page links]]
+func isRelationshipValue(value string) bool {
+	links := logseq.ExtractLinks(value)
+	if len(links) == 0 {
+		return false
+	}
+	rest := value
+	for _, link := range links {
+		rest = strings.ReplaceAll(rest, "[["+link+"]]", "")
+	}
+	// Only whitespace and list separators may remain
+	return strings.Trim(rest, " ,\t") == ""
 }
 
-func (s *MCPServer) handleUpsertProperty(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	s.logger.Debug("handleUpsertProperty", zap.Any("req", req))
+func (s *MCPServer) handleAddRelationship(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.logger.Debug("handleAddRelationship", zap.Any("req", req))
 	var args struct {
 		UUID  string `json:"uuid"`
 		Key   string `json:"key"`
 		Value string `json:"value"`
+		Class string `json:"class"`
 	}
 	if err := parseArguments(req, &args); err != nil {
-		return mcp.NewToolResultError("Invalid arguments provided. Please check the tool definition and try again."), nil
+		return invalidArguments(err), nil
+	}
+	if res := requireString("uuid", args.UUID, "the identifier for the entity to which to add the relationship"); res != nil {
+		return res, nil
 	}
-	if args.UUID == "" {
-		return mcp.NewToolResultError("A UUID or page name is required. Please provide the identifier for the entity to which to add/update the property."), nil
+	if res := requireString("key", args.Key, "the name of the relationship (e.g., 'author')"); res != nil {
+		return res, nil
 	}
-	if args.Key == "" {
-		return mcp.NewToolResultError("A property key is required. Please provide the name of the attribute you wish to add/update."), nil
+	if !isRelationshipValue(args.Value) {
+		return mcp.NewToolResultError(fmt.Sprintf("The value '%s' is not a relationship. Relationships must consist only of page links like '[[Alice Smith]]'. Use add_property for plain Attributes.", args.Value)), nil
 	}
 
-	key := args.Key
-	if s.mode == ModeOntological {
-		key = ToSnakeCase(key)
+	key := s.normalizeKey(args.Key)
+
+	// Make sure linked Instances exist (namespaced links are rewritten to UUID refs)
+	props := map[string]any{key: args.Value}
+	s.client.EnsureLinkedPages("", props)
+
+	if err := s.client.UpsertProperty(args.UUID, key, props[key]); err != nil {
+		s.logger.Error("handleAddRelationship failed", zap.String("uuid", args.UUID), zap.String("key", key), zap.Error(err))
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to add the relationship: %v. Please ensure the entity exists.", err)), nil
 	}
 
-	if err := s.client.UpsertProperty(args.UUID, key, args.Value); err != nil {
-		s.logger.Error("handleUpsertProperty failed", zap.String("uuid", args.UUID), zap.String("key", key), zap.Error(err))
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to add/update the property: %v. Please ensure the entity exists.", err)), nil
+	if args.Class != "" {
+		var errs []string
+		for _, link := range logseq.ExtractLinks(args.Value) {
+			if err := s.client.AddTag(link, args.Class); err != nil {
+				s.logger.Error("Failed to tag linked instance in handleAddRelationship", zap.String("page", link), zap.Error(err))
+				errs = append(errs, fmt.Sprintf("%s: %v", link, err))
+			}
+		}
+		if len(errs) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("Relationship '%s' added to %s, but tagging linked Instances as '%s' failed for: %v.", key, args.UUID, args.Class, errs)), nil
+		}
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Property '%s' successfully added/updated on %s.", key, args.UUID)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Relationship '%s' successfully added/updated on %s.", key, args.UUID)), nil
 }
@@ -3,17 +3,27 @@ package server_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/clstb/yalms/internal/server"
 	"github.com/clstb/yalms/pkg/logseq"
 	"github.com/mark3labs/mcp-go/mcp"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+// testBlockUUID is a well-formed block UUID for tools that require one
+const testBlockUUID = "6571c5b8-1f2a-4c3d-9e8f-0a1b2c3d4e5f"
+
 func setupTestServer() (*server.MCPServer, *logseq.Client) {
 	logger := zap.NewNop()
 	client := logseq.NewClient("http://localhost:12345", "token", logger)
@@ -145,7 +155,7 @@ func TestServer_UpdateBlock_Errors(t *testing.T) {
 		t.Errorf("Expected error result for missing uuid, got %v", res)
 	}
 
-	req = makeRequest("update_block", map[string]any{"uuid": "u1", "properties": "{invalid"})
+	req = makeRequest("update_block", map[string]any{"uuid": testBlockUUID, "properties": "{invalid"})
 	res, err = s.HandleUpdateBlock(context.Background(), req)
 	if err != nil || !res.IsError {
 		t.Errorf("Expected error result for invalid properties, got %v", res)
@@ -409,7 +419,7 @@ func TestServer_CreatePages_Success(t *testing.T) {
 func TestServer_ReadBlock_Success(t *testing.T) {
 	ts, s := setupSuccessMock()
 	defer ts.Close()
-	req := makeRequest("read_block", map[string]any{"uuid": "b1"})
+	req := makeRequest("read_block", map[string]any{"uuid": testBlockUUID})
 	res, err := s.HandleReadBlock(context.Background(), req)
 	if err != nil || res.IsError {
 		t.Errorf("handleReadBlock failed: %v", res)
@@ -436,6 +446,82 @@ func TestServer_CreateBlock_Success(t *testing.T) {
 	}
 }
 
+func TestServer_CoercePropertyTypes(t *testing.T) {
+	upserted := make(map[string]any)
+	var batch []any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.upsertBlockProperty":
+			upserted[body.Args[1].(string)] = body.Args[2]
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"uuid": "p1", "name": "book"}`))
+			return
+		case "logseq.Editor.insertBatchBlock":
+			batch, _ = body.Args[1].([]any)
+			w.Write([]byte(`[{"uuid": "b1"}]`))
+			return
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger, logseq.WithoutLinkAutoCreate())
+	s := server.NewMCPServer(client, logger, server.ModeGeneral, server.WithPropertyTypeCoercion())
+
+	res, err := s.HandleUpdatePage(context.Background(), makeRequest("update_page", map[string]any{
+		"uuid": "p1", "properties": `{"year": "1937", "rating": "4.5", "read": "true", "author": "[[Tolkien]]", "isbn": "0261103571"}`,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleUpdatePage failed: %v", res)
+	}
+	// JSON numbers decode as float64 on the mock side; strings stay strings
+	want := map[string]any{"year": float64(1937), "rating": 4.5, "read": true, "author": "[[Tolkien]]", "isbn": "0261103571"}
+	for k, v := range want {
+		if upserted[k] != v {
+			t.Errorf("Expected %s = %#v, got %#v", k, v, upserted[k])
+		}
+	}
+
+	res, err = s.HandleCreateBlockTree(context.Background(), makeRequest("create_block_tree", map[string]any{
+		"parent_uuid": testBlockUUID,
+		"tree":        `[{"content": "Review", "properties": {"rating": "4"}, "children": [{"content": "Reread", "properties": {"done": "false"}}]}]`,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreateBlockTree failed: %v", res)
+	}
+	if len(batch) != 1 {
+		t.Fatalf("Expected one top-level block, got %v", batch)
+	}
+	top := batch[0].(map[string]any)
+	child := top["children"].([]any)[0].(map[string]any)
+	if top["properties"].(map[string]any)["rating"] != float64(4) || child["properties"].(map[string]any)["done"] != false {
+		t.Errorf("Expected block properties to be coerced at every level, got %v", batch)
+	}
+
+	// Values the server writes itself are not user input and stay strings
+	upserted = make(map[string]any)
+	res, err = s.HandleAddAlias(context.Background(), makeRequest("add_alias", map[string]any{"uuid": "p1", "alias": "1984"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleAddAlias failed: %v", res)
+	}
+	if upserted["alias"] != "1984" {
+		t.Errorf("Expected the alias to stay a string, got %#v", upserted["alias"])
+	}
+
+	upserted = make(map[string]any)
+	plain := server.NewMCPServer(client, logger, server.ModeGeneral)
+	res, _ = plain.HandleUpsertProperty(context.Background(), makeRequest("upsert_property", map[string]any{"uuid": "p1", "key": "year", "value": "1937"}))
+	if res.IsError || upserted["year"] != "1937" {
+		t.Errorf("Expected values to pass through without coercion, got %#v", upserted["year"])
+	}
+}
+
 func TestServer_CreateBlockTree_Success(t *testing.T) {
 	ts, s := setupSuccessMock()
 	defer ts.Close()
@@ -459,7 +545,7 @@ func TestServer_DeleteBlocks_Success(t *testing.T) {
 func TestServer_UpdateBlock_Success(t *testing.T) {
 	ts, s := setupSuccessMock()
 	defer ts.Close()
-	req := makeRequest("update_block", map[string]any{"uuid": "b1", "content": "c1"})
+	req := makeRequest("update_block", map[string]any{"uuid": testBlockUUID, "content": "c1"})
 	res, err := s.HandleUpdateBlock(context.Background(), req)
 	if err != nil || res.IsError {
 		t.Errorf("handleUpdateBlock failed: %v", res)
@@ -569,7 +655,7 @@ func TestServer_UpdatePage_Success(t *testing.T) {
 func TestServer_DeleteBlock_Success(t *testing.T) {
 	ts, s := setupSuccessMock()
 	defer ts.Close()
-	req := makeRequest("delete_block", map[string]any{"uuid": "b1"})
+	req := makeRequest("delete_block", map[string]any{"uuid": testBlockUUID})
 	res, err := s.HandleDeleteBlock(context.Background(), req)
 	if err != nil || res.IsError {
 		t.Errorf("handleDeleteBlock failed: %v", res)
@@ -663,7 +749,7 @@ func TestServer_ReadBlock_APIError(t *testing.T) {
 	client := logseq.NewClient(ts.URL, "token", logger)
 	s := server.NewMCPServer(client, logger, server.ModeGeneral)
 
-	req := makeRequest("read_block", map[string]any{"uuid": "b1"})
+	req := makeRequest("read_block", map[string]any{"uuid": testBlockUUID})
 	res, err := s.HandleReadBlock(context.Background(), req)
 	if err != nil || !res.IsError {
 		t.Errorf("Expected error for handleReadBlock, got %v", res)
@@ -839,6 +925,48 @@ func TestServer_RegisterTools_ModeSwitch(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("JournalMode", func(t *testing.T) {
+		s := server.NewMCPServer(client, logger, server.ModeJournal)
+		tools := s.GetServer().ListTools()
+		var names []string
+		for name := range tools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		expected := []string{"append_block", "append_to_journal", "create_journal_page", "get_daily_journal", "query"}
+		if !reflect.DeepEqual(names, expected) {
+			t.Errorf("Expected journal tools %v, got %v", expected, names)
+		}
+	})
+}
+
+func TestParseLogseqMode(t *testing.T) {
+	for input, expected := range map[string]server.LogseqMode{
+		"general":        server.ModeGeneral,
+		" Ontological\n": server.ModeOntological,
+		"JOURNAL":        server.ModeJournal,
+	} {
+		mode, err := server.ParseLogseqMode(input)
+		if err != nil || mode != expected {
+			t.Errorf("ParseLogseqMode(%q) = %q, %v; expected %q", input, mode, err, expected)
+		}
+	}
+
+	_, err := server.ParseLogseqMode("ontologcal")
+	if err == nil || !strings.Contains(err.Error(), "general, ontological, journal") {
+		t.Errorf("Expected an error listing valid modes, got %v", err)
+	}
+	if server.LogseqMode("ontologcal").IsValid() {
+		t.Errorf("Expected ontologcal to be invalid")
+	}
+
+	// NewMCPServer falls back to general mode instead of registering nothing
+	client := logseq.NewClient("http://localhost", "token", nil)
+	s := server.NewMCPServer(client, zap.NewNop(), server.LogseqMode("ontologcal"))
+	if s.GetServer().ListTools()["create_pages"] == nil {
+		t.Errorf("Expected an invalid mode to fall back to general mode")
+	}
 }
 
 func TestUtils_ToSnakeCase(t *testing.T) {
@@ -859,3 +987,2372 @@ func TestUtils_ToSnakeCase(t *testing.T) {
 		}
 	}
 }
+
+func TestUtils_NormalizeKey(t *testing.T) {
+	tests := []struct {
+		style    server.KeyStyle
+		input    string
+		expected string
+	}{
+		{server.KeyStyleSnake, "publicationDate", "publication_date"},
+		{server.KeyStyleSnake, "already_snake", "already_snake"},
+		{server.KeyStyleKebab, "publicationDate", "publication-date"},
+		{server.KeyStyleKebab, "publication_date", "publication-date"},
+		{server.KeyStyleKebab, "Normal", "normal"},
+		{server.KeyStyleCamel, "publication_date", "publicationDate"},
+		{server.KeyStyleCamel, "publication-date", "publicationDate"},
+		{server.KeyStyleCamel, "PublicationDate", "publicationDate"},
+		{server.KeyStyleCamel, "_leading", "leading"},
+		{server.KeyStyleNone, "publicationDate", "publicationDate"},
+		{server.KeyStyle(""), "publicationDate", "publication_date"},
+	}
+
+	for _, tt := range tests {
+		res := server.NormalizeKey(tt.style, tt.input)
+		if res != tt.expected {
+			t.Errorf("NormalizeKey(%q, %s) = %s, want %s", tt.style, tt.input, res, tt.expected)
+		}
+	}
+}
+
+func TestServer_KeyStyle(t *testing.T) {
+	var upsertKey string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.upsertBlockProperty" {
+			upsertKey = body.Args[1].(string)
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+
+	for style, expected := range map[server.KeyStyle]string{
+		server.KeyStyleSnake: "publication_date",
+		server.KeyStyleKebab: "publication-date",
+		server.KeyStyleCamel: "publicationDate",
+		server.KeyStyleNone:  "PublicationDate",
+	} {
+		s := server.NewMCPServer(client, logger, server.ModeOntological, server.WithKeyStyle(style))
+		res, err := s.HandleUpsertProperty(context.Background(), makeRequest("add_property", map[string]any{"uuid": testBlockUUID, "key": "PublicationDate", "value": "1937"}))
+		if err != nil || res.IsError {
+			t.Fatalf("handleUpsertProperty with %s keys failed: %v", style, res)
+		}
+		if upsertKey != expected {
+			t.Errorf("Expected %s key %q, got %q", style, expected, upsertKey)
+		}
+	}
+}
+
+func TestServer_FindBrokenRefs_Errors(t *testing.T) {
+	s, _ := setupTestServer()
+	req := makeRequest("find_broken_refs", map[string]any{"repair": "delete"})
+	res, err := s.HandleFindBrokenRefs(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for unknown repair mode, got %v", res)
+	}
+}
+
+func TestServer_FindBrokenRefs_Success(t *testing.T) {
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	req := makeRequest("find_broken_refs", map[string]any{})
+	res, err := s.HandleFindBrokenRefs(context.Background(), req)
+	if err != nil || res.IsError {
+		t.Errorf("handleFindBrokenRefs failed: %v", res)
+	}
+}
+
+func TestServer_UpdateEntities_Errors(t *testing.T) {
+	s, _ := setupTestServer()
+	req := makeRequest("update_entities", map[string]any{})
+	res, err := s.HandleUpdateEntities(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing entities, got %v", res)
+	}
+
+	req = makeRequest("update_entities", map[string]any{"entities": "{invalid"})
+	res, err = s.HandleUpdateEntities(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for invalid entities JSON, got %v", res)
+	}
+}
+
+func TestServer_UpdateEntities_Ontological(t *testing.T) {
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			if body.Args[0] == "missing" {
+				w.Write([]byte(`null`))
+				return
+			}
+			w.Write([]byte(`{"uuid": "u1", "name": "test"}`))
+		case "logseq.Editor.upsertBlockProperty":
+			keys = append(keys, body.Args[1].(string))
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer ts.Close()
+
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	req := makeRequest("update_entities", map[string]any{
+		"entities": `[{"uuid": "a", "properties": {"FirstName": "Ann"}}, {"uuid": "b", "properties": {"LastName": "Bee"}}]`,
+	})
+	res, err := s.HandleUpdateEntities(context.Background(), req)
+	if err != nil || res.IsError {
+		t.Fatalf("handleUpdateEntities failed: %v", res)
+	}
+	if len(keys) != 2 || keys[0] != "first_name" || keys[1] != "last_name" {
+		t.Errorf("Expected snake_case keys, got %v", keys)
+	}
+
+	req = makeRequest("update_entities", map[string]any{
+		"entities": `[{"uuid": "a", "properties": {"k": "v"}}, {"uuid": "missing", "properties": {"k": "v"}}]`,
+	})
+	res, err = s.HandleUpdateEntities(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Fatalf("Expected error result for missing entity, got %v", res)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "Updated 1 entities") || !strings.Contains(text, "missing: not found") {
+		t.Errorf("Unexpected aggregated result: %s", text)
+	}
+}
+
+func TestServer_ImportCSV_Errors(t *testing.T) {
+	s, _ := setupTestServer()
+	req := makeRequest("import_csv", map[string]any{})
+	res, err := s.HandleImportCSV(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing csv, got %v", res)
+	}
+
+	req = makeRequest("import_csv", map[string]any{"csv": "Name\nA", "namespace": "Person", "name_column": "Name", "delimiter": ";;"})
+	res, err = s.HandleImportCSV(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for invalid delimiter, got %v", res)
+	}
+}
+
+func TestServer_ImportCSV_Success(t *testing.T) {
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	req := makeRequest("import_csv", map[string]any{"csv": "Name,Age\nnon_existent,3\n", "namespace": "Person", "name_column": "Name"})
+	res, err := s.HandleImportCSV(context.Background(), req)
+	if err != nil || res.IsError {
+		t.Errorf("handleImportCSV failed: %v", res)
+	}
+}
+
+func TestServer_ExportNamespace_Errors(t *testing.T) {
+	s, _ := setupTestServer()
+	req := makeRequest("export_namespace", map[string]any{})
+	res, err := s.HandleExportNamespace(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing namespace, got %v", res)
+	}
+
+	req = makeRequest("export_namespace", map[string]any{"namespace": "Person", "format": "xml"})
+	res, err = s.HandleExportNamespace(context.Background(), req)
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for unsupported format, got %v", res)
+	}
+}
+
+func TestServer_ExportNamespace_CSV(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[[{"uuid": "u1", "originalName": "Person/Alice", "properties": {"age": 30, "city": "Paris"}}], [{"uuid": "u2", "originalName": "Person/Bob", "properties": {"email": "b@x.y"}}], [{"uuid": "u3"}]]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	req := makeRequest("export_namespace", map[string]any{"namespace": "Person", "format": "csv"})
+	res, err := s.HandleExportNamespace(context.Background(), req)
+	if err != nil || res.IsError {
+		t.Fatalf("handleExportNamespace failed: %v", res)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	expected := "name,uuid,age,city,email\nPerson/Alice,u1,30,Paris,\nPerson/Bob,u2,,,b@x.y\n\nSkipped 1 pages without a name: [u3]"
+	if text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestServer_ReadNamespace_Recursive(t *testing.T) {
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	req := makeRequest("read_namespace", map[string]any{"namespace": "test_ns", "recursive": true})
+	res, err := s.HandleReadNamespace(context.Background(), req)
+	if err != nil || res.IsError {
+		t.Errorf("handleReadNamespace recursive failed: %v", res)
+	}
+}
+
+func TestServer_AddRelationship(t *testing.T) {
+	var upserted []any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.upsertBlockProperty" {
+			upserted = body.Args
+		}
+		w.Write([]byte(`{"uuid": "u1", "name": "test", "content": "test"}`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	tests := []struct {
+		value   string
+		isError bool
+	}{
+		{"[[Tolkien]]", false},
+		{"[[A]], [[B]]", false},
+		{"Tolkien", true},
+		{"by [[Tolkien]]", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		req := makeRequest("add_relationship", map[string]any{"uuid": "u1", "key": "WrittenBy", "value": tt.value})
+		res, err := s.HandleAddRelationship(context.Background(), req)
+		if err != nil || res.IsError != tt.isError {
+			t.Errorf("add_relationship(%q): expected isError=%v, got %v", tt.value, tt.isError, res)
+		}
+	}
+	if len(upserted) != 3 || upserted[1] != "written_by" {
+		t.Errorf("Expected snake_case relationship key, got %v", upserted)
+	}
+}
+
+func TestServer_GetChildren(t *testing.T) {
+	s, _ := setupTestServer()
+	res, err := s.HandleGetChildren(context.Background(), makeRequest("get_children", map[string]any{}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing uuid, got %v", res)
+	}
+
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	res, err = s.HandleGetChildren(context.Background(), makeRequest("get_children", map[string]any{"uuid": "b1"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleGetChildren failed: %v", res)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; text != "[]" {
+		t.Errorf("Expected empty array for leaf block, got %s", text)
+	}
+}
+
+func TestServer_DeletePages_Concurrent(t *testing.T) {
+	var mu sync.Mutex
+	deleted := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		name := body.Args[0].(string)
+		if strings.HasPrefix(name, "bad") {
+			w.Write([]byte(`{"error": "cannot delete"}`))
+			return
+		}
+		mu.Lock()
+		deleted[name] = true
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral, server.WithBatchConcurrency(3))
+
+	var uuids []string
+	for i := 0; i < 20; i++ {
+		uuids = append(uuids, fmt.Sprintf("p%d", i))
+	}
+	uuids = append(uuids, "bad2", "bad1")
+	uuidsJSON, _ := json.Marshal(uuids)
+
+	res, err := s.HandleDeletePages(context.Background(), makeRequest("delete_pages", map[string]any{"uuids": string(uuidsJSON)}))
+	if err != nil || !res.IsError {
+		t.Fatalf("Expected error result for failing items, got %v", res)
+	}
+	if len(deleted) != 20 {
+		t.Errorf("Expected 20 deleted pages, got %d", len(deleted))
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Deleted 20 pages") || strings.Index(text, "bad2") > strings.Index(text, "bad1") {
+		t.Errorf("Expected deterministic error aggregation in input order, got %s", text)
+	}
+}
+
+func TestServer_GetEntityByID(t *testing.T) {
+	s, _ := setupTestServer()
+	res, err := s.HandleGetEntityByID(context.Background(), makeRequest("get_entity_by_id", map[string]any{"key": "isbn"}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing value, got %v", res)
+	}
+
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	res, err = s.HandleGetEntityByID(context.Background(), makeRequest("get_entity_by_id", map[string]any{"key": "isbn", "value": "123"}))
+	if err != nil || res.IsError {
+		t.Errorf("handleGetEntityByID failed: %v", res)
+	}
+}
+
+func TestServer_UpsertEntity(t *testing.T) {
+	queryResult := `[]`
+	var createdName string
+	var updatedUUID string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.DB.q", "logseq.DB.datascriptQuery":
+			w.Write([]byte(queryResult))
+		case "logseq.Editor.getPage":
+			if createdName == "" && body.Args[0] == "Book/Hobbit" {
+				w.Write([]byte(`null`))
+				return
+			}
+			w.Write([]byte(`{"uuid": "u1", "name": "book/hobbit"}`))
+		case "logseq.Editor.createPage":
+			createdName = body.Args[0].(string)
+			w.Write([]byte(`{"uuid": "u1", "name": "book/hobbit"}`))
+		case "logseq.Editor.upsertBlockProperty":
+			updatedUUID = body.Args[0].(string)
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	args := map[string]any{"match_key": "ISBN", "match_value": "123", "name": "Hobbit", "namespace": "Book", "properties": `{"PageCount": 310}`}
+
+	res, err := s.HandleUpsertEntity(context.Background(), makeRequest("upsert_entity", args))
+	if err != nil || res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "Entity created") {
+		t.Fatalf("Expected entity to be created, got %v", res)
+	}
+	if createdName != "Book/Hobbit" {
+		t.Errorf("Expected page Book/Hobbit to be created, got %q", createdName)
+	}
+
+	queryResult = `[[{"uuid": "u9", "name": "book/hobbit"}]]`
+	res, err = s.HandleUpsertEntity(context.Background(), makeRequest("upsert_entity", args))
+	if err != nil || res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "Entity updated") {
+		t.Fatalf("Expected entity to be updated, got %v", res)
+	}
+	if updatedUUID != "u9" {
+		t.Errorf("Expected matched entity u9 to be updated, got %q", updatedUUID)
+	}
+
+	queryResult = `[[{"uuid": "u1", "name": "a"}], [{"uuid": "u2", "name": "b"}]]`
+	res, err = s.HandleUpsertEntity(context.Background(), makeRequest("upsert_entity", args))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for ambiguous match, got %v", res)
+	}
+}
+
+func TestServer_CreatePages_PerItemResults(t *testing.T) {
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	req := makeRequest("create_pages", map[string]any{"pages": `[{"name": "non_existent"}, {"name": "existing"}]`})
+	res, err := s.HandleCreatePages(context.Background(), req)
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreatePages failed: %v", res)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	lines := strings.SplitN(text, "\n", 2)
+	if lines[0] != "Created 1 pages, 1 already existed, 0 failed." {
+		t.Errorf("Unexpected summary: %s", lines[0])
+	}
+	var items []map[string]string
+	if err := json.Unmarshal([]byte(lines[1]), &items); err != nil {
+		t.Fatalf("Expected JSON item list, got %s", lines[1])
+	}
+	if len(items) != 2 || items[0]["status"] != "created" || items[1]["status"] != "existed" || items[1]["uuid"] != "u1" {
+		t.Errorf("Unexpected per-item results: %v", items)
+	}
+}
+
+func TestServer_AddTags(t *testing.T) {
+	s, _ := setupTestServer()
+	res, err := s.HandleAddTags(context.Background(), makeRequest("add_tags", map[string]any{"uuid": "u1", "tags": "{invalid"}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for invalid tags JSON, got %v", res)
+	}
+
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	res, err = s.HandleAddTags(context.Background(), makeRequest("add_tags", map[string]any{"uuid": "u1", "tags": `["a", "b"]`}))
+	if err != nil || res.IsError {
+		t.Errorf("handleAddTags failed: %v", res)
+	}
+}
+
+func TestServer_RemoveTags(t *testing.T) {
+	s, _ := setupTestServer()
+	res, err := s.HandleRemoveTags(context.Background(), makeRequest("remove_tags", map[string]any{"uuid": "u1"}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing tags, got %v", res)
+	}
+
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	res, err = s.HandleRemoveTags(context.Background(), makeRequest("remove_tags", map[string]any{"uuid": "u1", "tags": `["a"]`}))
+	if err != nil || res.IsError {
+		t.Errorf("handleRemoveTags failed: %v", res)
+	}
+}
+
+func TestServer_SetTaskState(t *testing.T) {
+	s, _ := setupTestServer()
+	res, err := s.HandleSetTaskState(context.Background(), makeRequest("set_task_state", map[string]any{"uuid": "b1", "state": "STARTED"}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for invalid state, got %v", res)
+	}
+
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+	res, err = s.HandleSetTaskState(context.Background(), makeRequest("set_task_state", map[string]any{"uuid": "b1", "state": "TODO"}))
+	if err != nil || res.IsError {
+		t.Errorf("handleSetTaskState failed: %v", res)
+	}
+}
+
+func TestServer_GetPageProperties(t *testing.T) {
+	s, _ := setupTestServer()
+	res, err := s.HandleGetPageProperties(context.Background(), makeRequest("get_page_properties", map[string]any{}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing uuid, got %v", res)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Args[0] == "empty" {
+			w.Write([]byte(`{"uuid": "u2", "name": "empty"}`))
+			return
+		}
+		w.Write([]byte(`{"uuid": "u1", "name": "book", "properties": {"published_date": "1937"}}`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s = server.NewMCPServer(client, logger, server.ModeOntological)
+
+	res, err = s.HandleGetPageProperties(context.Background(), makeRequest("get_entity_attributes", map[string]any{"uuid": "book", "display": true}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleGetPageProperties failed: %v", res)
+	}
+	var props map[string]any
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &props)
+	if len(props) != 1 || props["published date"] != "1937" {
+		t.Errorf("Expected only display-form properties, got %v", props)
+	}
+
+	res, _ = s.HandleGetPageProperties(context.Background(), makeRequest("get_entity_attributes", map[string]any{"uuid": "empty"}))
+	if text := res.Content[0].(mcp.TextContent).Text; text != "{}" {
+		t.Errorf("Expected {} for page without properties, got %s", text)
+	}
+}
+
+func TestServer_ReadNamespace_Pagination(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"uuid": "u3", "name": "ns/c"}, {"uuid": "u1", "name": "ns/a"}, {"uuid": "u2", "name": "ns/b"}]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	type response struct {
+		Total int           `json:"total"`
+		Pages []logseq.Page `json:"pages"`
+	}
+	read := func(args map[string]any) response {
+		t.Helper()
+		res, err := s.HandleReadNamespace(context.Background(), makeRequest("read_namespace", args))
+		if err != nil || res.IsError {
+			t.Fatalf("handleReadNamespace failed: %v", res)
+		}
+		var resp response
+		if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &resp); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return resp
+	}
+
+	resp := read(map[string]any{"namespace": "ns", "limit": 2, "offset": 1})
+	if resp.Total != 3 || len(resp.Pages) != 2 || resp.Pages[0].Name != "ns/b" || resp.Pages[1].Name != "ns/c" {
+		t.Errorf("Expected sorted second page [ns/b ns/c] of 3, got %+v", resp)
+	}
+
+	resp = read(map[string]any{"namespace": "ns", "offset": 10})
+	if resp.Total != 3 || resp.Pages == nil || len(resp.Pages) != 0 {
+		t.Errorf("Expected empty list with total 3 for offset beyond end, got %+v", resp)
+	}
+
+	res, _ := s.HandleReadNamespace(context.Background(), makeRequest("read_namespace", map[string]any{"namespace": "ns", "offset": -1}))
+	if !res.IsError {
+		t.Error("Expected error for negative offset")
+	}
+}
+
+func TestServer_RenameProperty(t *testing.T) {
+	var upsertKey string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlockProperty":
+			if body.Args[1] == "publishedDate" {
+				w.Write([]byte(`"1937"`))
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.upsertBlockProperty":
+			upsertKey = body.Args[1].(string)
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	res, err := s.HandleRenameProperty(context.Background(), makeRequest("rename_property", map[string]any{"uuid": "u1", "old_key": "publishedDate", "new_key": "publicationDate"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleRenameProperty failed: %v", res)
+	}
+	if upsertKey != "publication_date" {
+		t.Errorf("Expected snake_cased new key, got %q", upsertKey)
+	}
+
+	res, _ = s.HandleRenameProperty(context.Background(), makeRequest("rename_property", map[string]any{"uuid": "u1", "old_key": "missing", "new_key": "x"}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "does not exist") {
+		t.Errorf("Expected not-found error, got %v", res)
+	}
+
+	res, _ = s.HandleRenameProperty(context.Background(), makeRequest("rename_property", map[string]any{"uuid": "u1"}))
+	if !res.IsError {
+		t.Error("Expected error for missing keys")
+	}
+}
+
+func TestServer_RenamePropertyEverywhere(t *testing.T) {
+	removed := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.DB.datascriptQuery":
+			w.Write([]byte(`[[{"uuid": "u1", "name": "a"}], [{"uuid": "u2", "name": "b"}]]`))
+		case "logseq.Editor.getBlockProperty":
+			// u2 already carries the new key, so its rename is refused
+			if body.Args[1] == "old" || (body.Args[0] == "u2" && body.Args[1] == "new") {
+				w.Write([]byte(`"v"`))
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.removeBlockProperty":
+			removed[body.Args[0].(string)] = true
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	res, _ := s.HandleRenamePropertyEverywhere(context.Background(), makeRequest("rename_property_everywhere", map[string]any{"old_key": "old", "new_key": "new"}))
+	if !res.IsError || len(removed) != 0 {
+		t.Fatalf("Expected refusal without confirm, got %v", res)
+	}
+
+	res, err := s.HandleRenamePropertyEverywhere(context.Background(), makeRequest("rename_property_everywhere", map[string]any{"old_key": "old", "new_key": "new", "confirm": true}))
+	if err != nil || !res.IsError {
+		t.Fatalf("Expected partial failure result, got %v", res)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "on 1 pages") || !strings.Contains(text, "b: ") {
+		t.Errorf("Expected 1 renamed page and a failure for b, got %q", text)
+	}
+	if !removed["u1"] || removed["u2"] {
+		t.Errorf("Expected only u1 to lose the old key, got %v", removed)
+	}
+}
+
+func TestServer_DescribeClass(t *testing.T) {
+	extra := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method != "logseq.DB.q" {
+			w.Write([]byte(`null`))
+			return
+		}
+		query := body.Args[0].(string)
+		if strings.Contains(query, ":block/tags") {
+			w.Write([]byte(`[[{"uuid": "u1", "name": "hobbit", "properties": {"author": "x", "publishedDate": "1937"}}], [{"uuid": "u2", "name": "dune", "properties": {"author": "y"}}]]`))
+			return
+		}
+		pages := []string{`[{"uuid": "u2", "name": "dune", "properties": {"author": "y"}}]`}
+		for i := 0; i < extra; i++ {
+			pages = append(pages, fmt.Sprintf(`[{"uuid": "e%d", "name": "book/%03d"}]`, i, i))
+		}
+		w.Write([]byte("[" + strings.Join(pages, ",") + "]"))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	type schema struct {
+		Instances  int            `json:"instances"`
+		Inspected  int            `json:"inspected"`
+		Sampled    bool           `json:"sampled"`
+		Attributes map[string]int `json:"attributes"`
+	}
+	describe := func() schema {
+		t.Helper()
+		res, err := s.HandleDescribeClass(context.Background(), makeRequest("describe_class", map[string]any{"class": "Book"}))
+		if err != nil || res.IsError {
+			t.Fatalf("handleDescribeClass failed: %v", res)
+		}
+		var sc schema
+		json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &sc)
+		return sc
+	}
+
+	sc := describe()
+	if sc.Instances != 2 || sc.Sampled || sc.Attributes["author"] != 2 || sc.Attributes["published_date"] != 1 {
+		t.Errorf("Unexpected schema: %+v", sc)
+	}
+
+	extra = server.DescribeClassSampleSize
+	sc = describe()
+	if !sc.Sampled || sc.Inspected != server.DescribeClassSampleSize || sc.Instances != server.DescribeClassSampleSize+2 {
+		t.Errorf("Expected sampled schema, got %+v", sc)
+	}
+
+	res, _ := s.HandleDescribeClass(context.Background(), makeRequest("describe_class", map[string]any{}))
+	if !res.IsError {
+		t.Error("Expected error for missing class")
+	}
+}
+
+func TestServer_ApplyTemplate_Errors(t *testing.T) {
+	s, _ := setupTestServer()
+	res, err := s.HandleApplyTemplate(context.Background(), makeRequest("apply_template", map[string]any{"template_page": "T"}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing target, got %v", res)
+	}
+	res, err = s.HandleApplyTemplate(context.Background(), makeRequest("apply_template", map[string]any{"template_page": "T", "target_uuid": "u1", "variables": "nope"}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for invalid variables, got %v", res)
+	}
+}
+
+func TestServer_ReadGraphInfo_Unauthorized(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "bad", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	res, err := s.HandleReadGraphInfo(context.Background(), makeRequest("read_graph_info", map[string]any{}))
+	if err != nil || !res.IsError {
+		t.Fatalf("Expected error result, got %v", res)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "LOGSEQ_TOKEN") {
+		t.Errorf("Expected token hint, got %q", text)
+	}
+}
+
+func TestServer_ReadGraphInfo_NoGraphOpen(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	res, err := s.HandleReadGraphInfo(context.Background(), makeRequest("read_graph_info", map[string]any{}))
+	if err != nil || !res.IsError {
+		t.Fatalf("Expected error result, got %v", res)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "No graph is currently open in Logseq.") {
+		t.Errorf("Expected no-graph message, got %q", text)
+	}
+}
+
+func TestServer_BlockTools_RejectNonUUID(t *testing.T) {
+	s, _ := setupTestServer()
+	ctx := context.Background()
+	for name, handle := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"read_block":   s.HandleReadBlock,
+		"update_block": s.HandleUpdateBlock,
+		"delete_block": s.HandleDeleteBlock,
+	} {
+		res, err := handle(ctx, makeRequest(name, map[string]any{"uuid": "My Page", "content": "c"}))
+		if err != nil || !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "doesn't look like a block UUID") {
+			t.Errorf("%s: expected UUID hint, got %v", name, res)
+		}
+	}
+}
+
+func TestServer_MoveBlocks_Errors(t *testing.T) {
+	s, _ := setupTestServer()
+	for _, ops := range []string{"", "not json", `[{"uuid": "a"}]`} {
+		res, err := s.HandleMoveBlocks(context.Background(), makeRequest("move_blocks", map[string]any{"operations": ops}))
+		if err != nil || !res.IsError {
+			t.Errorf("Expected error result for operations %q, got %v", ops, res)
+		}
+	}
+}
+
+func TestServer_ReadBlocks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Args[0] == "missing" {
+			w.Write([]byte(`null`))
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`{"uuid": %q, "content": "content of %s"}`, body.Args[0], body.Args[0])))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	res, err := s.HandleReadBlocks(context.Background(), makeRequest("read_blocks", map[string]any{"uuids": `["b2", "missing", "b1"]`}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleReadBlocks failed: %v", res)
+	}
+	var results []struct {
+		UUID  string        `json:"uuid"`
+		Found bool          `json:"found"`
+		Block *logseq.Block `json:"block"`
+	}
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &results)
+	if len(results) != 3 || results[0].UUID != "b2" || results[0].Block.Content != "content of b2" || results[1].Found || results[1].Block != nil || results[2].UUID != "b1" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	res, _ = s.HandleReadBlocks(context.Background(), makeRequest("read_blocks", map[string]any{"uuids": "nope"}))
+	if !res.IsError {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestServer_ReadPages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Args[0] == "missing" {
+			w.Write([]byte(`null`))
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`{"uuid": "u-%s", "name": %q}`, body.Args[0], body.Args[0])))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	res, err := s.HandleReadPages(context.Background(), makeRequest("read_pages", map[string]any{"names": `["p1", "missing"]`}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleReadPages failed: %v", res)
+	}
+	var results []struct {
+		Name  string       `json:"name"`
+		Found bool         `json:"found"`
+		Page  *logseq.Page `json:"page"`
+	}
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &results)
+	if len(results) != 2 || !results[0].Found || results[0].Page.UUID != "u-p1" || results[1].Found || results[1].Name != "missing" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+}
+
+func TestServer_ToolFilters(t *testing.T) {
+	client := logseq.NewClient("http://localhost:12345", "token", nil)
+	core, logs := observer.New(zap.WarnLevel)
+	s := server.NewMCPServer(client, zap.New(core), server.ModeGeneral,
+		server.WithAllowedTools([]string{"read_page", " delete_page", "query", "no_such_tool"}),
+		server.WithDeniedTools([]string{"delete_page"}),
+	)
+
+	tools := s.GetServer().ListTools()
+	if len(tools) != 2 || tools["read_page"] == nil || tools["query"] == nil {
+		var names []string
+		for name := range tools {
+			names = append(names, name)
+		}
+		t.Errorf("Expected only read_page and query, got %v", names)
+	}
+	if logs.FilterField(zap.String("tool", "no_such_tool")).Len() != 1 {
+		t.Errorf("Expected a warning for the unknown tool, got %v", logs.All())
+	}
+
+	all := server.NewMCPServer(client, zap.NewNop(), server.ModeGeneral, server.WithDeniedTools([]string{"delete_page"}))
+	if tools := all.GetServer().ListTools(); tools["delete_page"] != nil || tools["read_page"] == nil {
+		t.Error("Expected deny list to hide delete_page only")
+	}
+}
+
+func TestServer_ReadOnly(t *testing.T) {
+	client := logseq.NewClient("http://localhost:12345", "token", nil)
+	s := server.NewMCPServer(client, zap.NewNop(), server.ModeGeneral, server.WithReadOnly())
+
+	tools := s.GetServer().ListTools()
+	for _, name := range []string{"read_page", "query", "search_all", "export_graph"} {
+		if tools[name] == nil {
+			t.Errorf("Expected read-only tool %s to be registered", name)
+		}
+	}
+	for _, name := range []string{"replace_everywhere", "replace_in_page", "delete_page", "update_block", "find_broken_refs"} {
+		if tools[name] != nil {
+			t.Errorf("Expected %s to be hidden in read-only mode", name)
+		}
+	}
+}
+
+func TestServer_ServerInfo(t *testing.T) {
+	client := logseq.NewClient("http://127.0.0.1:12315", "secret-token", nil)
+	s := server.NewMCPServer(client, zap.NewNop(), server.ModeOntological)
+
+	res, err := s.HandleServerInfo(context.Background(), makeRequest("server_info", map[string]any{}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleServerInfo failed: %v", res)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	var info map[string]string
+	json.Unmarshal([]byte(text), &info)
+	if info["name"] != "yalms" || info["version"] != server.Version || info["mode"] != "ontological" || info["transport"] != "stdio" || info["logseq_url"] != "http://127.0.0.1:12315" {
+		t.Errorf("Unexpected server info: %v", info)
+	}
+	if strings.Contains(text, "secret-token") {
+		t.Error("Server info must not leak the token")
+	}
+}
+
+func TestServer_CreateEntity_Existing(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.getPage" {
+			w.Write([]byte(`{"uuid": "u1", "name": "person/alice"}`))
+			return
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	res, err := s.HandleCreateEntity(context.Background(), makeRequest("create_entity", map[string]any{"name": "Alice", "namespace": "Person", "properties": `{"age": 30}`}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreateEntity failed: %v", res)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "already existed") || !strings.Contains(text, "merged") {
+		t.Errorf("Expected existing-page message, got %q", text)
+	}
+
+	res, _ = s.HandleCreateEntity(context.Background(), makeRequest("create_entity", map[string]any{"name": "Alice", "namespace": "Person"}))
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "left unchanged") {
+		t.Errorf("Expected unchanged message, got %q", text)
+	}
+}
+
+func TestServer_ClearProperty(t *testing.T) {
+	props := map[string]any{"published_date": "1937"}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.upsertBlockProperty":
+			props[body.Args[1].(string)] = body.Args[2]
+		case "logseq.Editor.removeBlockProperty":
+			delete(props, body.Args[1].(string))
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	res, err := s.HandleClearProperty(context.Background(), makeRequest("clear_property", map[string]any{"uuid": "u1", "key": "publishedDate"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleClearProperty failed: %v", res)
+	}
+	value, ok := props["published_date"]
+	if !ok || value != "" {
+		t.Errorf("Expected key to remain with an empty value, got %v", props)
+	}
+
+	res, _ = s.HandleClearProperty(context.Background(), makeRequest("clear_property", map[string]any{"uuid": "u1"}))
+	if !res.IsError {
+		t.Error("Expected error for missing key")
+	}
+}
+
+func TestServer_BatchTools_StructuredFailures(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Args[0] == "bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		switch body.Method {
+		case "logseq.Editor.createPage":
+			w.Write([]byte(fmt.Sprintf(`{"uuid": "u-%s", "name": %q}`, body.Args[0], body.Args[0])))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	type payload struct {
+		Succeeded json.RawMessage `json:"succeeded"`
+		Failed    []struct {
+			Index      int    `json:"index"`
+			Identifier string `json:"identifier"`
+			Error      string `json:"error"`
+		} `json:"failed"`
+	}
+	parse := func(res *mcp.CallToolResult) payload {
+		t.Helper()
+		if !res.IsError {
+			t.Fatalf("Expected partial failure to be flagged as error, got %v", res)
+		}
+		_, body, _ := strings.Cut(res.Content[0].(mcp.TextContent).Text, "\n")
+		var p payload
+		if err := json.Unmarshal([]byte(body), &p); err != nil {
+			t.Fatalf("Expected structured payload, got %q", body)
+		}
+		return p
+	}
+
+	for name, handle := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"delete_pages":  s.HandleDeletePages,
+		"remove_blocks": s.HandleDeleteBlocks,
+	} {
+		res, _ := handle(context.Background(), makeRequest(name, map[string]any{"uuids": `["a", "bad", "c"]`}))
+		p := parse(res)
+		var succeeded []string
+		json.Unmarshal(p.Succeeded, &succeeded)
+		if strings.Join(succeeded, ",") != "a,c" || len(p.Failed) != 1 || p.Failed[0].Index != 1 || p.Failed[0].Identifier != "bad" || p.Failed[0].Error == "" {
+			t.Errorf("%s: unexpected payload %s / %+v", name, p.Succeeded, p.Failed)
+		}
+	}
+
+	res, _ := s.HandleCreatePages(context.Background(), makeRequest("create_pages", map[string]any{"pages": `[{"name": "bad"}, {"name": "ok"}]`}))
+	p := parse(res)
+	var succeeded []map[string]string
+	json.Unmarshal(p.Succeeded, &succeeded)
+	if len(succeeded) != 1 || succeeded[0]["uuid"] != "u-ok" || len(p.Failed) != 1 || p.Failed[0].Index != 0 || p.Failed[0].Identifier != "bad" {
+		t.Errorf("create_pages: unexpected payload %s / %+v", p.Succeeded, p.Failed)
+	}
+}
+
+func TestServer_RecentPages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[[{"uuid": "u1", "name": "old", "updatedAt": 1700000000000}], [{"uuid": "u2", "name": "new", "originalName": "New", "updatedAt": 1768730400000}], [{"uuid": "u3", "name": "mid", "updatedAt": 1750000000000}]]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	res, err := s.HandleRecentPages(context.Background(), makeRequest("recent_pages", map[string]any{"limit": 2}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleRecentPages failed: %v", res)
+	}
+	var pages []map[string]string
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &pages)
+	if len(pages) != 2 || pages[0]["name"] != "New" || pages[0]["updated_at"] != "2026-01-18T10:00:00Z" || pages[1]["uuid"] != "u3" {
+		t.Errorf("Unexpected recent pages: %v", pages)
+	}
+}
+
+func TestServer_FindBlocksByProperty(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query, _ = body.Args[0].(string)
+		w.Write([]byte(`[[{"uuid": "b1", "content": "Fix login\nticket_status:: blocked", "page": {"id": 7, "uuid": "p1", "name": "sprint"}}]]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	res, err := s.HandleFindBlocksByProperty(context.Background(), makeRequest("find_blocks_by_property", map[string]any{"key": "ticketStatus", "value": "blocked"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleFindBlocksByProperty failed: %v", res)
+	}
+	if !strings.Contains(query, ":ticket_status") || !strings.Contains(query, `"blocked"`) {
+		t.Errorf("Expected snake_cased key and value in query, got %s", query)
+	}
+	var matches []map[string]string
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &matches)
+	if len(matches) != 1 || matches[0]["uuid"] != "b1" || matches[0]["page"] != "sprint" {
+		t.Errorf("Unexpected matches: %v", matches)
+	}
+
+	res, _ = s.HandleFindBlocksByProperty(context.Background(), makeRequest("find_blocks_by_property", map[string]any{"key": "status"}))
+	if !res.IsError {
+		t.Error("Expected error result for missing value")
+	}
+
+	query = ""
+	res, _ = s.HandleFindBlocksByProperty(context.Background(), makeRequest("find_blocks_by_property", map[string]any{"key": "status) [?b :block/uuid", "value": "blocked"}))
+	if !res.IsError || query != "" {
+		t.Errorf("Expected a key that is not a keyword to be rejected before querying, got %v (query: %s)", res, query)
+	}
+}
+
+func TestServer_CollapsePage(t *testing.T) {
+	s, _ := setupTestServer()
+	res, err := s.HandleCollapsePage(context.Background(), makeRequest("collapse_page", map[string]any{"nameOrUUID": "page"}))
+	if err != nil || !res.IsError {
+		t.Errorf("Expected error result for missing collapsed flag, got %v", res)
+	}
+}
+
+func TestServer_CreatePageTree(t *testing.T) {
+	var pageProps, batch any
+	var insertTarget any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`null`))
+		case "logseq.Editor.createPage":
+			pageProps = body.Args[1]
+			w.Write([]byte(`{"uuid": "p1", "name": "book/hobbit"}`))
+		case "logseq.Editor.insertBatchBlock":
+			insertTarget, batch = body.Args[0], body.Args[1]
+			w.Write([]byte(`[{"uuid": "b1", "content": "Root", "children": [{"uuid": "b2", "content": "Child"}]}]`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeOntological)
+
+	res, err := s.HandleCreatePageTree(context.Background(), makeRequest("create_page_tree", map[string]any{
+		"name":       "Hobbit",
+		"namespace":  "Book",
+		"properties": `{"publicationYear": 1937}`,
+		"tree":       `[{"content": "Root", "properties": {"readingStatus": "done"}, "children": [{"content": "Child"}]}]`,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreatePageTree failed: %v", res)
+	}
+	if insertTarget != "p1" {
+		t.Errorf("Expected tree to target the new page UUID, got %v", insertTarget)
+	}
+	if props, _ := pageProps.(map[string]any); props["publication_year"] == nil {
+		t.Errorf("Expected snake_cased page properties, got %v", pageProps)
+	}
+	if blocks, _ := batch.([]any); len(blocks) != 1 || blocks[0].(map[string]any)["properties"].(map[string]any)["reading_status"] != "done" {
+		t.Errorf("Expected snake_cased block properties, got %v", batch)
+	}
+
+	var result struct {
+		PageUUID   string   `json:"page_uuid"`
+		BlockUUIDs []string `json:"block_uuids"`
+	}
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &result)
+	if result.PageUUID != "p1" || strings.Join(result.BlockUUIDs, ",") != "b1,b2" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestServer_CreateEntity_InvalidName(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method != "logseq.Editor.getPage" {
+			t.Errorf("Expected only the existence lookup for an invalid name, got %s", body.Method)
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleCreateEntity(context.Background(), makeRequest("create_entity", map[string]any{"name": "Draft [wip]"}))
+	if err != nil || !res.IsError {
+		t.Fatalf("Expected error result for invalid name, got %v", res)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "'['") {
+		t.Errorf("Expected message naming the offending character, got %q", text)
+	}
+}
+
+func TestServer_PageExists(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Args[0] == "Known" {
+			w.Write([]byte(`{"uuid": "p1", "name": "known"}`))
+			return
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	for name, expected := range map[string]string{"Known": `{"exists":true,"uuid":"p1"}`, "Missing": `{"exists":false}`} {
+		res, err := s.HandlePageExists(context.Background(), makeRequest("page_exists", map[string]any{"nameOrUUID": name}))
+		if err != nil || res.IsError {
+			t.Fatalf("handlePageExists(%s) failed: %v", name, res)
+		}
+		if text := res.Content[0].(mcp.TextContent).Text; text != expected {
+			t.Errorf("handlePageExists(%s) = %s, want %s", name, text, expected)
+		}
+	}
+}
+
+func TestServer_PreviewLinks(t *testing.T) {
+	var created bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.createPage" {
+			created = true
+		}
+		if body.Args[0] == "Known" || body.Args[0] == "A" {
+			w.Write([]byte(`{"uuid": "p1"}`))
+			return
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandlePreviewLinks(context.Background(), makeRequest("preview_links", map[string]any{
+		"content":    "see [[Known]] and [[A/B/C]], again [[Known]]",
+		"properties": `{"author": "[[Typo]]"}`,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handlePreviewLinks failed: %v", res)
+	}
+	var preview logseq.LinkPreview
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &preview); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if strings.Join(preview.Existing, ",") != "Known,A" {
+		t.Errorf("Unexpected existing pages: %v", preview.Existing)
+	}
+	if strings.Join(preview.WouldCreate, ",") != "A/B,A/B/C,Typo" {
+		t.Errorf("Unexpected pages to create: %v", preview.WouldCreate)
+	}
+	if created {
+		t.Error("Did not expect preview_links to create pages")
+	}
+}
+
+func TestServer_ReplaceInPage(t *testing.T) {
+	var updated []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"uuid": "p1", "name": "page"}`))
+		case "logseq.Editor.getPageBlocksTree":
+			w.Write([]byte(`[{"uuid": "b1", "content": "Teh start"}, {"uuid": "b2", "content": "other"}]`))
+		case "logseq.Editor.updateBlock":
+			updated = append(updated, body.Args[1].(string))
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleReplaceInPage(context.Background(), makeRequest("replace_in_page", map[string]any{"nameOrUUID": "page", "find": "teh", "replace": "the"}))
+	if err != nil || res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "in 0 block(s)") {
+		t.Errorf("Expected a case-sensitive replace by default, got %v", res)
+	}
+
+	res, _ = s.HandleReplaceInPage(context.Background(), makeRequest("replace_in_page", map[string]any{"nameOrUUID": "page", "find": "teh", "replace": "the", "case_sensitive": false}))
+	if res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "in 1 block(s)") || len(updated) != 1 || updated[0] != "the start" {
+		t.Errorf("Expected one block to be updated, got %v (%v)", res, updated)
+	}
+
+	res, _ = s.HandleReplaceInPage(context.Background(), makeRequest("replace_in_page", map[string]any{"nameOrUUID": "page"}))
+	if !res.IsError {
+		t.Error("Expected error for missing find text")
+	}
+}
+
+func TestServer_ReplaceEverywhere(t *testing.T) {
+	var updated []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.DB.q":
+			if strings.Contains(body.Args[0].(string), ":block/content ?c") {
+				w.Write([]byte(`[[{"uuid": "b1", "content": "teh one", "page": {"name": "a"}}], [{"uuid": "b2", "content": "Teh two", "page": {"name": "b"}}], [{"uuid": "b3", "content": "[[teh]] link", "page": {"name": "b"}}]]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		case "logseq.Editor.updateBlock":
+			updated = append(updated, body.Args[0].(string)+"="+body.Args[1].(string))
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, _ := s.HandleReplaceEverywhere(context.Background(), makeRequest("replace_everywhere", map[string]any{"find": "teh", "replace": "the"}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "confirm") {
+		t.Errorf("Expected confirmation error, got %v", res)
+	}
+
+	res, err := s.HandleReplaceEverywhere(context.Background(), makeRequest("replace_everywhere", map[string]any{"find": "teh", "replace": "the", "dry_run": true}))
+	if err != nil || res.IsError {
+		t.Fatalf("dry run failed: %v", res)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !strings.HasPrefix(text, "Dry run: 1 block(s) would change.") || !strings.Contains(text, `"replaced": "the one"`) || len(updated) != 0 {
+		t.Errorf("Unexpected dry run result: %s (updated %v)", text, updated)
+	}
+
+	res, _ = s.HandleReplaceEverywhere(context.Background(), makeRequest("replace_everywhere", map[string]any{"find": "teh", "replace": "the", "case_sensitive": false, "confirm": true}))
+	if res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "in 2 block(s)") {
+		t.Errorf("Expected two blocks to be replaced, got %v", res)
+	}
+	if strings.Join(updated, ",") != "b1=the one,b2=the two" {
+		t.Errorf("Unexpected updates: %v", updated)
+	}
+}
+
+func TestServer_FindOrphans(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.App.getUserConfigs" {
+			w.Write([]byte(`{"preferredDateFormat": "MMM do, yyyy"}`))
+			return
+		}
+		if body.Method != "logseq.DB.q" {
+			w.Write([]byte(`null`))
+			return
+		}
+		query := body.Args[0].(string)
+		switch {
+		case strings.Contains(query, "pull ?p"):
+			// "jan 17th, 2026" is a journal name in the graph's date format that lacks the journal flag
+			w.Write([]byte(`[[{"id": 1, "uuid": "p1", "name": "lonely", "originalName": "Lonely"}], [{"id": 2, "uuid": "p2", "name": "cited"}], [{"id": 3, "uuid": "p3", "name": "linker"}], [{"id": 4, "uuid": "p4", "name": "2026-01-18", "journal?": true}], [{"id": 5, "uuid": "p5", "name": "jan 17th, 2026", "originalName": "Jan 17th, 2026"}]]`))
+		case strings.Contains(query, "?b :block/page ?p"):
+			w.Write([]byte(`[[3]]`))
+		default:
+			w.Write([]byte(`[[2]]`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleFindOrphans(context.Background(), makeRequest("find_orphans", map[string]any{}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleFindOrphans failed: %v", res)
+	}
+	var names []string
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &names)
+	if strings.Join(names, ",") != "Lonely" {
+		t.Errorf("Expected only Lonely, got %v", names)
+	}
+
+	res, _ = s.HandleFindOrphans(context.Background(), makeRequest("find_orphans", map[string]any{"include_journals": true}))
+	names = nil
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &names)
+	if strings.Join(names, ",") != "2026-01-18,Jan 17th, 2026,Lonely" {
+		t.Errorf("Expected the journal page to be included, got %v", names)
+	}
+}
+
+func TestServer_InsertBlockRef(t *testing.T) {
+	const refUUID = "7a2b3c4d-5e6f-4a1b-8c9d-0e1f2a3b4c5d"
+	var inserted []any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			if body.Args[0] == refUUID {
+				w.Write([]byte(`{"uuid": "` + refUUID + `", "content": "target"}`))
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.insertBlock":
+			inserted = body.Args
+			w.Write([]byte(`{"uuid": "new-block"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleInsertBlockRef(context.Background(), makeRequest("insert_block_ref", map[string]any{"parent_uuid": testBlockUUID, "ref_uuid": refUUID}))
+	if err != nil || res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "new-block") {
+		t.Fatalf("handleInsertBlockRef failed: %v", res)
+	}
+	if len(inserted) < 2 || inserted[0] != testBlockUUID || inserted[1] != "(("+refUUID+"))" {
+		t.Errorf("Unexpected insertBlock args: %v", inserted)
+	}
+
+	inserted = nil
+	res, _ = s.HandleInsertBlockRef(context.Background(), makeRequest("insert_block_ref", map[string]any{"parent_uuid": testBlockUUID, "ref_uuid": "00000000-0000-0000-0000-000000000000"}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "does not exist") || inserted != nil {
+		t.Errorf("Expected a clear error for a missing ref target, got %v", res)
+	}
+}
+
+func TestServer_InsertEmbed(t *testing.T) {
+	const targetUUID = "7a2b3c4d-5e6f-4a1b-8c9d-0e1f2a3b4c5d"
+	var inserted []any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			if body.Args[0] == targetUUID {
+				w.Write([]byte(`{"uuid": "` + targetUUID + `", "content": "target"}`))
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.getPage":
+			if body.Args[0] == "projects/alpha" {
+				w.Write([]byte(`{"uuid": "page-uuid", "name": "projects/alpha", "originalName": "Projects/Alpha"}`))
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.insertBlock":
+			inserted = body.Args
+			w.Write([]byte(`{"uuid": "new-block"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleInsertEmbed(context.Background(), makeRequest("insert_embed", map[string]any{"parent_uuid": testBlockUUID, "target": targetUUID, "type": "block"}))
+	if err != nil || res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "new-block") {
+		t.Fatalf("handleInsertEmbed (block) failed: %v", res)
+	}
+	if len(inserted) < 2 || inserted[1] != "{{embed (("+targetUUID+"))}}" {
+		t.Errorf("Unexpected block embed args: %v", inserted)
+	}
+
+	inserted = nil
+	res, err = s.HandleInsertEmbed(context.Background(), makeRequest("insert_embed", map[string]any{"parent_uuid": testBlockUUID, "target": "projects/alpha", "type": "page"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleInsertEmbed (page) failed: %v", res)
+	}
+	if len(inserted) < 2 || inserted[1] != "{{embed [[Projects/Alpha]]}}" {
+		t.Errorf("Unexpected page embed args: %v", inserted)
+	}
+
+	inserted = nil
+	res, _ = s.HandleInsertEmbed(context.Background(), makeRequest("insert_embed", map[string]any{"parent_uuid": testBlockUUID, "target": "Missing", "type": "page"}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "does not exist") || inserted != nil {
+		t.Errorf("Expected a clear error for a missing embed target, got %v", res)
+	}
+}
+
+func TestServer_Count(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[[12]]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleCount(context.Background(), makeRequest("count", map[string]any{"tag": "Book"}))
+	if err != nil || res.IsError || res.Content[0].(mcp.TextContent).Text != "12" {
+		t.Errorf("Expected a count of 12, got %v", res)
+	}
+
+	res, _ = s.HandleCount(context.Background(), makeRequest("count", map[string]any{"tag": "Book", "property": "status"}))
+	if !res.IsError {
+		t.Error("Expected error result for more than one filter")
+	}
+}
+
+func TestServer_CreateBlock_PageNameParent(t *testing.T) {
+	var insertParent any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			if body.Args[0] == "Reading List" {
+				w.Write([]byte(`{"uuid": "` + testBlockUUID + `", "name": "reading list"}`))
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.insertBlock":
+			insertParent = body.Args[0]
+			w.Write([]byte(`{"uuid": "b1", "content": "The Hobbit"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleCreateBlock(context.Background(), makeRequest("create_block", map[string]any{"parent_uuid": "Reading List", "content": "The Hobbit"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreateBlock failed: %v", res)
+	}
+	if insertParent != testBlockUUID {
+		t.Errorf("Expected the page name to resolve to the page UUID, got %v", insertParent)
+	}
+
+	res, _ = s.HandleCreateBlock(context.Background(), makeRequest("create_block", map[string]any{"parent_uuid": "Missing Page", "content": "x"}))
+	if !res.IsError {
+		t.Error("Expected error result for an unknown page name")
+	}
+}
+
+type notificationSession struct {
+	ch chan mcp.JSONRPCNotification
+}
+
+func (n *notificationSession) Initialize()       {}
+func (n *notificationSession) Initialized() bool { return true }
+func (n *notificationSession) SessionID() string { return "watch-test" }
+func (n *notificationSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return n.ch
+}
+
+func TestServer_Watch(t *testing.T) {
+	var polls atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// Unchanged for the baseline and first poll, then a newer edit
+		if polls.Add(1) <= 2 {
+			w.Write([]byte(`[[1768730400000]]`))
+			return
+		}
+		w.Write([]byte(`[[1768734000000]]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral, server.WithWatchInterval(10*time.Millisecond))
+
+	session := &notificationSession{ch: make(chan mcp.JSONRPCNotification, 10)}
+	if err := s.GetServer().RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("RegisterSession failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Watch(ctx)
+		close(done)
+	}()
+
+	select {
+	case n := <-session.ch:
+		if n.Method != mcp.MethodNotificationResourceUpdated || n.Params.AdditionalFields["uri"] != server.GraphResourceURI {
+			t.Errorf("Unexpected notification: %+v", n)
+		}
+		if n.Params.AdditionalFields["updated_at"] != int64(1768734000000) {
+			t.Errorf("Expected the new update time, got %v", n.Params.AdditionalFields["updated_at"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a notification after the graph changed")
+	}
+
+	cancel()
+	<-done
+	select {
+	case n := <-session.ch:
+		t.Errorf("Expected exactly one notification, got another: %+v", n)
+	default:
+	}
+}
+
+func TestServer_Watch_Disabled(t *testing.T) {
+	s, _ := setupTestServer()
+	done := make(chan struct{})
+	go func() {
+		s.Watch(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Watch to return immediately without a watch interval")
+	}
+}
+
+func TestServer_ImportGraph(t *testing.T) {
+	var mu sync.Mutex
+	var created []string
+	inserted := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			switch body.Args[0] {
+			case "Existing":
+				w.Write([]byte(`{"uuid": "old-uuid", "name": "existing"}`))
+			case "New Page":
+				if len(created) > 0 {
+					w.Write([]byte(`{"uuid": "new-uuid", "name": "new page"}`))
+					return
+				}
+				w.Write([]byte(`null`))
+			default:
+				w.Write([]byte(`null`))
+			}
+		case "logseq.Editor.createPage":
+			created = append(created, body.Args[0].(string))
+			w.Write([]byte(`{"uuid": "new-uuid", "name": "new page"}`))
+		case "logseq.Editor.insertBatchBlock":
+			batch, _ := json.Marshal(body.Args[1])
+			inserted[body.Args[0].(string)] = string(batch)
+			w.Write([]byte(`[{"uuid": "x"}]`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral, server.WithBatchConcurrency(1))
+
+	data := `Exported 2 of 2 pages (300 bytes).
+{"total": 2, "pages": [
+	{"name": "New Page", "uuid": "p1", "blocks": [{"uuid": "b1", "content": "Target\nid:: b1"}, {"uuid": "b2", "content": "See ((b1))", "children": [{"uuid": "b3", "content": "Child"}]}]},
+	{"name": "Existing", "uuid": "p2", "blocks": [{"uuid": "b4", "content": "Appended"}]}
+]}`
+
+	res, err := s.HandleImportGraph(context.Background(), makeRequest("import_graph", map[string]any{"data": data, "skip_existing": true}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleImportGraph failed: %v", res)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "1 created, 0 merged, 1 skipped") || !strings.Contains(text, "1 block reference(s)") {
+		t.Errorf("Unexpected summary: %s", text)
+	}
+	if strings.Join(created, ",") != "New Page" {
+		t.Errorf("Expected only the new page to be created, got %v", created)
+	}
+	if batch := inserted["new-uuid"]; strings.Contains(batch, "id::") || !strings.Contains(batch, `"Child"`) {
+		t.Errorf("Expected the block tree without id:: lines, got %s", batch)
+	}
+	if _, ok := inserted["old-uuid"]; ok {
+		t.Error("Did not expect blocks to be added to a skipped page")
+	}
+
+	res, _ = s.HandleImportGraph(context.Background(), makeRequest("import_graph", map[string]any{"data": data}))
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "0 created, 2 merged") || inserted["old-uuid"] == "" {
+		t.Errorf("Expected the existing page to be merged, got %s", text)
+	}
+
+	res, _ = s.HandleImportGraph(context.Background(), makeRequest("import_graph", map[string]any{"data": "not json"}))
+	if !res.IsError {
+		t.Error("Expected error result for invalid data")
+	}
+}
+
+func TestServer_ValidationMessages(t *testing.T) {
+	ts, s := setupSuccessMock()
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		handler func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error)
+		args    map[string]any
+		want    string
+	}{
+		{"missing string", s.HandleSearchAll, map[string]any{"term": "  "}, "'term' argument is required"},
+		{"missing second string", s.HandleRenameProperty, map[string]any{"uuid": testBlockUUID, "old_key": "a"}, "'new_key' argument is required"},
+		{"missing json", s.HandleReadPages, map[string]any{}, "'names' argument is required"},
+		{"invalid json", s.HandleReadPages, map[string]any{"names": "[oops"}, "'names' argument is not valid JSON"},
+		{"invalid optional json", s.HandleCreatePageTree, map[string]any{"name": "P", "tree": "[]", "properties": "{"}, "'properties' argument is not valid JSON"},
+		{"wrong type", s.HandleSearchAll, map[string]any{"term": 42}, "Invalid arguments provided: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.handler(context.Background(), makeRequest("", tt.args))
+			if err != nil || !res.IsError {
+				t.Fatalf("Expected a tool error, got %v (err %v)", res, err)
+			}
+			if msg := res.Content[0].(mcp.TextContent).Text; !strings.Contains(msg, tt.want) {
+				t.Errorf("Expected message containing %q, got %q", tt.want, msg)
+			}
+		})
+	}
+}
+
+func TestServer_RawAPI(t *testing.T) {
+	var calls []string
+	var gotArgs []any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		calls = append(calls, body.Method)
+		gotArgs = body.Args
+		w.Write([]byte(`[{"uuid": "ref-1"}]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+
+	if tools := server.NewMCPServer(client, logger, server.ModeGeneral).GetServer().ListTools(); tools["raw_api"] != nil {
+		t.Error("Expected raw_api to be hidden unless enabled")
+	}
+
+	s := server.NewMCPServer(client, logger, server.ModeGeneral, server.WithRawAPI())
+	res, err := s.HandleRawAPI(context.Background(), makeRequest("raw_api", map[string]any{"method": "logseq.Editor.getPageLinkedReferences", "args": `["Foo"]`}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleRawAPI failed: %v", res)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; text != `[{"uuid": "ref-1"}]` {
+		t.Errorf("Expected the raw response, got %q", text)
+	}
+	if len(gotArgs) != 1 || gotArgs[0] != "Foo" {
+		t.Errorf("Unexpected call args: %v", gotArgs)
+	}
+
+	ro := server.NewMCPServer(client, logger, server.ModeGeneral, server.WithRawAPI(), server.WithReadOnly())
+	if ro.GetServer().ListTools()["raw_api"] == nil {
+		t.Fatal("Expected raw_api to stay available in read-only mode")
+	}
+	calls = nil
+	res, _ = ro.HandleRawAPI(context.Background(), makeRequest("raw_api", map[string]any{"method": "logseq.Editor.removeBlock", "args": `["x"]`}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "--read-only") || len(calls) != 0 {
+		t.Errorf("Expected a mutating method to be refused in read-only mode, got %v (calls %v)", res, calls)
+	}
+	for _, method := range []string{"logseq.App.pushState", "logseq.App.execGitCommand", "logseq.UI.showMsg", "logseq.Editor.openInRightSidebar"} {
+		res, _ = ro.HandleRawAPI(context.Background(), makeRequest("raw_api", map[string]any{"method": method, "args": `["x"]`}))
+		if !res.IsError || len(calls) != 0 {
+			t.Errorf("Expected %s to be refused in read-only mode, got %v (calls %v)", method, res, calls)
+		}
+	}
+	res, _ = ro.HandleRawAPI(context.Background(), makeRequest("raw_api", map[string]any{"method": "logseq.DB.q", "args": `["[:find ?p :where [?p :block/name]]"]`}))
+	if res.IsError || len(calls) != 1 {
+		t.Errorf("Expected logseq.DB.q to pass in read-only mode, got %v", res)
+	}
+	calls = nil
+	res, _ = ro.HandleRawAPI(context.Background(), makeRequest("raw_api", map[string]any{"method": "logseq.Editor.getBlock", "args": `["x"]`}))
+	if res.IsError || len(calls) != 1 {
+		t.Errorf("Expected a read method to pass in read-only mode, got %v", res)
+	}
+}
+
+func TestServer_GetCurrent(t *testing.T) {
+	focused := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if !focused {
+			w.Write([]byte(`null`))
+			return
+		}
+		switch body.Method {
+		case "logseq.Editor.getCurrentBlock":
+			w.Write([]byte(`{"uuid": "` + testBlockUUID + `", "content": "editing"}`))
+		case "logseq.Editor.getCurrentPage":
+			w.Write([]byte(`{"uuid": "page-uuid", "name": "current"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleGetCurrentBlock(context.Background(), makeRequest("get_current_block", nil))
+	if err != nil || res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "editing") {
+		t.Fatalf("handleGetCurrentBlock failed: %v", res)
+	}
+	res, err = s.HandleGetCurrentPage(context.Background(), makeRequest("get_current_page", nil))
+	if err != nil || res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "current") {
+		t.Fatalf("handleGetCurrentPage failed: %v", res)
+	}
+
+	focused = false
+	res, _ = s.HandleGetCurrentBlock(context.Background(), makeRequest("get_current_block", nil))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "No block is being edited") {
+		t.Errorf("Expected a clear message when nothing is focused, got %v", res)
+	}
+	res, _ = s.HandleGetCurrentPage(context.Background(), makeRequest("get_current_page", nil))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "No page is open") {
+		t.Errorf("Expected a clear message when no page is open, got %v", res)
+	}
+}
+
+func TestServer_Navigation(t *testing.T) {
+	type call struct {
+		Method string
+		Args   []any
+	}
+	var calls []call
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body call
+		json.NewDecoder(r.Body).Decode(&body)
+		calls = append(calls, body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"id": 7, "uuid": "page-uuid", "name": "projects", "originalName": "Projects"}`))
+		case "logseq.Editor.getBlock":
+			w.Write([]byte(`{"uuid": "` + testBlockUUID + `", "page": {"id": 7}}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger)
+	s := server.NewMCPServer(client, logger, server.ModeGeneral)
+
+	res, err := s.HandleOpenPage(context.Background(), makeRequest("open_page", map[string]any{"nameOrUUID": "Projects"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleOpenPage failed: %v", res)
+	}
+	last := calls[len(calls)-1]
+	if last.Method != "logseq.App.pushState" || len(last.Args) != 2 || last.Args[0] != "page" || last.Args[1].(map[string]any)["name"] != "projects" {
+		t.Errorf("Unexpected navigation call: %+v", last)
+	}
+
+	res, err = s.HandleScrollToBlock(context.Background(), makeRequest("scroll_to_block", map[string]any{"uuid": testBlockUUID}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleScrollToBlock failed: %v", res)
+	}
+	last = calls[len(calls)-1]
+	if last.Method != "logseq.Editor.scrollToBlockInPage" || len(last.Args) != 2 || last.Args[0] != "projects" || last.Args[1] != testBlockUUID {
+		t.Errorf("Unexpected scroll call: %+v", last)
+	}
+
+	ro := server.NewMCPServer(client, logger, server.ModeGeneral, server.WithReadOnly())
+	calls = nil
+	res, _ = ro.HandleOpenPage(context.Background(), makeRequest("open_page", map[string]any{"nameOrUUID": "Projects"}))
+	if res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "--read-only") || len(calls) != 0 {
+		t.Errorf("Expected open_page to be a no-op in read-only mode, got %v (calls %v)", res, calls)
+	}
+	res, _ = ro.HandleScrollToBlock(context.Background(), makeRequest("scroll_to_block", map[string]any{"uuid": testBlockUUID}))
+	if res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "--read-only") || len(calls) != 0 {
+		t.Errorf("Expected scroll_to_block to be a no-op in read-only mode, got %v (calls %v)", res, calls)
+	}
+}
+
+func TestServer_ToggleTask(t *testing.T) {
+	var content, updated string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			b, _ := json.Marshal(map[string]any{"uuid": testBlockUUID, "content": content})
+			w.Write(b)
+		case "logseq.Editor.updateBlock":
+			updated = body.Args[1].(string)
+			w.Write([]byte(`{"uuid": "` + testBlockUUID + `"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"TODO write report\npriority:: high", "DONE write report\npriority:: high"},
+		{"DOING write report", "DONE write report"},
+		{"DONE write report", "TODO write report"},
+		{"NOW call Bob", "DONE call Bob"},
+		{"LATER read book", "DONE read book"},
+	}
+	for _, tt := range tests {
+		content, updated = tt.content, ""
+		res, err := s.HandleToggleTask(context.Background(), makeRequest("toggle_task", map[string]any{"uuid": testBlockUUID}))
+		if err != nil || res.IsError {
+			t.Fatalf("handleToggleTask(%q) failed: %v", tt.content, res)
+		}
+		if updated != tt.expected {
+			t.Errorf("Toggling %q: expected %q, got %q", tt.content, tt.expected, updated)
+		}
+	}
+
+	content, updated = "just a note", ""
+	res, _ := s.HandleToggleTask(context.Background(), makeRequest("toggle_task", map[string]any{"uuid": testBlockUUID}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "no task marker") || updated != "" {
+		t.Errorf("Expected a clear error for a block without marker, got %v", res)
+	}
+}
+
+func TestServer_SetSchedule(t *testing.T) {
+	content := "TODO write report\nDEADLINE: <2026-01-01 Thu>"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			b, _ := json.Marshal(map[string]any{"uuid": testBlockUUID, "content": content})
+			w.Write(b)
+		case "logseq.Editor.updateBlock":
+			content = body.Args[1].(string)
+			w.Write([]byte(`{"uuid": "` + testBlockUUID + `"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleSetSchedule(context.Background(), makeRequest("set_schedule", map[string]any{"uuid": testBlockUUID, "date": "2026-01-18", "time": "10:00", "type": "scheduled"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleSetSchedule (scheduled) failed: %v", res)
+	}
+	res, err = s.HandleSetSchedule(context.Background(), makeRequest("set_schedule", map[string]any{"uuid": testBlockUUID, "date": "2026-01-20", "type": "deadline"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleSetSchedule (deadline) failed: %v", res)
+	}
+	expected := "TODO write report\nSCHEDULED: <2026-01-18 Sun 10:00>\nDEADLINE: <2026-01-20 Tue>"
+	if content != expected {
+		t.Errorf("Expected %q, got %q", expected, content)
+	}
+
+	for _, args := range []map[string]any{
+		{"uuid": testBlockUUID, "date": "18.01.2026", "type": "scheduled"},
+		{"uuid": testBlockUUID, "date": "2026-01-18", "time": "25:00", "type": "scheduled"},
+		{"uuid": testBlockUUID, "date": "2026-01-18", "type": "someday"},
+	} {
+		res, _ := s.HandleSetSchedule(context.Background(), makeRequest("set_schedule", args))
+		if !res.IsError {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestServer_GetSiblingPages(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			if strings.EqualFold(body.Args[0].(string), "Top") {
+				w.Write([]byte(`{"uuid": "t-uuid", "name": "top", "originalName": "Top"}`))
+				return
+			}
+			w.Write([]byte(`{"uuid": "c-uuid", "name": "a/b/c", "originalName": "A/B/C"}`))
+		case "logseq.DB.q":
+			query = body.Args[0].(string)
+			w.Write([]byte(`[[{"uuid": "c-uuid", "name": "a/b/c"}], [{"uuid": "d-uuid", "name": "a/b/d"}]]`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleGetSiblingPages(context.Background(), makeRequest("get_sibling_pages", map[string]any{"nameOrUUID": "A/B/C"}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleGetSiblingPages failed: %v", res)
+	}
+	if !strings.Contains(query, `"a/b"`) {
+		t.Errorf("Expected the parent namespace to be queried, got %s", query)
+	}
+	var pages []logseq.Page
+	json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &pages)
+	if len(pages) != 1 || pages[0].UUID != "d-uuid" {
+		t.Errorf("Expected only the sibling a/b/d, got %+v", pages)
+	}
+
+	res, _ = s.HandleGetSiblingPages(context.Background(), makeRequest("get_sibling_pages", map[string]any{"nameOrUUID": "Top"}))
+	if text := res.Content[0].(mcp.TextContent).Text; res.IsError || !strings.Contains(text, "top-level") || !strings.HasSuffix(text, "[]") {
+		t.Errorf("Expected an empty list with a note for a top-level page, got %v", res)
+	}
+}
+
+func TestServer_TagNamespace(t *testing.T) {
+	var mu sync.Mutex
+	updated := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.DB.q":
+			w.Write([]byte(`[[{"uuid": "ada-uuid", "name": "people/ada", "originalName": "people/Ada"}], [{"uuid": "bob-uuid", "name": "people/bob", "originalName": "people/Bob"}]]`))
+		case "logseq.Editor.getBlock":
+			if body.Args[0] == "bob-uuid" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintf(w, `{"uuid": %q, "content": "type:: person"}`, body.Args[0])
+		case "logseq.Editor.updateBlock":
+			mu.Lock()
+			updated[body.Args[0].(string)] = body.Args[1].(string)
+			mu.Unlock()
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleTagNamespace(context.Background(), makeRequest("tag_namespace", map[string]any{"namespace": "people", "tag": "Person"}))
+	if err != nil || !res.IsError {
+		t.Fatalf("Expected a partial failure, got %v", res)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "Tagged 1 pages") || !strings.Contains(text, "people/Bob") {
+		t.Errorf("Unexpected result: %s", text)
+	}
+	if updated["ada-uuid"] != "type:: person #Person" {
+		t.Errorf("Expected people/Ada to be tagged, got %+v", updated)
+	}
+
+	ro := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral, server.WithReadOnly())
+	if ro.GetServer().ListTools()["tag_namespace"] != nil {
+		t.Errorf("Expected tag_namespace to be hidden in read-only mode")
+	}
+}
+
+func TestServer_CreateBlock_CustomUUID(t *testing.T) {
+	const customUUID = "6571c5b8-aaaa-4c3d-9e8f-0a1b2c3d4e5f"
+	var options map[string]any
+	inUse := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			if inUse {
+				fmt.Fprintf(w, `{"uuid": %q, "content": "taken"}`, body.Args[0])
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.insertBlock":
+			options, _ = body.Args[2].(map[string]any)
+			fmt.Fprintf(w, `{"uuid": %q, "content": %q}`, customUUID, body.Args[1])
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleCreateBlock(context.Background(), makeRequest("create_block", map[string]any{
+		"parent_uuid": testBlockUUID, "content": "Imported", "custom_uuid": customUUID,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreateBlock failed: %v", res)
+	}
+	if options["customUUID"] != customUUID {
+		t.Errorf("Expected customUUID to be forwarded, got %+v", options)
+	}
+
+	res, _ = s.HandleCreateBlock(context.Background(), makeRequest("create_block", map[string]any{
+		"parent_uuid": testBlockUUID, "content": "Imported", "custom_uuid": "not-a-uuid",
+	}))
+	if !res.IsError {
+		t.Errorf("Expected a malformed custom_uuid to be rejected")
+	}
+
+	inUse = true
+	res, _ = s.HandleCreateBlock(context.Background(), makeRequest("create_block", map[string]any{
+		"parent_uuid": testBlockUUID, "content": "Imported", "custom_uuid": customUUID,
+	}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "already in use") {
+		t.Errorf("Expected a used custom_uuid to be rejected, got %v", res)
+	}
+}
+
+func TestServer_CreatePage_Options(t *testing.T) {
+	const customUUID = "6571c5b8-bbbb-4c3d-9e8f-0a1b2c3d4e5f"
+	const takenUUID = "6571c5b8-cccc-4c3d-9e8f-0a1b2c3d4e5f"
+	var mu sync.Mutex
+	var options []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			if body.Args[0] == takenUUID {
+				fmt.Fprintf(w, `{"uuid": %q, "name": "taken", "originalName": "Taken"}`, takenUUID)
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.createPage":
+			opts, _ := body.Args[2].(map[string]any)
+			mu.Lock()
+			options = append(options, opts)
+			mu.Unlock()
+			uuid, _ := opts["customUUID"].(string)
+			if uuid == "" {
+				uuid = testBlockUUID
+			}
+			fmt.Fprintf(w, `{"uuid": %q, "name": %q}`, uuid, strings.ToLower(body.Args[0].(string)))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleCreateEntity(context.Background(), makeRequest("create_entity", map[string]any{
+		"name": "Imported", "custom_uuid": customUUID, "create_first_block": false,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreateEntity failed: %v", res)
+	}
+	if len(options) != 1 || options[0]["customUUID"] != customUUID || options[0]["createFirstBlock"] != false {
+		t.Errorf("Expected customUUID and createFirstBlock to be forwarded, got %+v", options)
+	}
+
+	res, _ = s.HandleCreateEntity(context.Background(), makeRequest("create_entity", map[string]any{
+		"name": "Other", "custom_uuid": takenUUID,
+	}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "already in use") {
+		t.Errorf("Expected a used custom_uuid to be rejected, got %v", res)
+	}
+
+	for _, uuid := range []string{takenUUID, "not-a-uuid"} {
+		res, _ = s.HandleCreateEntity(context.Background(), makeRequest("create_entity", map[string]any{
+			"name": "Other", "options": fmt.Sprintf(`{"customUUID": %q}`, uuid),
+		}))
+		if !res.IsError {
+			t.Errorf("Expected customUUID %q in options to be rejected, got %v", uuid, res)
+		}
+	}
+
+	options = nil
+	res, _ = s.HandleCreatePages(context.Background(), makeRequest("create_pages", map[string]any{
+		"pages": fmt.Sprintf(`[{"name": "A", "create_first_block": true}, {"name": "B", "custom_uuid": %q}]`, takenUUID),
+	}))
+	if !res.IsError || len(options) != 1 || options[0]["createFirstBlock"] != true {
+		t.Errorf("Expected only page A to be created with createFirstBlock, got %v / %+v", res, options)
+	}
+}
+
+func TestServer_CreatePage_RawOptions(t *testing.T) {
+	var mu sync.Mutex
+	options := map[string]map[string]any{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.createPage":
+			name := body.Args[0].(string)
+			mu.Lock()
+			if len(body.Args) > 2 {
+				options[name], _ = body.Args[2].(map[string]any)
+			} else {
+				options[name] = nil
+			}
+			mu.Unlock()
+			fmt.Fprintf(w, `{"uuid": %q, "name": %q}`, testBlockUUID, strings.ToLower(name))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral)
+
+	res, err := s.HandleCreateEntity(context.Background(), makeRequest("create_entity", map[string]any{
+		"name": "Entity", "options": `{"journal": true, "createFirstBlock": true}`, "create_first_block": false,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreateEntity failed: %v", res)
+	}
+	if opts := options["Entity"]; opts["journal"] != true || opts["createFirstBlock"] != false {
+		t.Errorf("Expected options to be forwarded with create_first_block taking precedence, got %+v", opts)
+	}
+
+	res, err = s.HandleCreatePages(context.Background(), makeRequest("create_pages", map[string]any{
+		"pages": `[{"name": "A"}, {"name": "B"}]`, "options": `{"createFirstBlock": false}`,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreatePages failed: %v", res)
+	}
+	for _, name := range []string{"A", "B"} {
+		if options[name]["createFirstBlock"] != false {
+			t.Errorf("Expected options to reach the createPage call for %s, got %+v", name, options[name])
+		}
+	}
+
+	res, err = s.HandleCreateNamespace(context.Background(), makeRequest("create_namespace", map[string]any{
+		"namespace": "work/project", "options": `{"createFirstBlock": false}`,
+	}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleCreateNamespace failed: %v", res)
+	}
+	if options["work/project"]["createFirstBlock"] != false {
+		t.Errorf("Expected options to reach the createPage call, got %+v", options["work/project"])
+	}
+
+	res, _ = s.HandleCreateNamespace(context.Background(), makeRequest("create_namespace", map[string]any{
+		"namespace": "work/other", "options": `[1, 2]`,
+	}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "'options' argument is not valid JSON") {
+		t.Errorf("Expected malformed options to be rejected, got %v", res)
+	}
+}
+
+func TestServer_Query_TimeoutOverride(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[[{"uuid": "p1", "name": "slow"}]]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	client := logseq.NewClient(ts.URL, "token", logger, logseq.WithTimeout(50*time.Millisecond))
+	req := makeRequest("query", map[string]any{"query": "[:find (pull ?p [*]) :where [?p :block/name]]", "timeout_seconds": 5})
+
+	s := server.NewMCPServer(client, logger, server.ModeGeneral, server.WithMaxQueryTimeout(time.Second))
+	res, err := s.HandleQuery(context.Background(), req)
+	if err != nil || res.IsError {
+		t.Errorf("Expected the override to give the slow query enough time, got %v", res)
+	}
+
+	res, _ = s.HandleQuery(context.Background(), makeRequest("query", map[string]any{"query": "[:find (pull ?p [*]) :where [?p :block/name]]", "timeout_seconds": 0}))
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "timed out") {
+		t.Errorf("Expected timeout_seconds <= 0 to keep the regular timeout, got %v", res)
+	}
+
+	capped := server.NewMCPServer(client, logger, server.ModeGeneral, server.WithMaxQueryTimeout(100*time.Millisecond))
+	res, _ = capped.HandleQuery(context.Background(), req)
+	if !res.IsError || !strings.Contains(res.Content[0].(mcp.TextContent).Text, "100ms") {
+		t.Errorf("Expected timeout_seconds to be capped at the server maximum, got %v", res)
+	}
+}
+
+func TestServer_ValidateGraph(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+	logger := zap.NewNop()
+	s := server.NewMCPServer(logseq.NewClient(ts.URL, "token", logger), logger, server.ModeGeneral, server.WithReadOnly())
+
+	if s.GetServer().ListTools()["validate_graph"] == nil {
+		t.Errorf("Expected validate_graph to be available in read-only mode")
+	}
+
+	res, err := s.HandleValidateGraph(context.Background(), makeRequest("validate_graph", map[string]any{}))
+	if err != nil || res.IsError {
+		t.Fatalf("handleValidateGraph failed: %v", res)
+	}
+	var report logseq.GraphValidationReport
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &report); err != nil || !report.Valid {
+		t.Errorf("Expected a valid report for an empty graph, got %v", res)
+	}
+
+	res, _ = s.HandleValidateGraph(context.Background(), makeRequest("validate_graph", map[string]any{"sample_limit": -1}))
+	if !res.IsError {
+		t.Errorf("Expected a negative sample_limit to be rejected")
+	}
+}
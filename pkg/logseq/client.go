@@ -1,10 +1,25 @@
 package logseq
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-resty/resty/v2"
 	"go.uber.org/zap"
@@ -15,21 +30,232 @@ type Client struct {
 	logger *zap.Logger
 	token  string
 	apiURL string
+
+	pageCacheOn  bool // Set once by WithPageCache; pageCache itself is only touched under pageCacheMu
+	pageCache    map[string]pageCacheEntry
+	pageCacheTTL time.Duration
+	pageCacheMu  sync.Mutex
+
+	location *time.Location // Timezone used to determine "today" for journal lookups
+	clock    Clock
+
+	concurrency int // Maximum parallel API calls made by multi-item methods like GetPages
+
+	maxResponseBytes int // 0 means unlimited
+
+	idempotentAppend bool // Skip appends identical to the page's last block (e.g. a retry after a lost response)
+
+	noAutoCreateLinks bool // Leave missing [[linked]] pages uncreated instead of creating them
+
+	noAliasResolution bool // Do not fall back to alias:: lookups when ResolvePage finds no page
+
+	timeout      time.Duration // Deadline of a single API call
+	heavyTimeout time.Duration // Deadline of graph-wide calls (full page lists, graph scans)
+
+	defaultFormat string // Format of new pages and of blocks whose format Logseq does not report
+}
+
+// Clock provides the current time. It exists so date-dependent behavior can be tested.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+type pageCacheEntry struct {
+	page    *Page
+	expires time.Time
+}
+
+// ClientOption configures optional Client behavior
+type ClientOption func(*Client)
+
+// WithUserAgent sets the User-Agent header sent with every API call
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.client.SetHeader("User-Agent", userAgent)
+	}
+}
+
+// WithPageCache enables a short-lived cache for GetPage lookups. Entries expire
+// after ttl and the whole cache is dropped on any page or property mutation.
+func WithPageCache(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.pageCacheOn = true
+		c.pageCacheTTL = ttl
+		c.pageCache = make(map[string]pageCacheEntry)
+	}
+}
+
+// WithLocation sets the timezone used to determine the current journal day. Defaults to the system local zone.
+func WithLocation(loc *time.Location) ClientOption {
+	return func(c *Client) {
+		if loc != nil {
+			c.location = loc
+		}
+	}
+}
+
+// WithClock replaces the real clock, e.g. with a fixed time in tests
+func WithClock(clock Clock) ClientOption {
+	return func(c *Client) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithConcurrency sets how many API calls multi-item methods (GetPages, GetBlocks) make in parallel
+func WithConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		if n < 1 {
+			n = 1
+		}
+		c.concurrency = n
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for HTTPS connections to the Logseq API
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *Client) {
+		if cfg != nil {
+			c.client.SetTLSClientConfig(cfg)
+		}
+	}
+}
+
+// NewTLSConfig builds a TLS configuration that trusts the PEM certificates in caFile in addition
+// to the system roots. insecureSkipVerify disables certificate verification entirely.
+func NewTLSConfig(insecureSkipVerify bool, caFile string) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if caFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates found in CA file %s", caFile)
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// WithDefaultFormat sets the content format ("markdown" or "org") used for new pages and
+// assumed for blocks whose format Logseq does not report. Unknown formats are ignored.
+func WithDefaultFormat(format string) ClientOption {
+	return func(c *Client) {
+		if IsBlockFormat(format) {
+			c.defaultFormat = format
+		}
+	}
+}
+
+// WithIdempotentAppend makes AppendBlockInPage return the page's last top-level block instead of
+// appending when that block already has the same content. This keeps a retried append whose first
+// response was lost from adding the block twice, at the cost of one extra lookup per append and
+// of refusing intentional consecutive duplicates.
+func WithIdempotentAppend() ClientOption {
+	return func(c *Client) {
+		c.idempotentAppend = true
+	}
+}
+
+// WithoutLinkAutoCreate stops EnsureLinkedPages from creating missing [[linked]] pages. Missing pages are
+// logged as a warning instead, and namespaced links to them are left as [[links]].
+func WithoutLinkAutoCreate() ClientOption {
+	return func(c *Client) {
+		c.noAutoCreateLinks = true
+	}
+}
+
+// WithoutAliasResolution makes ResolvePage report a name as missing instead of looking for a page that has it as an alias
+func WithoutAliasResolution() ClientOption {
+	return func(c *Client) {
+		c.noAliasResolution = true
+	}
+}
+
+// DefaultTimeout is the deadline of a single Logseq API call
+const DefaultTimeout = 10 * time.Second
+
+// DefaultHeavyTimeout is the deadline of calls that scan the whole graph, such as listing all pages or
+// the queries behind search_all, export_graph, replace_everywhere and the graph hygiene tools
+const DefaultHeavyTimeout = 2 * time.Minute
+
+// WithTimeout sets the deadline of regular API calls. Values <= 0 keep the default.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.timeout = d
+		}
+	}
+}
+
+// WithHeavyTimeout sets the deadline of graph-wide calls. Values <= 0 keep the default.
+func WithHeavyTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.heavyTimeout = d
+		}
+	}
+}
+
+// WithMaxResponseBytes makes Call fail with ErrResponseTooLarge instead of buffering responses larger than n bytes
+func WithMaxResponseBytes(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxResponseBytes = n
+			c.client.SetResponseBodyLimit(n)
+		}
+	}
 }
 
-func NewClient(apiURL, token string, logger *zap.Logger) *Client {
+func NewClient(apiURL, token string, logger *zap.Logger, opts ...ClientOption) *Client {
 	c := resty.New()
 	c.SetBaseURL(apiURL)
-	c.SetTimeout(10 * time.Second)
 	c.SetHeader("Authorization", "Bearer "+token)
 	c.SetHeader("Content-Type", "application/json")
+	c.SetHeader("User-Agent", "yalms")
+
+	client := &Client{
+		client:        c,
+		logger:        logger,
+		token:         token,
+		apiURL:        apiURL,
+		location:      time.Local,
+		clock:         realClock{},
+		concurrency:   1,
+		defaultFormat: FormatMarkdown,
+		timeout:       DefaultTimeout,
+		heavyTimeout:  DefaultHeavyTimeout,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// APIURL returns the base URL of the Logseq HTTP API the client talks to
+func (c *Client) APIURL() string {
+	return c.apiURL
+}
 
-	return &Client{
-		client: c,
-		logger: logger,
-		token:  token,
-		apiURL: apiURL,
+// newRequestID returns a short random id used to correlate a call with Logseq/proxy logs
+func newRequestID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "00000000"
 	}
+	return hex.EncodeToString(b)
 }
 
 // Generic request structure for Logseq API
@@ -38,17 +264,52 @@ type apiRequest struct {
 	Args   []interface{} `json:"args"`
 }
 
+// ErrResponseTooLarge is returned when a response exceeds the limit set with WithMaxResponseBytes
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ErrNoGraphOpen is returned by GetGraph when Logseq is reachable but has no graph open
+var ErrNoGraphOpen = errors.New("no graph is open")
+
+// APIError is returned by Call when the Logseq HTTP API responds with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Body       string
+	Method     string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error: %s (status: %d)", e.Body, e.StatusCode)
+}
+
+// IsUnauthorized reports whether err is an APIError caused by a missing or invalid API token
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
 func (c *Client) Call(method string, args ...any) ([]byte, error) {
+	return c.CallWithTimeout(c.timeout, method, args...)
+}
+
+// CallWithTimeout is Call with its own deadline, for operations that legitimately take longer than an interactive read
+func (c *Client) CallWithTimeout(timeout time.Duration, method string, args ...any) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
 	reqBody := apiRequest{
 		Method: method,
 		Args:   args,
 	}
 
+	requestID := newRequestID()
+
 	if c.logger != nil {
-		c.logger.Debug("Logseq API Call", zap.String("method", method), zap.Any("args", args))
+		c.logger.Debug("Logseq API Call", zap.String("method", method), zap.Any("args", args), zap.String("request_id", requestID))
 	}
 
 	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("X-Request-ID", requestID).
 		SetBody(reqBody).
 		Post("/api")
 
@@ -56,6 +317,12 @@ func (c *Client) Call(method string, args ...any) ([]byte, error) {
 		if c.logger != nil {
 			c.logger.Error("Logseq API request failed", zap.String("method", method), zap.Error(err))
 		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("request failed: %s did not finish within %s: %w", method, timeout, err)
+		}
+		if errors.Is(err, resty.ErrResponseBodyTooLarge) {
+			return nil, fmt.Errorf("%w: %s returned more than %d bytes, try a narrower query (e.g. pull specific attributes instead of [*])", ErrResponseTooLarge, method, c.maxResponseBytes)
+		}
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
@@ -63,7 +330,7 @@ func (c *Client) Call(method string, args ...any) ([]byte, error) {
 		if c.logger != nil {
 			c.logger.Error("Logseq API error response", zap.String("method", method), zap.Int("status", resp.StatusCode()), zap.String("body", resp.String()))
 		}
-		return nil, fmt.Errorf("api error: %s (status: %d)", resp.String(), resp.StatusCode())
+		return nil, &APIError{StatusCode: resp.StatusCode(), Body: resp.String(), Method: method}
 	}
 
 	// Logseq API often returns errors as JSON with an "error" field even with 200 OK
@@ -87,17 +354,63 @@ func (c *Client) GetGraph() (*GraphInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	if string(resp) == "null" {
+		return nil, ErrNoGraphOpen
+	}
 	var graph GraphInfo
 	if err := json.Unmarshal(resp, &graph); err != nil {
-		// Logseq might return null if no graph is open, or different structure
 		return nil, fmt.Errorf("failed to parse graph info: %w", err)
 	}
 	return &graph, nil
 }
 
+// ResolveAssetPath returns the absolute filesystem path of an asset referenced in a block, within the current graph
+func (c *Client) ResolveAssetPath(ref string) (string, error) {
+	graph, err := c.GetGraph()
+	if err != nil {
+		return "", err
+	}
+	return ResolveAssetPath(graph.Path, ref)
+}
+
 // Page Methods
 
+// ErrUnsupported is returned when the running Logseq version does not provide an API method
+var ErrUnsupported = errors.New("not supported by this Logseq version")
+
+// GetUserConfigs returns the app settings (preferred date format, workflow, format, ...)
+func (c *Client) GetUserConfigs() (map[string]any, error) {
+	resp, err := c.Call("logseq.App.getUserConfigs")
+	if err != nil {
+		if isMissingMethod(err) {
+			return nil, fmt.Errorf("logseq.App.getUserConfigs: %w", ErrUnsupported)
+		}
+		return nil, err
+	}
+	if string(resp) == "null" {
+		return nil, fmt.Errorf("logseq.App.getUserConfigs: %w", ErrUnsupported)
+	}
+
+	var configs map[string]any
+	if err := json.Unmarshal(resp, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse user configs: %w", err)
+	}
+	return configs, nil
+}
+
+// isMissingMethod reports whether err means the API method does not exist in this Logseq version
+func isMissingMethod(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "methodnotexist") || strings.Contains(msg, "method not exist") || strings.Contains(msg, "not a function")
+}
+
 func (c *Client) RenamePage(uuid string, newName string) error {
+	defer c.invalidatePageCache()
+
 	// 1. Rename to new name directly
 	if _, err := c.Call("logseq.Editor.renamePage", uuid, newName); err != nil {
 		return fmt.Errorf("failed to rename page: %w", err)
@@ -107,6 +420,10 @@ func (c *Client) RenamePage(uuid string, newName string) error {
 }
 
 func (c *Client) GetPage(nameOrUUID string) (*Page, error) {
+	if page, ok := c.cachedPage(nameOrUUID); ok {
+		return page, nil
+	}
+
 	// 1. Try direct lookup (UUID or Name)
 	resp, err := c.Call("logseq.Editor.getPage", nameOrUUID)
 	if err != nil {
@@ -117,19 +434,141 @@ func (c *Client) GetPage(nameOrUUID string) (*Page, error) {
 	if string(resp) != "null" && string(resp) != "[]" {
 		var page Page
 		if err := json.Unmarshal(resp, &page); err == nil {
+			c.cachePage(nameOrUUID, &page)
 			return &page, nil
 		}
 	}
-	
+
 	return nil, nil
 }
 
+// ResolvePage is GetPage for readers: a name that matches no page is looked up as an alias:: of another page.
+// Write paths use GetPage, so a name that is only an alias is created as its own page rather than redirected.
+func (c *Client) ResolvePage(nameOrUUID string) (*Page, error) {
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil || page != nil || c.noAliasResolution || IsUUID(nameOrUUID) {
+		return page, err
+	}
+
+	page, err = c.resolveAlias(nameOrUUID)
+	if err != nil && c.logger != nil {
+		// The page itself was not found; a failed alias lookup does not change that
+		c.logger.Warn("Alias lookup failed", zap.String("page", nameOrUUID), zap.Error(err))
+	}
+	return page, nil
+}
+
+// resolveAlias finds the page that lists name in its alias:: property. It returns nil unless exactly one page does.
+func (c *Client) resolveAlias(name string) (*Page, error) {
+	datalog := fmt.Sprintf(`[:find (pull ?p [*]) :where [?a :block/name %q] [?p :block/alias ?a]]`, strings.ToLower(name))
+
+	results, err := c.Query(datalog)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []Page
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			pageBytes, _ := json.Marshal(item)
+			var p Page
+			if err := json.Unmarshal(pageBytes, &p); err == nil && p.UUID != "" {
+				pages = append(pages, p)
+			}
+		}
+	}
+	if len(pages) != 1 {
+		if len(pages) > 1 && c.logger != nil {
+			c.logger.Warn("Alias is shared by several pages; not resolving it", zap.String("alias", name), zap.Int("pages", len(pages)))
+		}
+		return nil, nil
+	}
+	if c.logger != nil {
+		c.logger.Debug("Resolved alias", zap.String("alias", name), zap.String("page", pages[0].Name))
+	}
+	return &pages[0], nil
+}
+
+// GetPages looks up several pages by name, alias or UUID, preserving the input order. Pages that do not exist are nil.
+func (c *Client) GetPages(namesOrUUIDs []string) ([]*Page, error) {
+	pages := make([]*Page, len(namesOrUUIDs))
+	err := c.forEach(len(namesOrUUIDs), func(i int) error {
+		page, err := c.ResolvePage(namesOrUUIDs[i])
+		if err != nil {
+			return fmt.Errorf("failed to get page %s: %w", namesOrUUIDs[i], err)
+		}
+		pages[i] = page
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+func (c *Client) cachedPage(key string) (*Page, bool) {
+	if !c.pageCacheOn {
+		return nil, false
+	}
+	c.pageCacheMu.Lock()
+	defer c.pageCacheMu.Unlock()
+
+	entry, ok := c.pageCache[strings.ToLower(key)]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return clonePage(entry.page), true
+}
+
+// clonePage copies a page and its property maps so the cache and its callers never share them.
+// Property values are JSON-decoded and not copied further.
+func clonePage(p *Page) *Page {
+	page := *p
+	page.Properties = maps.Clone(p.Properties)
+	page.RawProperties = maps.Clone(p.RawProperties)
+	page.ExplicitProperties = maps.Clone(p.ExplicitProperties)
+	return &page
+}
+
+func (c *Client) cachePage(key string, page *Page) {
+	if !c.pageCacheOn {
+		return
+	}
+	c.pageCacheMu.Lock()
+	defer c.pageCacheMu.Unlock()
+
+	entry := pageCacheEntry{page: clonePage(page), expires: time.Now().Add(c.pageCacheTTL)}
+	c.pageCache[strings.ToLower(key)] = entry
+	if page.UUID != "" {
+		c.pageCache[page.UUID] = entry
+	}
+	if page.Name != "" {
+		c.pageCache[strings.ToLower(page.Name)] = entry
+	}
+}
+
+func (c *Client) invalidatePageCache() {
+	if !c.pageCacheOn {
+		return
+	}
+	c.pageCacheMu.Lock()
+	defer c.pageCacheMu.Unlock()
+	clear(c.pageCache)
+}
+
 func (c *Client) _RenamePage_Legacy(uuid string, newName string) error {
 	// Keep this signature valid for now by not using it directly
 	return nil
 }
 
 func (c *Client) CreatePage(name string, properties map[string]any, options map[string]any) (*Page, error) {
+	page, _, err := c.EnsurePage(name, properties, options)
+	return page, err
+}
+
+// EnsurePage behaves like CreatePage but additionally reports whether the page
+// was newly created (true) or already existed (false).
+func (c *Client) EnsurePage(name string, properties map[string]any, options map[string]any) (*Page, bool, error) {
 	// Prepare properties
 	if properties == nil {
 		properties = make(map[string]any)
@@ -140,12 +579,25 @@ func (c *Client) CreatePage(name string, properties map[string]any, options map[
 		// Idempotent: It's the same page. Return it.
 		if len(properties) > 0 {
 			c.UpdatePage(existing.UUID, properties)
-			return c.GetPage(existing.UUID)
+			page, err := c.GetPage(existing.UUID)
+			return page, false, err
 		}
-		return existing, nil
+		return existing, false, nil
 	}
 
-	// 2. Create Page directly
+	// 2. Create Page directly. Only new names are validated, so existing pages
+	// with names Logseq no longer accepts can still be ensured and updated.
+	if err := ValidatePageName(name); err != nil {
+		return nil, false, err
+	}
+	if c.defaultFormat != FormatMarkdown {
+		if options == nil {
+			options = make(map[string]any)
+		}
+		if _, ok := options["format"]; !ok {
+			options["format"] = c.defaultFormat
+		}
+	}
 	args := []any{name, properties}
 	if options != nil {
 		args = append(args, options)
@@ -156,6 +608,7 @@ func (c *Client) CreatePage(name string, properties map[string]any, options map[
 	}
 
 	resp, err := c.Call("logseq.Editor.createPage", args...)
+	c.invalidatePageCache()
 	
 	var page Page
 	success := false
@@ -180,7 +633,7 @@ func (c *Client) CreatePage(name string, properties map[string]any, options map[
 		if c.logger != nil {
 			c.logger.Warn("CreatePage failed", zap.Error(err))
 		}
-		return nil, err
+		return nil, false, err
 	}
 
 	if success {
@@ -188,14 +641,14 @@ func (c *Client) CreatePage(name string, properties map[string]any, options map[
 		if len(properties) > 0 {
 			c.UpdatePage(page.UUID, properties)
 			if updated, err := c.GetPage(page.UUID); err == nil && updated != nil {
-				return updated, nil
+				return updated, true, nil
 			}
 		}
 		
-		return &page, nil
+		return &page, true, nil
 	}
 
-	return nil, fmt.Errorf("failed to create page '%s'", name)
+	return nil, false, fmt.Errorf("failed to create page '%s'", name)
 }
 
 func (c *Client) UpdatePage(uuid string, properties map[string]any) (*Page, error) {
@@ -207,9 +660,11 @@ func (c *Client) UpdatePage(uuid string, properties map[string]any) (*Page, erro
 	for k, v := range properties {
 		_, err := c.Call("logseq.Editor.upsertBlockProperty", uuid, k, v)
 		if err != nil {
+			c.invalidatePageCache()
 			return nil, fmt.Errorf("failed to update property %s: %w", k, err)
 		}
 	}
+	c.invalidatePageCache()
 	
 	// Fetch updated page
 	// Wait a tiny bit? No, API should be synchronous enough or consistent.
@@ -218,527 +673,2517 @@ func (c *Client) UpdatePage(uuid string, properties map[string]any) (*Page, erro
 
 func (c *Client) DeletePage(nameOrUUID string) error {
 	_, err := c.Call("logseq.Editor.deletePage", nameOrUUID)
+	c.invalidatePageCache()
 	return err
 }
 
 func (c *Client) UpsertProperty(uuid string, key string, value any) error {
 	_, err := c.Call("logseq.Editor.upsertBlockProperty", uuid, key, value)
+	c.invalidatePageCache()
 	return err
 }
 
 func (c *Client) RemoveProperty(uuid string, key string) error {
 	_, err := c.Call("logseq.Editor.removeBlockProperty", uuid, key)
+	c.invalidatePageCache()
 	return err
 }
 
-// Namespace Methods
+// ErrPropertyNotFound is returned when an operation expects a property that the entity does not have
+var ErrPropertyNotFound = errors.New("property not found")
 
-func (c *Client) GetNamespacePages(namespace string) ([]Page, error) {
-	// Find all pages where the parent is the specified namespace page
-	datalog := fmt.Sprintf(`[:find (pull ?p [*]) :where [?p :block/name] [?p :block/parent ?parent] [?parent :block/name "%s"]]`, strings.ToLower(namespace))
+// RenameProperty moves the value of oldKey to newKey on a single block or page.
+// The new key is written before the old one is removed, so a failed write never loses the value.
+func (c *Client) RenameProperty(uuid string, oldKey string, newKey string) error {
+	if oldKey == newKey {
+		return fmt.Errorf("old and new property keys are identical: '%s'", oldKey)
+	}
 
-	if c.logger != nil {
-		c.logger.Debug("GetNamespacePages Query", zap.String("namespace", namespace), zap.String("query", datalog))
+	value, err := c.getProperty(uuid, oldKey)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("%w: '%s' on %s", ErrPropertyNotFound, oldKey, uuid)
 	}
 
-	results, err := c.Query(datalog)
+	existing, err := c.getProperty(uuid, newKey)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("property '%s' already exists on %s, refusing to overwrite it", newKey, uuid)
 	}
 
-	var pages []Page
-	if list, ok := results.([]any); ok {
-		for _, item := range list {
-			pageBytes, _ := json.Marshal(item)
-			var p Page
-			if err := json.Unmarshal(pageBytes, &p); err == nil && p.UUID != "" {
-				pages = append(pages, p)
+	if err := c.UpsertProperty(uuid, newKey, value); err != nil {
+		return fmt.Errorf("failed to write '%s', '%s' was left unchanged: %w", newKey, oldKey, err)
+	}
+	if err := c.RemoveProperty(uuid, oldKey); err != nil {
+		return fmt.Errorf("value copied to '%s' but failed to remove '%s': %w", newKey, oldKey, err)
+	}
+	return nil
+}
+
+// RenamePropertyEverywhere renames oldKey to newKey on every page that has it.
+// It returns the number of renamed pages and one error per page that could not be renamed.
+func (c *Client) RenamePropertyEverywhere(oldKey string, newKey string) (int, []error, error) {
+	pages, err := c.FindPagesWithProperty(oldKey)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	count := 0
+	var errs []error
+	for _, p := range pages {
+		if err := c.RenameProperty(p.UUID, oldKey, newKey); err != nil {
+			if c.logger != nil {
+				c.logger.Error("RenamePropertyEverywhere failed for page", zap.String("page", p.Name), zap.Error(err))
 			}
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+			continue
 		}
+		count++
 	}
-
-	return pages, nil
+	return count, errs, nil
 }
 
-// Block Methods
-
-func (c *Client) GetBlock(uuid string) (*Block, error) {
-	resp, err := c.Call("logseq.Editor.getBlock", uuid, true) // include children
+// FindReplaceMatches lists the blocks of the whole graph a find-and-replace would change, with their new content.
+// Candidates come from a case-insensitive SearchAll and are narrowed with ReplaceText.
+func (c *Client) FindReplaceMatches(opts ReplaceOptions) ([]ReplaceMatch, error) {
+	if opts.Find == "" {
+		return nil, fmt.Errorf("search text must not be empty")
+	}
+	hits, err := c.SearchAll(opts.Find, 0)
 	if err != nil {
 		return nil, err
 	}
-	if string(resp) == "null" {
-		if c.logger != nil {
-			c.logger.Debug("GetBlock returned null", zap.String("uuid", uuid))
+
+	matches := []ReplaceMatch{}
+	for _, hit := range hits {
+		replaced, n := ReplaceText(hit.Content, opts)
+		if n == 0 {
+			continue
 		}
-		return nil, nil
+		matches = append(matches, ReplaceMatch{UUID: hit.UUID, Page: hit.Page, Content: hit.Content, Replaced: replaced, Count: n})
 	}
-	var block Block
-	if err := json.Unmarshal(resp, &block); err != nil {
-		return nil, fmt.Errorf("failed to parse block: %w", err)
+	return matches, nil
+}
+
+// ReplaceEverywhere runs a find-and-replace over every block of the graph. It returns the number of blocks changed
+// and the per-block update errors; the error is only set if the candidate blocks could not be found.
+func (c *Client) ReplaceEverywhere(opts ReplaceOptions) (int, []error, error) {
+	matches, err := c.FindReplaceMatches(opts)
+	if err != nil {
+		return 0, nil, err
 	}
-	// Debug: check properties
-	if c.logger != nil {
-		// Log properties map keys only to reduce noise? No, full properties.
-		// c.logger.Debug("GetBlock properties", zap.String("uuid", uuid), zap.Any("props", block.Properties))
+
+	count := 0
+	var errs []error
+	for _, m := range matches {
+		if _, err := c.UpdateBlock(m.UUID, m.Replaced, nil); err != nil {
+			if c.logger != nil {
+				c.logger.Error("ReplaceEverywhere failed for block", zap.String("uuid", m.UUID), zap.Error(err))
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", m.UUID, err))
+			continue
+		}
+		count++
 	}
-	return &block, nil
+	return count, errs, nil
 }
 
-// Tag Methods (Text-based #Tag)
+// FindPagesWithProperty returns all pages that have the property key set, whatever its value
+func (c *Client) FindPagesWithProperty(key string) ([]Page, error) {
+	return c.FindPagesByProperty(key, nil)
+}
 
-func (c *Client) getEntityBlock(uuid string) (*Block, error) {
-	block, err := c.GetBlock(uuid)
+func (c *Client) getProperty(uuid string, key string) (any, error) {
+	resp, err := c.Call("logseq.Editor.getBlockProperty", uuid, key)
 	if err != nil {
 		return nil, err
 	}
-	if block != nil {
-		return block, nil
+	var value any
+	if err := json.Unmarshal(resp, &value); err != nil {
+		return nil, err
 	}
+	return value, nil
+}
 
-	// Try as page
-	page, err := c.GetPage(uuid)
-	if err != nil || page == nil {
-		return nil, fmt.Errorf("entity not found: %s", uuid)
-	}
+// Namespace Methods
 
-	// Try to find block by UUID which matches page UUID for page properties block
-	block, err = c.GetBlock(page.UUID)
+// GetParentNamespace resolves the namespace parent of a page, e.g. "A/B" for "A/B/C". It returns the parent's
+// name and page; the name is empty for a top-level page, and the page is nil if the parent does not exist as a page.
+func (c *Client) GetParentNamespace(nameOrUUID string) (string, *Page, error) {
+	page, err := c.GetPage(nameOrUUID)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
-	if block != nil {
-		return block, nil
+	if page == nil {
+		return "", nil, fmt.Errorf("page not found: %s", nameOrUUID)
 	}
+	parentName := parentNamespace(page)
+	if parentName == "" {
+		return "", nil, nil
+	}
+	parent, err := c.GetPage(parentName)
+	if err != nil {
+		return parentName, nil, err
+	}
+	return parentName, parent, nil
+}
 
-	// Try fetching page blocks tree and take the first block
-	blocksRaw, err := c.Call("logseq.Editor.getPageBlocksTree", page.UUID)
-	if err == nil && string(blocksRaw) != "null" && string(blocksRaw) != "[]" {
-		var bTree []Block
-		if err := json.Unmarshal(blocksRaw, &bTree); err == nil && len(bTree) > 0 {
-			return &bTree[0], nil
-		}
+// parentNamespace returns the namespace part of a page's name ("A/B" for "A/B/C"), or "" for a top-level page
+func parentNamespace(page *Page) string {
+	name := page.OriginalName
+	if name == "" {
+		name = page.Name
+	}
+	if idx := strings.LastIndex(name, "/"); idx > 0 {
+		return name[:idx]
+	}
+	return ""
+}
+
+// GetSiblingPages returns the other pages in the namespace of a page, e.g. "A/B/D" for "A/B/C".
+// The namespace is empty, and so are the pages, for a top-level page.
+func (c *Client) GetSiblingPages(nameOrUUID string) (string, []Page, error) {
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return "", nil, err
+	}
+	if page == nil {
+		return "", nil, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+	namespace := parentNamespace(page)
+	if namespace == "" {
+		return "", []Page{}, nil
 	}
 
-	return nil, fmt.Errorf("failed to find content block for page: %s", uuid)
+	children, err := c.GetNamespacePages(namespace)
+	if err != nil {
+		return namespace, nil, err
+	}
+	siblings := []Page{}
+	for _, p := range children {
+		if p.UUID != page.UUID {
+			siblings = append(siblings, p)
+		}
+	}
+	return namespace, siblings, nil
 }
 
-func (c *Client) AddTag(uuid string, tag string) error {
-	block, err := c.getEntityBlock(uuid)
+func (c *Client) GetNamespacePages(namespace string) ([]Page, error) {
+	// Find all pages where the parent is the specified namespace page
+	datalog := fmt.Sprintf(`[:find (pull ?p [*]) :where [?p :block/name] [?p :block/parent ?parent] [?parent :block/name "%s"]]`, strings.ToLower(namespace))
+
+	if c.logger != nil {
+		c.logger.Debug("GetNamespacePages Query", zap.String("namespace", namespace), zap.String("query", datalog))
+	}
+
+	results, err := c.Query(datalog)
 	if err != nil {
-		// If block not found, try to append an empty block if it's a page
-		if strings.Contains(err.Error(), "failed to find content block") {
-			block, err = c.AppendBlockInPage(uuid, "", nil)
-			if err != nil {
-				return err
+		return nil, err
+	}
+
+	var pages []Page
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			pageBytes, _ := json.Marshal(item)
+			var p Page
+			if err := json.Unmarshal(pageBytes, &p); err == nil && p.UUID != "" {
+				pages = append(pages, p)
 			}
-		} else {
-			return err
 		}
 	}
 
-	cleanTag := strings.TrimPrefix(tag, "#")
-	tagStr := "#" + cleanTag
-	if strings.Contains(block.Content, tagStr) {
-		return nil // Already tagged
+	return pages, nil
+}
+
+// FindPagesByTag returns all pages tagged with the given tag, either via a tags:: property or a #tag in one of their blocks
+func (c *Client) FindPagesByTag(tag string) ([]Page, error) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	datalog := fmt.Sprintf(`[:find (pull ?p [*]) :where [?t :block/name %q] (or [?p :block/tags ?t] (and [?b :block/page ?p] [?b :block/refs ?t])) [?p :block/name]]`, tag)
+
+	if c.logger != nil {
+		c.logger.Debug("FindPagesByTag Query", zap.String("tag", tag), zap.String("query", datalog))
+	}
+
+	results, err := c.Query(datalog)
+	if err != nil {
+		return nil, err
 	}
 
-	newContent := strings.TrimSpace(block.Content) + " " + tagStr
-	_, err = c.UpdateBlock(block.UUID, newContent, nil)
-	return err
+	pages := []Page{}
+	seen := make(map[string]bool)
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			pageBytes, _ := json.Marshal(item)
+			var p Page
+			if err := json.Unmarshal(pageBytes, &p); err == nil && p.UUID != "" && !seen[p.UUID] {
+				seen[p.UUID] = true
+				pages = append(pages, p)
+			}
+		}
+	}
+	return pages, nil
 }
 
-func (c *Client) RemoveTag(uuid string, tag string) error {
-	block, err := c.getEntityBlock(uuid)
+// ErrAmbiguousMatch is returned when a lookup expected to be unique matches several pages
+var ErrAmbiguousMatch = errors.New("multiple pages match")
+
+// FindPagesByProperty returns all pages whose property key has the given value (compared as strings).
+// A nil value matches every page that has the key.
+func (c *Client) FindPagesByProperty(key string, value any) ([]Page, error) {
+	kw, err := propertyKeyword(key)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	match := fmt.Sprintf(`[(get ?props %s)]`, kw)
+	if value != nil {
+		match = fmt.Sprintf(`[(get ?props %s) ?v] [(str ?v) ?s] [(= ?s %q)]`, kw, fmt.Sprint(value))
 	}
+	datalog := fmt.Sprintf(`[:find (pull ?p [*]) :where [?p :block/name] [?p :block/properties ?props] %s]`, match)
 
-	cleanTag := strings.TrimPrefix(tag, "#")
-	tagStr := "#" + cleanTag
+	if c.logger != nil {
+		c.logger.Debug("FindPagesByProperty Query", zap.String("key", key), zap.String("query", datalog))
+	}
 
-	if !strings.Contains(block.Content, tagStr) {
-		return nil
+	results, err := c.graphQuery(datalog)
+	if err != nil {
+		return nil, err
 	}
 
-	newContent := strings.ReplaceAll(block.Content, tagStr, "")
-	newContent = strings.ReplaceAll(newContent, "  ", " ") // Cleanup spaces
-	newContent = strings.TrimSpace(newContent)
+	pages := []Page{}
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			pageBytes, _ := json.Marshal(item)
+			var p Page
+			if err := json.Unmarshal(pageBytes, &p); err == nil && p.UUID != "" {
+				pages = append(pages, p)
+			}
+		}
+	}
 
-	_, err = c.UpdateBlock(block.UUID, newContent, nil)
-	return err
+	return pages, nil
 }
 
-func (c *Client) EnsureLinkedPages(content string, properties map[string]any) string {
-	// 1. From Content
-	links := extractLinks(content)
-	
-	// 2. From Properties (values)
-	for _, v := range properties {
-		if str, ok := v.(string); ok {
-			propLinks := extractLinks(str)
-			links = append(links, propLinks...)
+// FindPageByUniqueProperty returns the single page whose property matches the value.
+// It returns nil if nothing matches and ErrAmbiguousMatch if several pages do.
+func (c *Client) FindPageByUniqueProperty(key string, value any) (*Page, error) {
+	pages, err := c.FindPagesByProperty(key, value)
+	if err != nil {
+		return nil, err
+	}
+	switch len(pages) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &pages[0], nil
+	default:
+		names := make([]string, 0, len(pages))
+		for _, p := range pages {
+			names = append(names, p.Name)
 		}
+		return nil, fmt.Errorf("%w: %s=%v matches %d pages %v", ErrAmbiguousMatch, key, value, len(pages), names)
 	}
-	
-	// 3. Ensure each page exists (handling namespaces)
-	// Deduplicate first
-	uniqueLinks := make(map[string]bool)
-	var linkList []string
-	for _, l := range links {
-		if !uniqueLinks[l] {
-			uniqueLinks[l] = true
-			linkList = append(linkList, l)
+}
+
+// FindBlocksByProperty returns all blocks (not pages) whose property key has the given value (compared as strings).
+// The owning page is pulled with its name so callers can report where each block lives.
+func (c *Client) FindBlocksByProperty(key string, value string) ([]Block, error) {
+	kw, err := propertyKeyword(key)
+	if err != nil {
+		return nil, err
+	}
+	datalog := fmt.Sprintf(`[:find (pull ?b [* {:block/page [:block/uuid :block/name]}]) :where [?b :block/page] [?b :block/properties ?props] [(get ?props %s) ?v] [(str ?v) ?s] [(= ?s %q)]]`, kw, value)
+
+	if c.logger != nil {
+		c.logger.Debug("FindBlocksByProperty Query", zap.String("key", key), zap.String("query", datalog))
+	}
+
+	results, err := c.Query(datalog)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := []Block{}
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			blockBytes, _ := json.Marshal(item)
+			var b Block
+			if err := json.Unmarshal(blockBytes, &b); err == nil && b.UUID != "" {
+				blocks = append(blocks, b)
+			}
 		}
 	}
 
-	for _, link := range linkList {
-		// Handle hierarchy: if "A/B/C", ensure "A", then "A/B", then "A/B/C"
-		parts := strings.Split(link, "/")
-		currentPath := ""
-		
-		// If it's a namespaced page, we need its final UUID for replacement
-		var finalUUID string
-		
-		for i, part := range parts {
-			if currentPath == "" {
-				currentPath = part
+	return blocks, nil
+}
+
+// SearchAll finds blocks whose content or any property value contains term (case-insensitive).
+// Content matches come first; a block matching both is reported once as "content". At most limit hits are returned.
+func (c *Client) SearchAll(term string, limit int) ([]SearchHit, error) {
+	needle := strings.ToLower(term)
+	contentQuery := fmt.Sprintf(`[:find (pull ?b [:block/uuid :block/content {:block/page [:block/name]}]) :where [?b :block/content ?c] [(clojure.string/lower-case ?c) ?lc] [(clojure.string/includes? ?lc %q)]]`, needle)
+	// The properties map is matched as a whole and narrowed to its values below
+	propertyQuery := fmt.Sprintf(`[:find (pull ?b [:block/uuid :block/name :block/content :block/properties {:block/page [:block/name]}]) :where [?b :block/properties ?props] [(str ?props) ?s] [(clojure.string/lower-case ?s) ?ls] [(clojure.string/includes? ?ls %q)]]`, needle)
+
+	if c.logger != nil {
+		c.logger.Debug("SearchAll Query", zap.String("term", term), zap.String("content_query", contentQuery), zap.String("property_query", propertyQuery))
+	}
+
+	hits := []SearchHit{}
+	seen := make(map[string]bool)
+	for _, q := range []struct {
+		datalog   string
+		matchType string
+	}{{contentQuery, "content"}, {propertyQuery, "property"}} {
+		results, err := c.graphQuery(q.datalog)
+		if err != nil {
+			return nil, err
+		}
+		list, _ := results.([]any)
+		for _, item := range list {
+			if limit > 0 && len(hits) >= limit {
+				return hits, nil
+			}
+			m, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			uuid, _ := m["uuid"].(string)
+			if uuid == "" || seen[uuid] {
+				continue
+			}
+			if q.matchType == "property" && !propertyValuesContain(m["properties"], needle) {
+				continue
+			}
+			seen[uuid] = true
+
+			hit := SearchHit{UUID: uuid, MatchType: q.matchType}
+			hit.Content, _ = m["content"].(string)
+			if page, ok := m["page"].(map[string]any); ok {
+				hit.Page, _ = page["name"].(string)
 			} else {
-				currentPath = currentPath + "/" + part
+				hit.Page, _ = m["name"].(string) // A page's own properties
 			}
-			
-			// Check if exists
-			if c.logger != nil {
-				c.logger.Debug("Checking linked page existence", zap.String("page", currentPath))
+			hits = append(hits, hit)
+		}
+	}
+	return hits, nil
+}
+
+// propertyValuesContain reports whether any value of a properties map contains the lower-cased needle
+func propertyValuesContain(properties any, needle string) bool {
+	props, ok := properties.(map[string]any)
+	if !ok {
+		return false
+	}
+	for _, v := range props {
+		if strings.Contains(strings.ToLower(fmt.Sprint(v)), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetNamespaceTree returns all descendants of a namespace as a flat list in
+// breadth-first order, with Depth set relative to the namespace (1 = direct child).
+func (c *Client) GetNamespaceTree(namespace string) ([]Page, error) {
+	var tree []Page
+	visited := make(map[string]bool)
+
+	type level struct {
+		name  string
+		depth int
+	}
+	queue := []level{{name: namespace, depth: 0}}
+	visited[strings.ToLower(namespace)] = true
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		children, err := c.GetNamespacePages(current.name)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			key := strings.ToLower(child.Name)
+			if child.Name == "" || visited[key] {
+				continue // Guard against cycles and unnamed entries
 			}
-			
-			page, err := c.GetPage(currentPath)
-			if err == nil && page != nil {
-				if i == len(parts)-1 {
-					finalUUID = page.UUID
-				}
-				continue // Exists
+			visited[key] = true
+			child.Depth = current.depth + 1
+			tree = append(tree, child)
+			queue = append(queue, level{name: child.Name, depth: child.Depth})
+		}
+	}
+
+	return tree, nil
+}
+
+// ImportCSV creates one page per CSV row. The header row names the columns; the
+// NameColumn value becomes the page title and all other non-empty cells become properties.
+func (c *Client) ImportCSV(data string, opts CSVImportOptions) (*CSVImportResult, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	nameIdx := -1
+	for i, col := range header {
+		header[i] = strings.TrimSpace(col)
+		if header[i] == opts.NameColumn {
+			nameIdx = i
+		}
+	}
+	if nameIdx == -1 {
+		return nil, fmt.Errorf("name column '%s' not found in CSV header %v", opts.NameColumn, header)
+	}
+
+	result := &CSVImportResult{Errors: []CSVRowError{}}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// FieldPos must not be called after a failed Read; the parse error carries the line
+			var line int
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
 			}
-			
-			// Not found -> Create
-			if c.logger != nil {
-				c.logger.Info("Auto-creating missing linked page/namespace", zap.String("page", currentPath))
+			result.Errors = append(result.Errors, CSVRowError{Row: line, Error: err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		name := strings.TrimSpace(record[nameIdx])
+		if name == "" {
+			result.Errors = append(result.Errors, CSVRowError{Row: line, Error: fmt.Sprintf("empty value in name column '%s'", opts.NameColumn)})
+			continue
+		}
+
+		props := make(map[string]any)
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if i == nameIdx || value == "" || header[i] == "" {
+				continue
 			}
-			
-			created, err := c.CreatePage(currentPath, nil, nil)
-			if err != nil {
-				if c.logger != nil {
-					c.logger.Error("Failed to auto-create linked page", zap.String("page", currentPath), zap.Error(err))
-				}
-			} else if created != nil && i == len(parts)-1 {
-				finalUUID = created.UUID
+			key := header[i]
+			if opts.KeyFunc != nil {
+				key = opts.KeyFunc(key)
+			}
+			if opts.ValueFunc != nil {
+				props[key] = opts.ValueFunc(value)
+			} else {
+				props[key] = value
 			}
 		}
-		
-		// 4. Replace namespaced links with UUID refs in content
-		// User requirement: "for linking in blocks to pages that are in a namesapce we ALWAYS use a uuid"
-		if strings.Contains(link, "/") && finalUUID != "" {
-			// Replace [[Name/Space]] with ((UUID))
-			// Be careful with replacement to avoid partial matches if possible, but [[...]] is specific.
-			// extractLinks returns the name inside [[...]].
-			target := "[[" + link + "]]"
-			replacement := "((" + finalUUID + "))"
-			if c.logger != nil {
-				c.logger.Debug("Replacing namespaced link with UUID", zap.String("original", target), zap.String("replacement", replacement))
+
+		fullName := name
+		if opts.Namespace != "" {
+			fullName = opts.Namespace + "/" + name
+		}
+
+		page, created, err := c.EnsurePage(fullName, props, nil)
+		if err != nil {
+			result.Errors = append(result.Errors, CSVRowError{Row: line, Name: fullName, Error: err.Error()})
+			continue
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Existed++
+		}
+
+		if opts.Tag != "" {
+			if err := c.AddTag(page.UUID, opts.Tag); err != nil {
+				result.Errors = append(result.Errors, CSVRowError{Row: line, Name: fullName, Error: fmt.Sprintf("page created but tagging failed: %v", err)})
 			}
-			content = strings.ReplaceAll(content, target, replacement)
-			
-			// Update Properties
-			for k, v := range properties {
-				if str, ok := v.(string); ok && strings.Contains(str, target) {
-					properties[k] = strings.ReplaceAll(str, target, replacement)
+		}
+	}
+
+	return result, nil
+}
+
+// Block Methods
+
+func (c *Client) GetBlock(uuid string) (*Block, error) {
+	resp, err := c.Call("logseq.Editor.getBlock", uuid, true) // include children
+	if err != nil {
+		return nil, err
+	}
+	if string(resp) == "null" {
+		if c.logger != nil {
+			c.logger.Debug("GetBlock returned null", zap.String("uuid", uuid))
+		}
+		return nil, nil
+	}
+	var block Block
+	if err := json.Unmarshal(resp, &block); err != nil {
+		return nil, fmt.Errorf("failed to parse block: %w", err)
+	}
+	// Debug: check properties
+	if c.logger != nil {
+		// Log properties map keys only to reduce noise? No, full properties.
+		// c.logger.Debug("GetBlock properties", zap.String("uuid", uuid), zap.Any("props", block.Properties))
+	}
+	return &block, nil
+}
+
+// GetCurrentBlock returns the block being edited in Logseq, or nil if no block is focused
+func (c *Client) GetCurrentBlock() (*Block, error) {
+	resp, err := c.Call("logseq.Editor.getCurrentBlock")
+	if err != nil {
+		return nil, err
+	}
+	if string(resp) == "null" {
+		return nil, nil
+	}
+	var block Block
+	if err := json.Unmarshal(resp, &block); err != nil {
+		return nil, fmt.Errorf("failed to parse current block: %w", err)
+	}
+	return &block, nil
+}
+
+// GetCurrentPage returns the page open in Logseq's main view, or nil if none is (e.g. on the journals or all-pages view)
+func (c *Client) GetCurrentPage() (*Page, error) {
+	resp, err := c.Call("logseq.Editor.getCurrentPage")
+	if err != nil {
+		return nil, err
+	}
+	if string(resp) == "null" {
+		return nil, nil
+	}
+	var page Page
+	if err := json.Unmarshal(resp, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse current page: %w", err)
+	}
+	return &page, nil
+}
+
+// OpenPage navigates the Logseq UI to a page. It returns nil without navigating if the page does not exist.
+func (c *Client) OpenPage(nameOrUUID string) (*Page, error) {
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil || page == nil {
+		return nil, err
+	}
+	if _, err := c.Call("logseq.App.pushState", "page", map[string]any{"name": page.Name}); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ScrollToBlock opens the page of a block in the Logseq UI and scrolls to the block.
+// It returns the page, or nil without navigating if the block does not exist.
+func (c *Client) ScrollToBlock(uuid string) (*Page, error) {
+	block, err := c.GetBlock(uuid)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	resp, err := c.Call("logseq.Editor.getPage", block.Page.ID)
+	if err != nil {
+		return nil, err
+	}
+	var page Page
+	if err := json.Unmarshal(resp, &page); err != nil || page.Name == "" {
+		return nil, fmt.Errorf("failed to resolve page of block %s", uuid)
+	}
+	if _, err := c.Call("logseq.Editor.scrollToBlockInPage", page.Name, block.UUID); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetBlocks fetches several blocks, preserving the input order. Blocks that do not exist are nil.
+func (c *Client) GetBlocks(uuids []string) ([]*Block, error) {
+	blocks := make([]*Block, len(uuids))
+	err := c.forEach(len(uuids), func(i int) error {
+		block, err := c.GetBlock(uuids[i])
+		if err != nil {
+			return fmt.Errorf("failed to get block %s: %w", uuids[i], err)
+		}
+		blocks[i] = block
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// forEach calls fn for 0..n-1 with at most c.concurrency calls in flight and returns the error of the lowest failing index
+func (c *Client) forEach(n int, fn func(i int) error) error {
+	for _, err := range RunBatch(n, c.concurrency, fn) {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBlockChildren returns the direct children of a block in order. Leaf blocks yield an empty slice.
+func (c *Client) GetBlockChildren(uuid string) ([]Block, error) {
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found: %s", uuid)
+	}
+
+	children := []Block{}
+	for _, raw := range block.Children {
+		switch child := raw.(type) {
+		case map[string]any:
+			childBytes, _ := json.Marshal(child)
+			var b Block
+			if err := json.Unmarshal(childBytes, &b); err == nil && b.UUID != "" {
+				children = append(children, b)
+			}
+		case []any:
+			// Shallow form: ["uuid", "<uuid>"]
+			if len(child) == 2 {
+				if childUUID, ok := child[1].(string); ok {
+					b, err := c.GetBlock(childUUID)
+					if err != nil {
+						return nil, err
+					}
+					if b != nil {
+						children = append(children, *b)
+					}
+				}
+			}
+		}
+	}
+
+	return children, nil
+}
+
+// GetPageBlocksTree returns the top-level blocks of a page with their nested children expanded
+func (c *Client) GetPageBlocksTree(nameOrUUID string) ([]Block, error) {
+	resp, err := c.Call("logseq.Editor.getPageBlocksTree", nameOrUUID)
+	if err != nil {
+		return nil, err
+	}
+	blocks := []Block{}
+	if string(resp) == "null" {
+		return blocks, nil
+	}
+	if err := json.Unmarshal(resp, &blocks); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// NestedBlocks decodes the expanded children of a block returned by getPageBlocksTree
+func NestedBlocks(b Block) []Block {
+	var children []Block
+	for _, raw := range b.Children {
+		if child, ok := raw.(map[string]any); ok {
+			childBytes, _ := json.Marshal(child)
+			var cb Block
+			if err := json.Unmarshal(childBytes, &cb); err == nil {
+				children = append(children, cb)
+			}
+		}
+	}
+	return children
+}
+
+// ReplaceInPage runs a find-and-replace over every block of a page, at any depth, and returns the number of blocks changed.
+// Text inside [[links]] and ((block refs)) is left alone unless opts.IncludeRefs is set.
+func (c *Client) ReplaceInPage(nameOrUUID string, opts ReplaceOptions) (int, error) {
+	if opts.Find == "" {
+		return 0, fmt.Errorf("search text must not be empty")
+	}
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return 0, err
+	}
+	if page == nil {
+		return 0, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+
+	blocks, err := c.GetPageBlocksTree(page.UUID)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for len(blocks) > 0 {
+		var next []Block
+		for _, b := range blocks {
+			if content, n := ReplaceText(b.Content, opts); n > 0 {
+				if _, err := c.UpdateBlock(b.UUID, content, nil); err != nil {
+					return changed, fmt.Errorf("failed to update block %s: %w", b.UUID, err)
+				}
+				changed++
+			}
+			next = append(next, NestedBlocks(b)...)
+		}
+		blocks = next
+	}
+	return changed, nil
+}
+
+// GetPageOutline returns the first line of each block down to maxDepth (1 = top-level blocks only), in document order
+func (c *Client) GetPageOutline(nameOrUUID string, maxDepth int) ([]OutlineEntry, error) {
+	if maxDepth < 1 {
+		maxDepth = 1
+	}
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+
+	blocks, err := c.GetPageBlocksTree(page.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	outline := []OutlineEntry{}
+	var walk func([]Block, int)
+	walk = func(blocks []Block, depth int) {
+		for _, b := range blocks {
+			title, _, _ := strings.Cut(b.Content, "\n")
+			outline = append(outline, OutlineEntry{UUID: b.UUID, Title: strings.TrimSpace(title), Depth: depth})
+			if depth < maxDepth {
+				walk(NestedBlocks(b), depth+1)
+			}
+		}
+	}
+	walk(blocks, 1)
+	return outline, nil
+}
+
+// GetPageAssets returns the assets referenced from the blocks of a page (see ExtractAssetRefs), in document order.
+// Local references are resolved against the graph directory when the graph path is available.
+func (c *Client) GetPageAssets(nameOrUUID string) ([]PageAsset, error) {
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+
+	blocks, err := c.GetPageBlocksTree(page.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	graphPath := ""
+	if graph, err := c.GetGraph(); err == nil {
+		graphPath = graph.Path
+	} else if c.logger != nil {
+		c.logger.Warn("Could not get the graph path, asset paths stay unresolved", zap.Error(err))
+	}
+
+	assets := []PageAsset{}
+	seen := make(map[string]bool)
+	var walk func([]Block)
+	walk = func(blocks []Block) {
+		for _, b := range blocks {
+			for _, ref := range ExtractAssetRefs(b.Content) {
+				if seen[ref] {
+					continue
+				}
+				seen[ref] = true
+				asset := PageAsset{Ref: ref, Block: b.UUID}
+				if !strings.Contains(ref, "://") {
+					if path, err := ResolveAssetPath(graphPath, ref); err == nil {
+						asset.Path = path
+					}
+				}
+				assets = append(assets, asset)
+			}
+			walk(NestedBlocks(b))
+		}
+	}
+	walk(blocks)
+	return assets, nil
+}
+
+// CollapsePage collapses or expands every block at the given outline depth of a page
+// (1 = top-level blocks) and returns how many blocks were toggled.
+func (c *Client) CollapsePage(nameOrUUID string, collapsed bool, depth int) (int, error) {
+	if depth < 1 {
+		depth = 1
+	}
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return 0, err
+	}
+	if page == nil {
+		return 0, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+
+	blocks, err := c.GetPageBlocksTree(page.UUID)
+	if err != nil {
+		return 0, err
+	}
+	for level := 1; level < depth; level++ {
+		var next []Block
+		for _, b := range blocks {
+			next = append(next, NestedBlocks(b)...)
+		}
+		blocks = next
+	}
+
+	count := 0
+	for _, b := range blocks {
+		if err := c.SetBlockCollapsed(b.UUID, collapsed); err != nil {
+			return count, fmt.Errorf("failed to toggle block %s: %w", b.UUID, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// GetPageSize counts the blocks, words and characters of a page's content
+func (c *Client) GetPageSize(nameOrUUID string) (*PageSize, error) {
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+
+	blocks, err := c.GetPageBlocksTree(page.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	size := &PageSize{}
+	var walk func([]Block)
+	walk = func(blocks []Block) {
+		for _, b := range blocks {
+			size.BlockCount++
+			size.WordCount += len(strings.Fields(b.Content))
+			size.CharCount += utf8.RuneCountInString(b.Content)
+			walk(NestedBlocks(b))
+		}
+	}
+	walk(blocks)
+	return size, nil
+}
+
+// MoveBlock moves a block next to (sibling) or under the target block
+func (c *Client) MoveBlock(uuid string, targetUUID string, sibling bool, before bool) error {
+	_, err := c.Call("logseq.Editor.moveBlock", uuid, targetUUID, map[string]any{
+		"before":   before,
+		"children": !sibling,
+	})
+	c.invalidatePageCache() // Moving a page's first block changes which block holds its properties
+	return err
+}
+
+// SetBlockCollapsed collapses or expands a single block
+func (c *Client) SetBlockCollapsed(uuid string, collapsed bool) error {
+	_, err := c.Call("logseq.Editor.setBlockCollapsed", uuid, collapsed)
+	return err
+}
+
+// MoveBlocks applies the moves strictly in order, since a later move may target a block moved earlier.
+// It returns the number of successful moves and one error per failed operation, naming its index.
+func (c *Client) MoveBlocks(ops []MoveOperation) (int, []error) {
+	count := 0
+	var errs []error
+	for i, op := range ops {
+		if err := c.MoveBlock(op.UUID, op.TargetUUID, op.Sibling, op.Before); err != nil {
+			if c.logger != nil {
+				c.logger.Error("MoveBlocks operation failed", zap.Int("index", i), zap.String("uuid", op.UUID), zap.Error(err))
+			}
+			errs = append(errs, fmt.Errorf("operation %d (%s): %w", i, op.UUID, err))
+			continue
+		}
+		count++
+	}
+	return count, errs
+}
+
+// ReorderBlock moves a block one position "up" or "down" among its siblings.
+// It reports false without moving when the block is already first or last.
+func (c *Client) ReorderBlock(uuid string, direction string) (bool, error) {
+	if direction != "up" && direction != "down" {
+		return false, fmt.Errorf("invalid direction '%s', must be 'up' or 'down'", direction)
+	}
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return false, err
+	}
+	if block == nil {
+		return false, fmt.Errorf("block not found: %s", uuid)
+	}
+
+	siblings, err := c.getSiblings(block)
+	if err != nil {
+		return false, err
+	}
+	idx := -1
+	for i, b := range siblings {
+		if b.UUID == block.UUID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return false, fmt.Errorf("block %s not found among its parent's children", uuid)
+	}
+
+	if direction == "up" {
+		if idx == 0 {
+			return false, nil
+		}
+		return true, c.MoveBlock(block.UUID, siblings[idx-1].UUID, true, true)
+	}
+	if idx == len(siblings)-1 {
+		return false, nil
+	}
+	return true, c.MoveBlock(block.UUID, siblings[idx+1].UUID, true, false)
+}
+
+// GetBlockContext returns the block together with up to radius preceding and following siblings, in order.
+// The window is clamped at the first and last sibling.
+func (c *Client) GetBlockContext(uuid string, radius int) ([]Block, error) {
+	if radius < 0 {
+		return nil, fmt.Errorf("invalid radius %d, must not be negative", radius)
+	}
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found: %s", uuid)
+	}
+
+	siblings, err := c.getSiblings(block)
+	if err != nil {
+		return nil, err
+	}
+	idx := -1
+	for i, b := range siblings {
+		if b.UUID == block.UUID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil, fmt.Errorf("block %s not found among its parent's children", uuid)
+	}
+
+	start := max(idx-radius, 0)
+	end := min(idx+radius+1, len(siblings))
+	return siblings[start:end], nil
+}
+
+// getSiblings returns the children of the block's parent (the page's top-level blocks for top-level blocks), in order
+func (c *Client) getSiblings(block *Block) ([]Block, error) {
+	if block.Parent.ID != 0 && block.Parent.ID == block.Page.ID {
+		pageUUID := block.Page.UUID
+		if pageUUID == "" {
+			resp, err := c.Call("logseq.Editor.getPage", block.Page.ID)
+			if err != nil {
+				return nil, err
+			}
+			var page Page
+			if err := json.Unmarshal(resp, &page); err != nil || page.UUID == "" {
+				return nil, fmt.Errorf("failed to resolve page of block %s", block.UUID)
+			}
+			pageUUID = page.UUID
+		}
+		return c.GetPageBlocksTree(pageUUID)
+	}
+
+	parentUUID := block.Parent.UUID
+	if parentUUID == "" {
+		resp, err := c.Call("logseq.Editor.getBlock", block.Parent.ID)
+		if err != nil {
+			return nil, err
+		}
+		var parent Block
+		if err := json.Unmarshal(resp, &parent); err != nil || parent.UUID == "" {
+			return nil, fmt.Errorf("failed to resolve parent of block %s", block.UUID)
+		}
+		parentUUID = parent.UUID
+	}
+	return c.GetBlockChildren(parentUUID)
+}
+
+// Tag Methods (Text-based #Tag)
+
+// errNoContentBlock is returned by getEntityBlock for a page that has no blocks yet
+var errNoContentBlock = errors.New("failed to find content block")
+
+func (c *Client) getEntityBlock(uuid string) (*Block, error) {
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if block != nil {
+		return block, nil
+	}
+
+	// Try as page
+	page, err := c.GetPage(uuid)
+	if err != nil || page == nil {
+		return nil, fmt.Errorf("entity not found: %s", uuid)
+	}
+
+	// Try to find block by UUID which matches page UUID for page properties block
+	block, err = c.GetBlock(page.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if block != nil {
+		return block, nil
+	}
+
+	// Try fetching page blocks tree and take the first block
+	blocksRaw, err := c.Call("logseq.Editor.getPageBlocksTree", page.UUID)
+	if err == nil && string(blocksRaw) != "null" && string(blocksRaw) != "[]" {
+		var bTree []Block
+		if err := json.Unmarshal(blocksRaw, &bTree); err == nil && len(bTree) > 0 {
+			return &bTree[0], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w for page: %s", errNoContentBlock, uuid)
+}
+
+// GetTags returns the tags applied to a block or page: #tags in its content plus the values of its tags:: property, deduped
+func (c *Client) GetTags(uuid string) ([]string, error) {
+	block, err := c.getEntityBlock(uuid)
+	if err != nil {
+		if errors.Is(err, errNoContentBlock) {
+			return []string{}, nil // An empty page has no tags
+		}
+		return nil, err
+	}
+
+	tags := []string{}
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		tag = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(tag), "[["), "]]"))
+		tag = strings.TrimPrefix(tag, "#")
+		if tag != "" && !seen[strings.ToLower(tag)] {
+			seen[strings.ToLower(tag)] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, line := range strings.Split(block.Content, "\n") {
+		if value, ok := propertyLineValue(line, "tags", c.blockFormat(block)); ok {
+			for _, tag := range strings.Split(value, ",") {
+				add(tag)
+			}
+		}
+	}
+	switch v := block.Properties["tags"].(type) {
+	case []any:
+		for _, tag := range v {
+			add(fmt.Sprint(tag))
+		}
+	case string:
+		for _, tag := range strings.Split(v, ",") {
+			add(tag)
+		}
+	}
+	for _, tag := range ExtractTags(block.Content) {
+		add(tag)
+	}
+	return tags, nil
+}
+
+func (c *Client) AddTag(uuid string, tag string) error {
+	return c.AddTags(uuid, []string{tag})
+}
+
+// AddTags appends all missing #tags to the entity's block in a single update
+func (c *Client) AddTags(uuid string, tags []string) error {
+	block, err := c.getEntityBlock(uuid)
+	if err != nil {
+		// If block not found, try to append an empty block if it's a page
+		if errors.Is(err, errNoContentBlock) {
+			block, err = c.AppendBlockInPage(uuid, "", nil)
+			if err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+	}
+
+	present := make(map[string]bool)
+	for _, existing := range ExtractTags(block.Content) {
+		present[strings.ToLower(existing)] = true
+	}
+
+	newContent := strings.TrimSpace(block.Content)
+	for _, tag := range tags {
+		cleanTag := strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		cleanTag = strings.TrimSuffix(strings.TrimPrefix(cleanTag, "[["), "]]")
+		if cleanTag == "" || present[strings.ToLower(cleanTag)] {
+			continue // Already tagged
+		}
+		present[strings.ToLower(cleanTag)] = true
+		tagStr := "#" + cleanTag
+		if strings.ContainsAny(cleanTag, " \t") {
+			tagStr = "#[[" + cleanTag + "]]"
+		}
+		newContent = strings.TrimSpace(newContent + " " + tagStr)
+	}
+
+	if newContent == strings.TrimSpace(block.Content) {
+		return nil
+	}
+
+	_, err = c.UpdateBlock(block.UUID, newContent, nil)
+	return err
+}
+
+func (c *Client) RemoveTag(uuid string, tag string) error {
+	_, err := c.RemoveTags(uuid, []string{tag})
+	return err
+}
+
+// RemoveTags strips several #tags from the entity's block in a single update and
+// returns how many of them were actually present.
+func (c *Client) RemoveTags(uuid string, tags []string) (int, error) {
+	block, err := c.getEntityBlock(uuid)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	newContent := block.Content
+	for _, tag := range tags {
+		cleanTag := strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if cleanTag == "" {
+			continue
+		}
+		cleanTag = strings.TrimSuffix(strings.TrimPrefix(cleanTag, "[["), "]]")
+		var ok bool
+		if newContent, ok = removeTag(newContent, cleanTag); ok {
+			removed++
+		}
+	}
+
+	if removed == 0 {
+		return 0, nil
+	}
+	newContent = strings.TrimSpace(newContent)
+
+	if _, err := c.UpdateBlock(block.UUID, newContent, nil); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// AliasProperty is the page property holding alternate names of a page
+const AliasProperty = "alias"
+
+// GetAliases returns the alias:: values of a page
+func (c *Client) GetAliases(nameOrUUID string) ([]string, error) {
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+	return splitPropertyList(page.Properties[AliasProperty]), nil
+}
+
+// AddAlias appends an alias to the page's alias:: property, keeping the existing ones, and returns the new list.
+// Adding an alias the page already has is a no-op.
+func (c *Client) AddAlias(nameOrUUID string, alias string) ([]string, error) {
+	alias = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(alias), "[["), "]]"))
+	if err := ValidatePageName(alias); err != nil {
+		return nil, err
+	}
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+
+	aliases := splitPropertyList(page.Properties[AliasProperty])
+	for _, a := range aliases {
+		if strings.EqualFold(a, alias) {
+			return aliases, nil
+		}
+	}
+	aliases = append(aliases, alias)
+	if err := c.UpsertProperty(page.UUID, AliasProperty, strings.Join(aliases, ", ")); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// RemoveAlias drops an alias from the page's alias:: property and returns the remaining list.
+// The property is removed once no alias is left. The bool reports whether the alias was present.
+func (c *Client) RemoveAlias(nameOrUUID string, alias string) ([]string, bool, error) {
+	alias = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(alias), "[["), "]]"))
+	page, err := c.GetPage(nameOrUUID)
+	if err != nil {
+		return nil, false, err
+	}
+	if page == nil {
+		return nil, false, fmt.Errorf("page not found: %s", nameOrUUID)
+	}
+
+	aliases := splitPropertyList(page.Properties[AliasProperty])
+	remaining := []string{}
+	for _, a := range aliases {
+		if !strings.EqualFold(a, alias) {
+			remaining = append(remaining, a)
+		}
+	}
+	if len(remaining) == len(aliases) {
+		return aliases, false, nil
+	}
+
+	if len(remaining) == 0 {
+		err = c.RemoveProperty(page.UUID, AliasProperty)
+	} else {
+		err = c.UpsertProperty(page.UUID, AliasProperty, strings.Join(remaining, ", "))
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return remaining, true, nil
+}
+
+// PreviewLinkedPages reports which [[linked]] pages in content and property values already exist and which
+// EnsureLinkedPages would create, expanding namespaced links into their parent pages. Nothing is created.
+func (c *Client) PreviewLinkedPages(content string, properties map[string]any) (*LinkPreview, error) {
+	links := extractLinks(content)
+	for _, v := range properties {
+		if str, ok := v.(string); ok {
+			links = append(links, extractLinks(str)...)
+		}
+	}
+
+	preview := &LinkPreview{Existing: []string{}, WouldCreate: []string{}}
+	seen := make(map[string]bool)
+	for _, link := range links {
+		currentPath := ""
+		for _, part := range strings.Split(link, "/") {
+			if currentPath == "" {
+				currentPath = part
+			} else {
+				currentPath = currentPath + "/" + part
+			}
+			key := strings.ToLower(currentPath)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			page, err := c.GetPage(currentPath)
+			if err != nil {
+				return nil, err
+			}
+			if page != nil {
+				preview.Existing = append(preview.Existing, currentPath)
+			} else {
+				preview.WouldCreate = append(preview.WouldCreate, currentPath)
+			}
+		}
+	}
+	return preview, nil
+}
+
+func (c *Client) EnsureLinkedPages(content string, properties map[string]any) string {
+	// 1. From Content
+	links := extractLinks(content)
+	
+	// 2. From Properties (values)
+	for _, v := range properties {
+		if str, ok := v.(string); ok {
+			propLinks := extractLinks(str)
+			links = append(links, propLinks...)
+		}
+	}
+	
+	// 3. Ensure each page exists (handling namespaces)
+	// Deduplicate first
+	uniqueLinks := make(map[string]bool)
+	var linkList []string
+	for _, l := range links {
+		if !uniqueLinks[l] {
+			uniqueLinks[l] = true
+			linkList = append(linkList, l)
+		}
+	}
+
+	var missing []string
+	for _, link := range linkList {
+		// Handle hierarchy: if "A/B/C", ensure "A", then "A/B", then "A/B/C"
+		parts := strings.Split(link, "/")
+		currentPath := ""
+		
+		// If it's a namespaced page, we need its final UUID for replacement
+		var finalUUID string
+		
+		for i, part := range parts {
+			if currentPath == "" {
+				currentPath = part
+			} else {
+				currentPath = currentPath + "/" + part
+			}
+			
+			// Check if exists
+			if c.logger != nil {
+				c.logger.Debug("Checking linked page existence", zap.String("page", currentPath))
+			}
+			
+			page, err := c.GetPage(currentPath)
+			if err == nil && page != nil {
+				if i == len(parts)-1 {
+					finalUUID = page.UUID
+				}
+				continue // Exists
+			}
+			
+			if c.noAutoCreateLinks {
+				missing = append(missing, currentPath)
+				continue
+			}
+
+			// Not found -> Create
+			if c.logger != nil {
+				c.logger.Info("Auto-creating missing linked page/namespace", zap.String("page", currentPath))
+			}
+			
+			created, err := c.CreatePage(currentPath, nil, nil)
+			if err != nil {
+				if c.logger != nil {
+					c.logger.Error("Failed to auto-create linked page", zap.String("page", currentPath), zap.Error(err))
+				}
+			} else if created != nil && i == len(parts)-1 {
+				finalUUID = created.UUID
+			}
+		}
+		
+		// 4. Replace namespaced links with UUID refs in content
+		// User requirement: "for linking in blocks to pages that are in a namesapce we ALWAYS use a uuid"
+		if strings.Contains(link, "/") && finalUUID != "" {
+			// Replace [[Name/Space]] with ((UUID))
+			// Be careful with replacement to avoid partial matches if possible, but [[...]] is specific.
+			// extractLinks returns the name inside [[...]].
+			target := "[[" + link + "]]"
+			replacement := "((" + finalUUID + "))"
+			if c.logger != nil {
+				c.logger.Debug("Replacing namespaced link with UUID", zap.String("original", target), zap.String("replacement", replacement))
+			}
+			content = strings.ReplaceAll(content, target, replacement)
+			
+			// Update Properties
+			for k, v := range properties {
+				if str, ok := v.(string); ok && strings.Contains(str, target) {
+					properties[k] = strings.ReplaceAll(str, target, replacement)
+				}
+			}
+		}
+	}
+
+	if len(missing) > 0 && c.logger != nil {
+		c.logger.Warn("Linked pages do not exist and were not created", zap.Strings("pages", missing))
+	}
+
+	// 5. Verify Block Refs ((uuid))
+	// We just check if they exist and log warning if not
+	refs := extractBlockRefs(content)
+	for _, v := range properties {
+		if str, ok := v.(string); ok {
+			propRefs := extractBlockRefs(str)
+			refs = append(refs, propRefs...)
+		}
+	}
+
+	for _, uuid := range refs {
+		block, err := c.GetBlock(uuid)
+		if err != nil || block == nil {
+			if c.logger != nil {
+				c.logger.Warn(" Referenced block not found", zap.String("uuid", uuid))
+			}
+		}
+	}
+	
+	return content
+}
+
+// ErrRefTargetNotFound is returned when a block reference would point to a block that does not exist
+var ErrRefTargetNotFound = errors.New("referenced block not found")
+
+// InsertBlockRef inserts a block whose content is ((refUUID)), after checking that the referenced block exists
+func (c *Client) InsertBlockRef(parentUUID string, refUUID string, options map[string]any) (*Block, error) {
+	target, err := c.GetBlock(refUUID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRefTargetNotFound, refUUID)
+	}
+	return c.InsertBlock(parentUUID, "(("+target.UUID+"))", nil, options)
+}
+
+// Embed types accepted by InsertEmbed
+const (
+	EmbedBlock = "block"
+	EmbedPage  = "page"
+)
+
+// InsertEmbed inserts a block embedding another block ({{embed ((uuid))}}) or page ({{embed [[Page]]}}).
+// The target must exist. The content bypasses link handling, so a namespaced page stays a [[link]]
+// instead of being rewritten to a ((uuid)) ref, which Logseq would embed as a block.
+func (c *Client) InsertEmbed(parentUUID string, target string, embedType string, options map[string]any) (*Block, error) {
+	var content string
+	switch embedType {
+	case EmbedBlock:
+		block, err := c.GetBlock(target)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRefTargetNotFound, target)
+		}
+		content = "{{embed ((" + block.UUID + "))}}"
+	case EmbedPage:
+		page, err := c.GetPage(target)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			return nil, fmt.Errorf("%w: %s", ErrRefTargetNotFound, target)
+		}
+		name := page.OriginalName
+		if name == "" {
+			name = page.Name
+		}
+		content = "{{embed [[" + name + "]]}}"
+	default:
+		return nil, fmt.Errorf("invalid embed type '%s', must be '%s' or '%s'", embedType, EmbedBlock, EmbedPage)
+	}
+	return c.insertBlock(parentUUID, content, options)
+}
+
+func (c *Client) InsertBlock(parentUUID string, content string, properties map[string]any, options map[string]any) (*Block, error) {
+	// Auto-create linked pages before insertion and update content with UUIDs for namespaces
+	content = c.EnsureLinkedPages(content, properties)
+
+	block, err := c.insertBlock(parentUUID, content, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply properties if provided
+	if len(properties) > 0 {
+		// We use UpdateBlock to apply properties in batch if possible, or loop upsert
+		// UpdateBlock overwrites content, so we pass the same content
+		_, err := c.UpdateBlock(block.UUID, content, properties)
+		if err != nil {
+			if c.logger != nil {
+				c.logger.Error("Failed to apply properties to new block", zap.Error(err))
+			}
+			// Return block anyway, but maybe with error? 
+			// Better to error out so user knows properties failed
+			return block, fmt.Errorf("block created but properties failed: %w", err)
+		}
+		// Refresh block
+		return c.GetBlock(block.UUID)
+	}
+
+	return block, nil
+}
+
+// insertBlock inserts content as is, without the link handling of InsertBlock
+func (c *Client) insertBlock(parentUUID string, content string, options map[string]any) (*Block, error) {
+	args := []any{parentUUID, content}
+	if options != nil {
+		args = append(args, options)
+	}
+
+	resp, err := c.Call("logseq.Editor.insertBlock", args...)
+	c.invalidatePageCache() // The page may be created or gain properties
+	if err != nil {
+		return nil, err
+	}
+	var block Block
+	if err := json.Unmarshal(resp, &block); err != nil {
+		return nil, fmt.Errorf("failed to parse inserted block: %w", err)
+	}
+	return &block, nil
+}
+
+func (c *Client) InsertBatchBlock(parentUUID string, batch []BlockContent, options map[string]any) ([]Block, error) {
+	// Auto-create linked pages for all blocks in batch
+	// This might be recursive for children
+	var scanBlocks func([]BlockContent)
+	scanBlocks = func(blocks []BlockContent) {
+		for i := range blocks {
+			blocks[i].Content = c.EnsureLinkedPages(blocks[i].Content, blocks[i].Properties)
+			if len(blocks[i].Children) > 0 {
+				scanBlocks(blocks[i].Children)
+			}
+		}
+	}
+	scanBlocks(batch)
+
+	// logseq.Editor.insertBatchBlock(parent, batch, options)
+	args := []any{parentUUID, batch}
+	if options != nil {
+		args = append(args, options)
+	}
+	resp, err := c.Call("logseq.Editor.insertBatchBlock", args...)
+	c.invalidatePageCache() // The first block may hold page properties
+	if err != nil {
+		return nil, err
+	}
+	
+	// Returns a list of created blocks (or sometimes null if failed silently, but we check err)
+	var blocks []Block
+	if err := json.Unmarshal(resp, &blocks); err != nil {
+		// Sometimes it might return a list of lists or different structure if only one block?
+		// But insertBatchBlock usually returns array of Blocks.
+		return nil, fmt.Errorf("failed to parse batch blocks: %w", err)
+	}
+	
+	// If options contain properties for specific blocks in the batch, they should have been in the batch structure.
+	// But if we passed global options? `insertBatchBlock` has options for `sibling`, `before`.
+	
+	return blocks, nil
+}
+
+// ApplyTemplate copies the block tree of templatePage under targetUUID, substituting {{var}} placeholders
+func (c *Client) ApplyTemplate(templatePage string, targetUUID string, vars map[string]string) (*TemplateResult, error) {
+	page, err := c.GetPage(templatePage)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil {
+		return nil, fmt.Errorf("template page not found: %s", templatePage)
+	}
+
+	tree, err := c.GetPageBlocksTree(page.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	var toBatch func([]Block) []BlockContent
+	toBatch = func(blocks []Block) []BlockContent {
+		var batch []BlockContent
+		for _, b := range blocks {
+			content := substituteVariables(stripTemplateProperties(b.Content, c.blockFormat(&b)), vars, used)
+			batch = append(batch, BlockContent{Content: content, Children: toBatch(NestedBlocks(b))})
+		}
+		return batch
+	}
+	batch := toBatch(tree)
+	if len(batch) == 0 {
+		return nil, fmt.Errorf("template page is empty: %s", templatePage)
+	}
+
+	blocks, err := c.InsertBatchBlock(targetUUID, batch, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TemplateResult{Blocks: blocks, UsedVariables: []string{}, UnusedVariables: []string{}}
+	for name := range vars {
+		if used[name] {
+			result.UsedVariables = append(result.UsedVariables, name)
+		} else {
+			result.UnusedVariables = append(result.UnusedVariables, name)
+		}
+	}
+	sort.Strings(result.UsedVariables)
+	sort.Strings(result.UnusedVariables)
+	return result, nil
+}
+
+func (c *Client) UpdateBlock(uuid string, content string, properties map[string]any) (*Block, error) {
+	// Auto-create linked pages before update and update content with UUIDs for namespaces
+	content = c.EnsureLinkedPages(content, properties)
+
+	args := []any{uuid, content}
+	if properties != nil {
+		args = append(args, properties)
+	}
+	resp, err := c.Call("logseq.Editor.updateBlock", args...)
+	c.invalidatePageCache() // The block may hold page properties
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Try parsing full block response
+	var block Block
+	if err := json.Unmarshal(resp, &block); err == nil && block.UUID != "" {
+		return &block, nil
+	}
+
+	// 2. Fallback: If response is just a UUID string or doesn't match Block struct, fetch it
+	var respUUID string
+	if err := json.Unmarshal(resp, &respUUID); err == nil && respUUID != "" {
+		return c.GetBlock(respUUID)
+	}
+
+	// 3. Last resort: Return the block with requested UUID and hope it updated (or fetch it)
+	return c.GetBlock(uuid)
+}
+
+// blockFormat returns the block's content format, falling back to the client's default format
+func (c *Client) blockFormat(b *Block) string {
+	if IsBlockFormat(b.Format) {
+		return b.Format
+	}
+	return c.defaultFormat
+}
+
+// GetBlockFormat returns the content format ("markdown" or "org") of a block
+func (c *Client) GetBlockFormat(uuid string) (string, error) {
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return "", err
+	}
+	if block == nil {
+		return "", fmt.Errorf("block not found: %s", uuid)
+	}
+	return c.blockFormat(block), nil
+}
+
+// SetTaskState rewrites the block's leading task marker (TODO, DOING, ...). "none" removes it.
+func (c *Client) SetTaskState(uuid string, state string) (*Block, error) {
+	if !IsTaskState(state) {
+		return nil, fmt.Errorf("invalid task state '%s', must be one of %v", state, TaskStates)
+	}
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found: %s", uuid)
+	}
+
+	newContent := SetTaskMarker(block.Content, state)
+	if newContent == block.Content {
+		return block, nil // Already in the requested state
+	}
+	return c.UpdateBlock(block.UUID, newContent, nil)
+}
+
+// ErrNotATask is returned when a task operation targets a block without a task marker
+var ErrNotATask = errors.New("block is not a task")
+
+// ToggleTask flips a task between done and not done: DONE becomes TODO, any other marker
+// (TODO, DOING, NOW, LATER, ...) becomes DONE. The rest of the content, including property lines, is kept.
+// It returns the updated block and its new marker.
+func (c *Client) ToggleTask(uuid string) (*Block, string, error) {
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return nil, "", err
+	}
+	if block == nil {
+		return nil, "", fmt.Errorf("block not found: %s", uuid)
+	}
+
+	state := "DONE"
+	switch TaskMarker(block.Content) {
+	case "":
+		return nil, "", fmt.Errorf("%w: %s", ErrNotATask, uuid)
+	case "DONE":
+		state = "TODO"
+	}
+	updated, err := c.UpdateBlock(block.UUID, SetTaskMarker(block.Content, state), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return updated, state, nil
+}
+
+// SetSchedule sets the SCHEDULED or DEADLINE timestamp of a block (see SetScheduleLine).
+// date is YYYY-MM-DD; clock is an optional HH:MM time of day.
+func (c *Client) SetSchedule(uuid string, scheduleType string, date string, clock string) (*Block, error) {
+	if scheduleType != ScheduleScheduled && scheduleType != ScheduleDeadline {
+		return nil, fmt.Errorf("invalid schedule type '%s', must be '%s' or '%s'", scheduleType, ScheduleScheduled, ScheduleDeadline)
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", date)
+	}
+	if clock != "" {
+		tod, err := time.Parse("15:04", clock)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time '%s', expected HH:MM", clock)
+		}
+		t = t.Add(time.Duration(tod.Hour())*time.Hour + time.Duration(tod.Minute())*time.Minute)
+	}
+
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found: %s", uuid)
+	}
+
+	newContent := SetScheduleLine(block.Content, scheduleType, t, clock != "")
+	if newContent == block.Content {
+		return block, nil
+	}
+	return c.UpdateBlock(block.UUID, newContent, nil)
+}
+
+// AppendToBlock adds text as a new line at the end of a block's content, keeping everything already there
+func (c *Client) AppendToBlock(uuid string, content string) (*Block, error) {
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found: %s", uuid)
+	}
+
+	newContent := content
+	if block.Content != "" {
+		newContent = block.Content + "\n" + content
+	}
+	return c.UpdateBlock(block.UUID, newContent, nil)
+}
+
+// PrependToBlock adds text at the start of a block's content, either as its own first line
+// or, with sameLine, in front of the existing first line separated by a space (e.g. a marker or timestamp)
+func (c *Client) PrependToBlock(uuid string, content string, sameLine bool) (*Block, error) {
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found: %s", uuid)
+	}
+
+	separator := "\n"
+	if sameLine {
+		separator = " "
+	}
+	newContent := content
+	if block.Content != "" {
+		newContent = content + separator + block.Content
+	}
+	return c.UpdateBlock(block.UUID, newContent, nil)
+}
+
+func (c *Client) DeleteBlock(uuid string) error {
+	_, err := c.Call("logseq.Editor.removeBlock", uuid)
+	c.invalidatePageCache() // The block may hold page properties
+	return err
+}
+
+func (c *Client) AppendBlockInPage(pageName string, content string, options map[string]any) (*Block, error) {
+	// Auto-create linked pages and update content
+	content = c.EnsureLinkedPages(content, nil)
+
+	if c.idempotentAppend {
+		blocks, err := c.GetPageBlocksTree(pageName)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(blocks); n > 0 && blocks[n-1].Content == content {
+			if c.logger != nil {
+				c.logger.Debug("AppendBlockInPage skipped duplicate append", zap.String("page", pageName), zap.String("uuid", blocks[n-1].UUID))
+			}
+			return &blocks[n-1], nil
+		}
+	}
+
+	args := []any{pageName, content}
+	if options != nil {
+		args = append(args, options)
+	}
+	resp, err := c.Call("logseq.Editor.appendBlockInPage", args...)
+	c.invalidatePageCache() // Logseq creates a missing page on append
+	if err != nil {
+		return nil, err
+	}
+	var block Block
+	if err := json.Unmarshal(resp, &block); err != nil {
+		return nil, fmt.Errorf("failed to parse appended block: %w", err)
+	}
+	return &block, nil
+}
+
+// Search
+func (c *Client) Query(datalog string) (any, error) {
+	return c.query(c.timeout, datalog)
+}
+
+// QueryWithTimeout is Query with a caller-chosen deadline, for queries known to be slow.
+// Values <= 0 use the regular timeout.
+func (c *Client) QueryWithTimeout(timeout time.Duration, datalog string) (any, error) {
+	if timeout <= 0 {
+		timeout = c.timeout
+	}
+	return c.query(timeout, datalog)
+}
+
+// graphQuery is Query with the heavy timeout, for queries that scan the whole graph
+func (c *Client) graphQuery(datalog string) (any, error) {
+	return c.query(c.heavyTimeout, datalog)
+}
+
+// ErrQueryAPIDisabled is returned when neither logseq.DB.q nor logseq.DB.datascriptQuery may be called
+var ErrQueryAPIDisabled = errors.New("the Logseq query API (logseq.DB.q and logseq.DB.datascriptQuery) is disabled in this Logseq build; " +
+	"enable it in the HTTP API server settings, or use tools that read pages and blocks directly (e.g. read_block, get_page_outline)")
+
+// disabledMethodRe matches the error Logseq returns for an API method turned off in its settings
+var disabledMethodRe = regexp.MustCompile(`\bAPI method [\w.]+ is disabled\b`)
+
+// isQueryDisabled reports whether err means a query method is unavailable or blocked, as opposed to a failing query
+func isQueryDisabled(err error) bool {
+	if isMissingMethod(err) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden {
+		return true
+	}
+	return disabledMethodRe.MatchString(err.Error())
+}
+
+func (c *Client) query(timeout time.Duration, datalog string) (any, error) {
+	resp, err := c.CallWithTimeout(timeout, "logseq.DB.q", datalog)
+	if err != nil {
+		if !isQueryDisabled(err) {
+			return nil, err
+		}
+		// logseq.DB.q is blocked; datascriptQuery may still be allowed
+		var dsErr error
+		resp, dsErr = c.CallWithTimeout(timeout, "logseq.DB.datascriptQuery", datalog)
+		if dsErr != nil {
+			if isQueryDisabled(dsErr) {
+				if c.logger != nil {
+					c.logger.Warn("Logseq query API is disabled", zap.NamedError("q", err), zap.NamedError("datascriptQuery", dsErr))
+				}
+				return nil, ErrQueryAPIDisabled
+			}
+			return nil, dsErr
+		}
+	}
+
+	// Fallback to datascriptQuery if q returns empty
+	if string(resp) == "[]" || string(resp) == "null" {
+		respDS, err := c.CallWithTimeout(timeout, "logseq.DB.datascriptQuery", datalog)
+		if err == nil && string(respDS) != "[]" && string(respDS) != "null" {
+			resp = respDS
+		}
+	}
+
+	var results any
+	if err := json.Unmarshal(resp, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse query results: %w", err)
+	}
+
+	// Flatten if it's list of lists (common for simple finds)
+	if list, ok := results.([]any); ok {
+		if len(list) > 0 {
+			if _, ok := list[0].([]any); ok {
+				var flat []any
+				for _, item := range list {
+					if sublist, ok := item.([]any); ok && len(sublist) > 0 {
+						flat = append(flat, sublist[0])
+					}
 				}
+				return flat, nil
 			}
 		}
 	}
 
-	// 5. Verify Block Refs ((uuid))
-	// We just check if they exist and log warning if not
-	refs := extractBlockRefs(content)
-	for _, v := range properties {
-		if str, ok := v.(string); ok {
-			propRefs := extractBlockRefs(str)
-			refs = append(refs, propRefs...)
-		}
+	return results, nil
+}
+
+// CountQuery runs a Datalog query with a single aggregate in its :find clause
+// (e.g. [:find (count ?p) :where ...]) and returns the number. No matches count as 0.
+func (c *Client) CountQuery(datalog string) (int, error) {
+	n, err := c.queryScalar(datalog)
+	if err != nil {
+		return 0, fmt.Errorf("count query: %w", err)
 	}
+	return int(n), nil
+}
 
-	for _, uuid := range refs {
-		block, err := c.GetBlock(uuid)
-		if err != nil || block == nil {
-			if c.logger != nil {
-				c.logger.Warn(" Referenced block not found", zap.String("uuid", uuid))
-			}
+// queryScalar runs a query expected to return a single number, e.g. from (count ?x) or (max ?x).
+// An empty result is 0.
+func (c *Client) queryScalar(datalog string) (float64, error) {
+	results, err := c.Query(datalog)
+	if err != nil {
+		return 0, err
+	}
+
+	value := results
+	if list, ok := results.([]any); ok {
+		switch len(list) {
+		case 0:
+			return 0, nil // Aggregates over an empty set return no rows
+		case 1:
+			value = list[0]
+		default:
+			return 0, fmt.Errorf("returned %d rows, expected a single number", len(list))
 		}
 	}
-	
-	return content
+	n, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("did not return a number: %v", results)
+	}
+	return n, nil
 }
 
-func (c *Client) InsertBlock(parentUUID string, content string, properties map[string]any, options map[string]any) (*Block, error) {
-	// Auto-create linked pages before insertion and update content with UUIDs for namespaces
-	content = c.EnsureLinkedPages(content, properties)
+// GetLatestUpdatedAt returns the most recent :block/updated-at in the graph (epoch milliseconds), 0 for an empty graph.
+// It is a cheap way to detect that something in the graph changed.
+func (c *Client) GetLatestUpdatedAt() (int64, error) {
+	n, err := c.queryScalar(`[:find (max ?t) :where [?b :block/updated-at ?t]]`)
+	if err != nil {
+		return 0, fmt.Errorf("latest update query: %w", err)
+	}
+	return int64(n), nil
+}
 
-	args := []any{parentUUID, content}
-	if options != nil {
-		args = append(args, options)
+// CountPagesByTag counts the pages FindPagesByTag would return, without fetching them
+func (c *Client) CountPagesByTag(tag string) (int, error) {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	return c.CountQuery(fmt.Sprintf(`[:find (count-distinct ?p) :where [?t :block/name %q] (or [?p :block/tags ?t] (and [?b :block/page ?p] [?b :block/refs ?t])) [?p :block/name]]`, tag))
+}
+
+// CountPagesByProperty counts pages that have the property key, or, if value is not empty,
+// whose property equals value (compared as strings)
+func (c *Client) CountPagesByProperty(key string, value string) (int, error) {
+	kw, err := propertyKeyword(key)
+	if err != nil {
+		return 0, err
+	}
+	if value == "" {
+		return c.CountQuery(fmt.Sprintf(`[:find (count ?p) :where [?p :block/name] [?p :block/properties ?props] [(get ?props %s)]]`, kw))
 	}
+	return c.CountQuery(fmt.Sprintf(`[:find (count ?p) :where [?p :block/name] [?p :block/properties ?props] [(get ?props %s) ?v] [(str ?v) ?s] [(= ?s %q)]]`, kw, value))
+}
+
+func (c *Client) GetDailyJournal() (any, error) {
+	// 1. Try logseq.App.getTodayJournalPage first
+	// Note: We swallow 500 error here to allow fallback if the method is undefined in this version
+	resp, err := c.Call("logseq.App.getTodayJournalPage")
 	
-	resp, err := c.Call("logseq.Editor.insertBlock", args...)
-	if err != nil {
-		return nil, err
+	if err == nil {
+		if string(resp) != "null" && string(resp) != "[]" {
+			var page Page
+			if err := json.Unmarshal(resp, &page); err == nil && page.UUID != "" {
+				return &page, nil
+			}
+			// Fallback to raw map if unmarshal fails
+			var raw map[string]any
+			if err := json.Unmarshal(resp, &raw); err == nil {
+				return raw, nil
+			}
+		}
 	}
-	var block Block
-	if err := json.Unmarshal(resp, &block); err != nil {
-		return nil, fmt.Errorf("failed to parse inserted block: %w", err)
+
+	// 2. Fallback: Search for pages with specific journal-day match
+	// This avoids the 500 "apply" error if getTodayJournalPage is missing
+	now := c.clock.Now().In(c.location)
+	todayInt := now.Format("20060102")
+	datalog := fmt.Sprintf(`[:find (pull ?p [*]) :where [?p :block/journal-day %s]]`, todayInt)
+
+	if c.logger != nil {
+		c.logger.Debug("GetDailyJournal Fallback Query", zap.String("query", datalog))
 	}
 
-	// Apply properties if provided
-	if len(properties) > 0 {
-		// We use UpdateBlock to apply properties in batch if possible, or loop upsert
-		// UpdateBlock overwrites content, so we pass the same content
-		_, err := c.UpdateBlock(block.UUID, content, properties)
-		if err != nil {
-			if c.logger != nil {
-				c.logger.Error("Failed to apply properties to new block", zap.Error(err))
+	results, err := c.Query(datalog)
+	if err == nil {
+		if list, ok := results.([]any); ok && len(list) > 0 {
+			pageBytes, _ := json.Marshal(list[0])
+			var p Page
+			if err := json.Unmarshal(pageBytes, &p); err == nil && p.UUID != "" {
+				return &p, nil
+			}
+			// Fallback to raw map
+			var raw map[string]any
+			if err := json.Unmarshal(pageBytes, &raw); err == nil {
+				return raw, nil
 			}
-			// Return block anyway, but maybe with error? 
-			// Better to error out so user knows properties failed
-			return &block, fmt.Errorf("block created but properties failed: %w", err)
 		}
-		// Refresh block
-		return c.GetBlock(block.UUID)
 	}
 
-	return &block, nil
+	return nil, nil
 }
 
-func (c *Client) InsertBatchBlock(parentUUID string, batch []BlockContent, options map[string]any) ([]Block, error) {
-	// Auto-create linked pages for all blocks in batch
-	// This might be recursive for children
-	var scanBlocks func([]BlockContent)
-	scanBlocks = func(blocks []BlockContent) {
-		for i := range blocks {
-			blocks[i].Content = c.EnsureLinkedPages(blocks[i].Content, blocks[i].Properties)
-			if len(blocks[i].Children) > 0 {
-				scanBlocks(blocks[i].Children)
-			}
+
+// JournalDateFormat returns the graph's preferred date format for journal page names.
+// It falls back to DefaultJournalDateFormat when the app config is unavailable.
+func (c *Client) JournalDateFormat() string {
+	if configs, err := c.GetUserConfigs(); err == nil {
+		if f, ok := configs["preferredDateFormat"].(string); ok && f != "" {
+			return f
 		}
+	} else if c.logger != nil {
+		c.logger.Debug("Falling back to default journal date format", zap.Error(err))
 	}
-	scanBlocks(batch)
+	return DefaultJournalDateFormat
+}
 
-	// logseq.Editor.insertBatchBlock(parent, batch, options)
-	args := []any{parentUUID, batch}
-	if options != nil {
-		args = append(args, options)
+// JournalPageName returns the name of the journal page for date, in the graph's preferred date format
+func (c *Client) JournalPageName(date time.Time) string {
+	return FormatJournalDate(date, c.JournalDateFormat())
+}
+
+// ErrNotAJournalPage is returned when a journal page name is already taken by a regular page
+var ErrNotAJournalPage = errors.New("page exists but is not a journal page")
+
+// EnsureJournalPage creates the journal page of date with the journal option, so Logseq
+// derives its journal-day from the name, and reports whether it was newly created.
+// A zero date means today in the client's location.
+func (c *Client) EnsureJournalPage(date time.Time) (*Page, bool, error) {
+	if date.IsZero() {
+		date = c.clock.Now().In(c.location)
 	}
-	resp, err := c.Call("logseq.Editor.insertBatchBlock", args...)
+	name := c.JournalPageName(date)
+
+	page, created, err := c.EnsurePage(name, nil, map[string]any{"journal": true})
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	
-	// Returns a list of created blocks (or sometimes null if failed silently, but we check err)
-	var blocks []Block
-	if err := json.Unmarshal(resp, &blocks); err != nil {
-		// Sometimes it might return a list of lists or different structure if only one block?
-		// But insertBatchBlock usually returns array of Blocks.
-		return nil, fmt.Errorf("failed to parse batch blocks: %w", err)
+	if !created && !page.Journal {
+		return nil, false, fmt.Errorf("%w: %s", ErrNotAJournalPage, name)
 	}
-	
-	// If options contain properties for specific blocks in the batch, they should have been in the batch structure.
-	// But if we passed global options? `insertBatchBlock` has options for `sibling`, `before`.
-	
-	return blocks, nil
+	return page, created, nil
 }
 
-func (c *Client) UpdateBlock(uuid string, content string, properties map[string]any) (*Block, error) {
-	// Auto-create linked pages before update and update content with UUIDs for namespaces
-	content = c.EnsureLinkedPages(content, properties)
+// AppendToJournal appends a block to the journal page of date, creating the page if needed.
+// A zero date means today in the client's location.
+func (c *Client) AppendToJournal(date time.Time, content string) (*Block, error) {
+	if date.IsZero() {
+		date = c.clock.Now().In(c.location)
+	}
+	name := c.JournalPageName(date)
 
-	args := []any{uuid, content}
-	if properties != nil {
-		args = append(args, properties)
+	page, _, err := c.EnsurePage(name, nil, map[string]any{"journal": true})
+	if err != nil {
+		return nil, err
 	}
-	resp, err := c.Call("logseq.Editor.updateBlock", args...)
+	return c.AppendBlockInPage(page.Name, content, nil)
+}
+
+func (c *Client) ListPages() ([]Page, error) {
+	// 1. Try getAllPages (more reliable in some environments)
+	resp, err := c.CallWithTimeout(c.heavyTimeout, "logseq.Editor.getAllPages")
+	if err == nil && string(resp) != "null" && string(resp) != "[]" {
+		var pages []Page
+		if err := json.Unmarshal(resp, &pages); err == nil {
+			return pages, nil
+		}
+	}
+
+	// 2. Fallback to Query to find all pages
+	datalog := `[:find (pull ?p [*]) :where [?p :block/name]]`
+	
+	resp, err = c.CallWithTimeout(c.heavyTimeout, "logseq.DB.q", datalog)
 	if err != nil {
 		return nil, err
 	}
 
-	// 1. Try parsing full block response
-	var block Block
-	if err := json.Unmarshal(resp, &block); err == nil && block.UUID != "" {
-		return &block, nil
+	var rawResult []interface{}
+	if err := json.Unmarshal(resp, &rawResult); err != nil {
+		return nil, fmt.Errorf("failed to parse pages: %w", err)
 	}
 
-	// 2. Fallback: If response is just a UUID string or doesn't match Block struct, fetch it
-	var respUUID string
-	if err := json.Unmarshal(resp, &respUUID); err == nil && respUUID != "" {
-		return c.GetBlock(respUUID)
+	var pages []Page
+	for _, item := range rawResult {
+		if list, ok := item.([]interface{}); ok && len(list) > 0 {
+			pageBytes, _ := json.Marshal(list[0])
+			var p Page
+			if err := json.Unmarshal(pageBytes, &p); err == nil {
+				pages = append(pages, p)
+			}
+		} else if itemMap, ok := item.(map[string]interface{}); ok {
+			pageBytes, _ := json.Marshal(itemMap)
+			var p Page
+			if err := json.Unmarshal(pageBytes, &p); err == nil {
+				pages = append(pages, p)
+			}
+		}
 	}
 
-	// 3. Last resort: Return the block with requested UUID and hope it updated (or fetch it)
-	return c.GetBlock(uuid)
+	if pages == nil {
+		return []Page{}, nil
+	}
+	return pages, nil
 }
 
-func (c *Client) DeleteBlock(uuid string) error {
-	_, err := c.Call("logseq.Editor.removeBlock", uuid)
-	return err
-}
+// ExportGraph dumps pages (sorted by name) with their properties and nested block trees.
+// Only the page's own properties are exported, not Logseq attributes such as journalDay.
+// Page trees are fetched in parallel up to the client's concurrency.
+func (c *Client) ExportGraph(opts ExportOptions) (*GraphExport, error) {
+	pages, err := c.ListPages()
+	if err != nil {
+		return nil, err
+	}
 
-func (c *Client) AppendBlockInPage(pageName string, content string, options map[string]any) (*Block, error) {
-	// Auto-create linked pages and update content
-	content = c.EnsureLinkedPages(content, nil)
+	selected := []Page{}
+	for _, p := range pages {
+		if p.UUID == "" || (p.Journal && !opts.IncludeJournals) {
+			continue
+		}
+		selected = append(selected, p)
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
 
-	args := []any{pageName, content}
-	if options != nil {
-		args = append(args, options)
+	total := len(selected)
+	start := min(max(opts.Offset, 0), total)
+	end := total
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, total)
 	}
-	resp, err := c.Call("logseq.Editor.appendBlockInPage", args...)
+	selected = selected[start:end]
+
+	export := &GraphExport{Total: total, Pages: make([]PageExport, len(selected))}
+	err = c.forEach(len(selected), func(i int) error {
+		p := selected[i]
+		tree, err := c.GetPageBlocksTree(p.UUID)
+		if err != nil {
+			return fmt.Errorf("failed to export page %s: %w", p.Name, err)
+		}
+		name := p.OriginalName
+		if name == "" {
+			name = p.Name
+		}
+		export.Pages[i] = PageExport{
+			Name:       name,
+			UUID:       p.UUID,
+			Journal:    p.Journal,
+			Properties: p.ExplicitProperties,
+			Blocks:     exportBlocks(tree),
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	var block Block
-	if err := json.Unmarshal(resp, &block); err != nil {
-		return nil, fmt.Errorf("failed to parse appended block: %w", err)
+	return export, nil
+}
+
+// exportBlocks converts a block tree from getPageBlocksTree into ExportedBlocks
+func exportBlocks(blocks []Block) []ExportedBlock {
+	exported := []ExportedBlock{}
+	for _, b := range blocks {
+		eb := ExportedBlock{UUID: b.UUID, Content: b.Content, Properties: b.Properties}
+		if children := NestedBlocks(b); len(children) > 0 {
+			eb.Children = exportBlocks(children)
+		}
+		exported = append(exported, eb)
 	}
-	return &block, nil
+	return exported
 }
 
-// Search
-func (c *Client) Query(datalog string) (any, error) {
-	resp, err := c.Call("logseq.DB.q", datalog)
+// pageAttributes are page fields Logseq returns next to the properties object. Dumps written
+// before ExportGraph exported only that object carry them as properties; ImportPage drops them.
+var pageAttributes = map[string]bool{
+	"id": true, "uuid": true, "name": true, "originalName": true, "journal?": true, "journalDay": true,
+	"createdAt": true, "updatedAt": true, "file": true, "format": true, "namespace": true,
+	"propertiesTextValues": true, "propertiesOrder": true,
+}
+
+// Statuses reported by ImportPage
+const (
+	ImportCreated = "created" // The page did not exist and was created with its blocks
+	ImportMerged  = "merged"  // The page existed; its exported blocks were appended
+	ImportSkipped = "skipped" // The page existed and was left untouched
+)
+
+// ImportPage recreates an exported page and its block tree. An existing page is left untouched
+// when skipExisting is set, otherwise the exported blocks are appended to it.
+// Blocks get new UUIDs, so ((refs)) to blocks of the dump dangle afterwards; id:: properties
+// are dropped to avoid clashing with blocks that still exist in the target graph.
+func (c *Client) ImportPage(p PageExport, skipExisting bool) (*Page, string, error) {
+	existing, err := c.GetPage(p.Name)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	if existing != nil && skipExisting {
+		return existing, ImportSkipped, nil
+	}
+
+	page, status, blocks := existing, ImportMerged, p.Blocks
+	if page == nil {
+		// The exported blocks are the whole page, so Logseq must not add an empty first block
+		options := map[string]any{"createFirstBlock": false}
+		if p.Journal {
+			options["journal"] = true
+		}
+		props := make(map[string]any, len(p.Properties))
+		for k, v := range p.Properties {
+			if !pageAttributes[k] {
+				props[k] = v
+			}
+		}
+		page, err = c.CreatePage(p.Name, props, options)
+		if err != nil {
+			return nil, "", err
+		}
+		status = ImportCreated
+		// createPage wrote the page properties; don't insert the exported properties block again
+		if len(props) > 0 && len(blocks) > 0 && c.isPropertiesBlock(blocks[0]) {
+			blocks = blocks[1:]
+		}
 	}
 
-	// Fallback to datascriptQuery if q returns empty
-	if string(resp) == "[]" || string(resp) == "null" {
-		respDS, err := c.Call("logseq.DB.datascriptQuery", datalog)
-		if err == nil && string(respDS) != "[]" && string(respDS) != "null" {
-			resp = respDS
+	if batch := c.importBlocks(blocks); len(batch) > 0 {
+		if _, err := c.InsertBatchBlock(page.UUID, batch, nil); err != nil {
+			if status == ImportCreated {
+				return page, status, fmt.Errorf("page %s was created but its blocks could not be inserted: %w", p.Name, err)
+			}
+			return page, status, fmt.Errorf("page %s already existed and the exported blocks could not be appended: %w", p.Name, err)
 		}
 	}
+	return page, status, nil
+}
 
-	var results any
-	if err := json.Unmarshal(resp, &results); err != nil {
-		return nil, fmt.Errorf("failed to parse query results: %w", err)
+// isPropertiesBlock reports whether b only holds property lines, like the first block
+// Logseq keeps page properties in
+func (c *Client) isPropertiesBlock(b ExportedBlock) bool {
+	if len(b.Properties) == 0 || len(b.Children) > 0 {
+		return false
 	}
-
-	// Flatten if it's list of lists (common for simple finds)
-	if list, ok := results.([]any); ok {
-		if len(list) > 0 {
-			if _, ok := list[0].([]any); ok {
-				var flat []any
-				for _, item := range list {
-					if sublist, ok := item.([]any); ok && len(sublist) > 0 {
-						flat = append(flat, sublist[0])
-					}
-				}
-				return flat, nil
+	for _, line := range strings.Split(b.Content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var key string
+		if c.defaultFormat == FormatOrg {
+			if !strings.HasPrefix(line, ":") {
+				return false
 			}
+			key, _, _ = strings.Cut(line[1:], ":")
+		} else {
+			key, _, _ = strings.Cut(line, "::")
+		}
+		if key == "" || key == line || strings.ContainsAny(key, " \t") {
+			return false
 		}
 	}
-
-	return results, nil
+	return true
 }
 
-func (c *Client) GetDailyJournal() (any, error) {
-	// 1. Try logseq.App.getTodayJournalPage first
-	// Note: We swallow 500 error here to allow fallback if the method is undefined in this version
-	resp, err := c.Call("logseq.App.getTodayJournalPage")
-	
-	if err == nil {
-		if string(resp) != "null" && string(resp) != "[]" {
-			var page Page
-			if err := json.Unmarshal(resp, &page); err == nil && page.UUID != "" {
-				return &page, nil
-			}
-			// Fallback to raw map if unmarshal fails
-			var raw map[string]any
-			if err := json.Unmarshal(resp, &raw); err == nil {
-				return raw, nil
+// importBlocks converts exported blocks back into insertable content, dropping id:: lines.
+// Properties stay in the content, which is how Logseq returned them.
+func (c *Client) importBlocks(blocks []ExportedBlock) []BlockContent {
+	var batch []BlockContent
+	for _, b := range blocks {
+		lines := strings.Split(b.Content, "\n")
+		kept := lines[:0]
+		for _, line := range lines {
+			if _, ok := propertyLineValue(line, "id", c.defaultFormat); !ok {
+				kept = append(kept, line)
 			}
 		}
+		batch = append(batch, BlockContent{Content: strings.Join(kept, "\n"), Children: c.importBlocks(b.Children)})
 	}
+	return batch
+}
 
-	// 2. Fallback: Search for pages with specific journal-day match
-	// This avoids the 500 "apply" error if getTodayJournalPage is missing
-	now := time.Now()
-	todayInt := now.Format("20060102")
-	datalog := fmt.Sprintf(`[:find (pull ?p [*]) :where [?p :block/journal-day %s]]`, todayInt)
+// InternalRefs counts the ((uuid)) references in the export that point to blocks of the export itself.
+// These references dangle after ImportPage, since imported blocks get new UUIDs.
+func (e *GraphExport) InternalRefs() int {
+	uuids := make(map[string]bool)
+	var contents []string
+	var walk func([]ExportedBlock)
+	walk = func(blocks []ExportedBlock) {
+		for _, b := range blocks {
+			uuids[b.UUID] = true
+			contents = append(contents, b.Content)
+			walk(b.Children)
+		}
+	}
+	for _, p := range e.Pages {
+		walk(p.Blocks)
+	}
 
-	if c.logger != nil {
-		c.logger.Debug("GetDailyJournal Fallback Query", zap.String("query", datalog))
+	count := 0
+	for _, content := range contents {
+		for _, ref := range extractBlockRefs(content) {
+			if uuids[ref] {
+				count++
+			}
+		}
 	}
+	return count
+}
+
+// GetRecentlyEditedPages returns up to limit pages, most recently edited first
+func (c *Client) GetRecentlyEditedPages(limit int) ([]Page, error) {
+	datalog := `[:find (pull ?p [:db/id :block/uuid :block/name :block/original-name :block/updated-at]) :where [?p :block/name] [?p :block/updated-at]]`
 
 	results, err := c.Query(datalog)
-	if err == nil {
-		if list, ok := results.([]any); ok && len(list) > 0 {
-			pageBytes, _ := json.Marshal(list[0])
+	if err != nil {
+		return nil, err
+	}
+
+	pages := []Page{}
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			pageBytes, _ := json.Marshal(item)
 			var p Page
 			if err := json.Unmarshal(pageBytes, &p); err == nil && p.UUID != "" {
-				return &p, nil
-			}
-			// Fallback to raw map
-			var raw map[string]any
-			if err := json.Unmarshal(pageBytes, &raw); err == nil {
-				return raw, nil
+				pages = append(pages, p)
 			}
 		}
 	}
 
-	return nil, nil
+	sort.SliceStable(pages, func(i, j int) bool { return pages[i].UpdatedAt > pages[j].UpdatedAt })
+	if limit > 0 && len(pages) > limit {
+		pages = pages[:limit]
+	}
+	return pages, nil
 }
 
+// ListAllProperties returns every property key used by a page or block, with its usage count, most used first.
+// Keys are reported as Logseq stores them. Pre-blocks are skipped, since their properties are also on the page.
+func (c *Client) ListAllProperties() ([]PropertyInfo, error) {
+	datalog := `[:find (pull ?b [:db/id :block/properties]) :where [?b :block/properties] (not [?b :block/pre-block? true])]`
 
-func (c *Client) ListPages() ([]Page, error) {
-	// 1. Try getAllPages (more reliable in some environments)
-	resp, err := c.Call("logseq.Editor.getAllPages")
-	if err == nil && string(resp) != "null" && string(resp) != "[]" {
-		var pages []Page
-		if err := json.Unmarshal(resp, &pages); err == nil {
-			return pages, nil
+	results, err := c.graphQuery(datalog)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			entity, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			props, _ := entity["properties"].(map[string]any)
+			for k := range props {
+				counts[k]++
+			}
 		}
 	}
 
-	// 2. Fallback to Query to find all pages
-	datalog := `[:find (pull ?p [*]) :where [?p :block/name]]`
-	
-	resp, err = c.Call("logseq.DB.q", datalog)
+	properties := make([]PropertyInfo, 0, len(counts))
+	for k, n := range counts {
+		properties = append(properties, PropertyInfo{Key: k, Count: n})
+	}
+	sort.Slice(properties, func(i, j int) bool {
+		if properties[i].Count != properties[j].Count {
+			return properties[i].Count > properties[j].Count
+		}
+		return properties[i].Key < properties[j].Key
+	})
+	return properties, nil
+}
+
+// FindDuplicatePages groups pages whose names collide once lowercased and trimmed (e.g. "Tolkien" and " tolkien"),
+// a common source of near-duplicate entities. Only groups with more than one page are returned.
+func (c *Client) FindDuplicatePages() ([][]Page, error) {
+	pages, err := c.ListPages()
 	if err != nil {
 		return nil, err
 	}
 
-	var rawResult []interface{}
-	if err := json.Unmarshal(resp, &rawResult); err != nil {
-		return nil, fmt.Errorf("failed to parse pages: %w", err)
+	groups := make(map[string][]Page)
+	for _, p := range pages {
+		name := p.OriginalName
+		if name == "" {
+			name = p.Name
+		}
+		key := strings.ToLower(strings.Join(strings.Fields(name), " "))
+		if key == "" {
+			continue
+		}
+		groups[key] = append(groups[key], p)
 	}
 
-	var pages []Page
-	for _, item := range rawResult {
-		if list, ok := item.([]interface{}); ok && len(list) > 0 {
-			pageBytes, _ := json.Marshal(list[0])
+	keys := make([]string, 0, len(groups))
+	for key, group := range groups {
+		if len(group) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	duplicates := make([][]Page, 0, len(keys))
+	for _, key := range keys {
+		duplicates = append(duplicates, groups[key])
+	}
+	return duplicates, nil
+}
+
+// FindOrphanPages returns the pages that no block references and whose own blocks reference nothing, sorted by name.
+// Journal pages are included; callers can drop them with IsJournalName.
+func (c *Client) FindOrphanPages() ([]Page, error) {
+	results, err := c.graphQuery(`[:find (pull ?p [:db/id :block/uuid :block/name :block/original-name :block/journal?]) :where [?p :block/name]]`)
+	if err != nil {
+		return nil, err
+	}
+	referenced, err := c.queryIDs(`[:find ?p :where [?b :block/refs ?p] [?p :block/name]]`)
+	if err != nil {
+		return nil, fmt.Errorf("backlink query: %w", err)
+	}
+	linking, err := c.queryIDs(`[:find ?p :where [?b :block/page ?p] [?b :block/refs ?r]]`)
+	if err != nil {
+		return nil, fmt.Errorf("outgoing link query: %w", err)
+	}
+
+	orphans := []Page{}
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			pageBytes, _ := json.Marshal(item)
 			var p Page
-			if err := json.Unmarshal(pageBytes, &p); err == nil {
-				pages = append(pages, p)
+			if err := json.Unmarshal(pageBytes, &p); err != nil || p.UUID == "" {
+				continue
 			}
-		} else if itemMap, ok := item.(map[string]interface{}); ok {
-			pageBytes, _ := json.Marshal(itemMap)
-			var p Page
-			if err := json.Unmarshal(pageBytes, &p); err == nil {
-				pages = append(pages, p)
+			if !referenced[p.ID] && !linking[p.ID] {
+				orphans = append(orphans, p)
 			}
 		}
 	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Name < orphans[j].Name })
+	return orphans, nil
+}
 
-	if pages == nil {
-		return []Page{}, nil
+// queryIDs runs a query whose :find clause is a single entity id and returns the ids as a set
+func (c *Client) queryIDs(datalog string) (map[int]bool, error) {
+	results, err := c.graphQuery(datalog)
+	if err != nil {
+		return nil, err
 	}
-	return pages, nil
+	ids := make(map[int]bool)
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			if id, ok := item.(float64); ok {
+				ids[int(id)] = true
+			}
+		}
+	}
+	return ids, nil
 }
 
 func (c *Client) ListNamespaces() ([]string, error) {
@@ -753,6 +3198,9 @@ func (c *Client) ListNamespaces() ([]string, error) {
 	}
 
 	results, err := c.Query(datalog)
+	if errors.Is(err, ErrQueryAPIDisabled) {
+		return nil, err
+	}
 	if err == nil {
 		if list, ok := results.([]any); ok {
 			for _, res := range list {
@@ -779,5 +3227,270 @@ func (c *Client) ListNamespaces() ([]string, error) {
 	return list, nil
 }
 
+// ListTasks returns every block whose :block/marker is one of states (OpenTaskStates if empty),
+// sorted by page and content
+func (c *Client) ListTasks(states []string) ([]Task, error) {
+	if len(states) == 0 {
+		states = OpenTaskStates
+	}
+	quoted := make([]string, len(states))
+	for i, state := range states {
+		quoted[i] = fmt.Sprintf("%q", strings.ToUpper(state))
+	}
+	datalog := fmt.Sprintf(`[:find (pull ?b [:block/uuid :block/content :block/marker {:block/page [:block/name]}]) :where [?b :block/marker ?m] [(contains? #{%s} ?m)]]`, strings.Join(quoted, " "))
+
+	if c.logger != nil {
+		c.logger.Debug("ListTasks Query", zap.String("query", datalog))
+	}
+
+	results, err := c.graphQuery(datalog)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []Task{}
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			blockBytes, _ := json.Marshal(item)
+			var b struct {
+				Block
+				Marker string `json:"marker"`
+			}
+			if err := json.Unmarshal(blockBytes, &b); err != nil || b.UUID == "" {
+				continue
+			}
+			tasks = append(tasks, Task{UUID: b.UUID, Content: b.Content, Page: b.Page.Name, Marker: b.Marker})
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Page != tasks[j].Page {
+			return tasks[i].Page < tasks[j].Page
+		}
+		return tasks[i].Content < tasks[j].Content
+	})
+	return tasks, nil
+}
+
+// MaxAgendaDays caps the date range GetAgenda accepts
+const MaxAgendaDays = 366
+
+// GetAgenda returns the blocks whose :block/scheduled or :block/deadline day falls within [from, to],
+// grouped by date in ascending order. A block scheduled and due within the range appears under both days.
+func (c *Client) GetAgenda(from, to time.Time) ([]AgendaDay, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid range: %s is before %s", to.Format("2006-01-02"), from.Format("2006-01-02"))
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > MaxAgendaDays {
+		return nil, fmt.Errorf("range of %d days exceeds the maximum of %d", days, MaxAgendaDays)
+	}
+	fromDay, toDay := from.Format("20060102"), to.Format("20060102")
+
+	datalog := fmt.Sprintf(`[:find (pull ?b [:block/uuid :block/content :block/marker :block/scheduled :block/deadline {:block/page [:block/name]}]) :where (or [?b :block/scheduled ?d] [?b :block/deadline ?d]) [(>= ?d %s)] [(<= ?d %s)]]`, fromDay, toDay)
+
+	if c.logger != nil {
+		c.logger.Debug("GetAgenda Query", zap.String("query", datalog))
+	}
+
+	results, err := c.graphQuery(datalog)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string][]AgendaItem)
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			blockBytes, _ := json.Marshal(item)
+			var b struct {
+				Block
+				Marker    string  `json:"marker"`
+				Scheduled float64 `json:"scheduled"`
+				Deadline  float64 `json:"deadline"`
+			}
+			if err := json.Unmarshal(blockBytes, &b); err != nil || b.UUID == "" {
+				continue
+			}
+			for _, entry := range []struct {
+				day  float64
+				kind string
+			}{{b.Scheduled, ScheduleScheduled}, {b.Deadline, ScheduleDeadline}} {
+				date, ok := journalDayToISO(entry.day)
+				if !ok || date < from.Format("2006-01-02") || date > to.Format("2006-01-02") {
+					continue
+				}
+				byDate[date] = append(byDate[date], AgendaItem{UUID: b.UUID, Content: b.Content, Page: b.Page.Name, Marker: b.Marker, Type: entry.kind})
+			}
+		}
+	}
+
+	agenda := []AgendaDay{}
+	for date, items := range byDate {
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].Page != items[j].Page {
+				return items[i].Page < items[j].Page
+			}
+			return items[i].Content < items[j].Content
+		})
+		agenda = append(agenda, AgendaDay{Date: date, Items: items})
+	}
+	sort.Slice(agenda, func(i, j int) bool { return agenda[i].Date < agenda[j].Date })
+	return agenda, nil
+}
+
+// Maintenance Methods
+
+// FindBrokenBlockRefs returns every ((uuid)) reference whose target block no longer exists
+func (c *Client) FindBrokenBlockRefs() ([]BrokenRef, error) {
+	// Only blocks whose content contains a ((...)) ref are candidates
+	datalog := `[:find (pull ?b [* {:block/page [:block/name]}]) :where [?b :block/content ?c] [(clojure.string/includes? ?c "((")]]`
+
+	if c.logger != nil {
+		c.logger.Debug("FindBrokenBlockRefs Query", zap.String("query", datalog))
+	}
+
+	results, err := c.graphQuery(datalog)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache resolution results since the same ref is often used in many blocks
+	resolved := make(map[string]bool)
+	broken := []BrokenRef{}
+
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			blockBytes, _ := json.Marshal(item)
+			var b Block
+			if err := json.Unmarshal(blockBytes, &b); err != nil || b.UUID == "" {
+				continue
+			}
+
+			for _, ref := range extractBlockRefs(b.Content) {
+				exists, seen := resolved[ref]
+				if !seen {
+					target, err := c.GetBlock(ref)
+					if err != nil {
+						return nil, fmt.Errorf("failed to resolve block ref %s: %w", ref, err)
+					}
+					exists = target != nil
+					resolved[ref] = exists
+				}
+				if !exists {
+					broken = append(broken, BrokenRef{
+						BlockUUID: b.UUID,
+						Page:      b.Page.Name,
+						Ref:       ref,
+					})
+				}
+			}
+		}
+	}
+
+	return broken, nil
+}
+
+// ValidateGraph checks the graph's referential integrity in one pass: ((uuid)) refs whose block
+// is gone, [[links]] to pages that do not exist and namespaced pages whose parent namespace page
+// is missing. Every section reports its full count but at most sampleLimit samples.
+func (c *Client) ValidateGraph(sampleLimit int) (*GraphValidationReport, error) {
+	report := &GraphValidationReport{
+		BrokenBlockRefs:  []BrokenRef{},
+		MissingPages:     []MissingPageLink{},
+		OrphanedChildren: []OrphanedNamespaceChild{},
+	}
+
+	broken, err := c.FindBrokenBlockRefs()
+	if err != nil {
+		return nil, fmt.Errorf("broken ref scan: %w", err)
+	}
+	report.BrokenBlockRefCount = len(broken)
+	report.BrokenBlockRefs = broken[:min(len(broken), sampleLimit)]
+
+	// One page scan serves both the link and the namespace checks, instead of a lookup per link
+	results, err := c.graphQuery(`[:find (pull ?p [:block/uuid :block/name :block/original-name]) :where [?p :block/name]]`)
+	if err != nil {
+		return nil, fmt.Errorf("page scan: %w", err)
+	}
+	var pages []Page
+	names := make(map[string]bool)
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			pageBytes, _ := json.Marshal(item)
+			var p Page
+			if err := json.Unmarshal(pageBytes, &p); err != nil || p.Name == "" {
+				continue
+			}
+			pages = append(pages, p)
+			names[strings.ToLower(p.Name)] = true
+		}
+	}
+
+	// Only blocks whose content contains a [[...]] link are candidates
+	results, err = c.graphQuery(`[:find (pull ?b [* {:block/page [:block/name]}]) :where [?b :block/content ?c] [(clojure.string/includes? ?c "[[")]]`)
+	if err != nil {
+		return nil, fmt.Errorf("link scan: %w", err)
+	}
+	if list, ok := results.([]any); ok {
+		for _, item := range list {
+			blockBytes, _ := json.Marshal(item)
+			var b Block
+			if err := json.Unmarshal(blockBytes, &b); err != nil || b.UUID == "" {
+				continue
+			}
+			for _, link := range extractLinks(b.Content) {
+				// Logseq page names are case-insensitive
+				if names[strings.ToLower(link)] {
+					continue
+				}
+				report.MissingPageCount++
+				if len(report.MissingPages) < sampleLimit {
+					report.MissingPages = append(report.MissingPages, MissingPageLink{BlockUUID: b.UUID, Page: b.Page.Name, Link: link})
+				}
+			}
+		}
+	}
+
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Name < pages[j].Name })
+	for i := range pages {
+		parent := parentNamespace(&pages[i])
+		if parent == "" || names[strings.ToLower(parent)] {
+			continue
+		}
+		report.OrphanedChildCount++
+		if len(report.OrphanedChildren) < sampleLimit {
+			name := pages[i].OriginalName
+			if name == "" {
+				name = pages[i].Name
+			}
+			report.OrphanedChildren = append(report.OrphanedChildren, OrphanedNamespaceChild{UUID: pages[i].UUID, Name: name, MissingParent: parent})
+		}
+	}
+
+	report.Valid = report.BrokenBlockRefCount == 0 && report.MissingPageCount == 0 && report.OrphanedChildCount == 0
+	return report, nil
+}
+
+// StripBlockRefs removes the given ((uuid)) references from a block's content
+func (c *Client) StripBlockRefs(uuid string, refs []string) error {
+	block, err := c.GetBlock(uuid)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return fmt.Errorf("block not found: %s", uuid)
+	}
 
+	newContent := block.Content
+	for _, ref := range refs {
+		newContent = strings.ReplaceAll(newContent, "(("+ref+"))", "")
+	}
+	if newContent == block.Content {
+		return nil
+	}
+	newContent = strings.ReplaceAll(newContent, "  ", " ") // Cleanup spaces
+	newContent = strings.TrimSpace(newContent)
 
+	_, err = c.UpdateBlock(block.UUID, newContent, nil)
+	return err
+}
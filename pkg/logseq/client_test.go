@@ -2,9 +2,18 @@ package logseq_test
 
 import (
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/clstb/yalms/pkg/logseq"
 )
@@ -121,6 +130,18 @@ func TestClient_GetGraph_Success(t *testing.T) {
 	}
 }
 
+func TestClient_GetGraph_NoGraphOpen(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+	if _, err := client.GetGraph(); !errors.Is(err, logseq.ErrNoGraphOpen) {
+		t.Errorf("Expected ErrNoGraphOpen, got %v", err)
+	}
+}
+
 func TestClient_RenamePage_Success(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -304,6 +325,274 @@ func TestClient_EnsureLinkedPages_Namespaces(t *testing.T) {
 	}
 }
 
+func TestClient_EnsureLinkedPages_NoAutoCreate(t *testing.T) {
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		methods = append(methods, body.Method)
+		if body.Method == "logseq.Editor.getPage" && body.Args[0] == "Existing" {
+			w.Write([]byte(`{"uuid": "u1", "name": "existing"}`))
+			return
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithoutLinkAutoCreate())
+
+	content := "see [[Existing]], [[Typo]] and [[A/B]]"
+	newContent := client.EnsureLinkedPages(content, map[string]any{"author": "[[Nobody]]"})
+	if newContent != content {
+		t.Errorf("Expected content unchanged, got %s", newContent)
+	}
+	for _, m := range methods {
+		if m == "logseq.Editor.createPage" {
+			t.Fatalf("Expected no createPage calls, got %v", methods)
+		}
+	}
+	if len(methods) == 0 {
+		t.Error("Expected linked pages to be looked up")
+	}
+}
+
+func TestClient_GetPageAssets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"uuid": "p1", "name": "trip"}`))
+		case "logseq.Editor.getPageBlocksTree":
+			w.Write([]byte(`[
+				{"uuid": "b1", "content": "![beach](../assets/beach.jpg)", "children": [
+					{"uuid": "b2", "content": "{{pdf ../assets/tickets.pdf}} and ![beach](../assets/beach.jpg)"}
+				]},
+				{"uuid": "b3", "content": "![map](https://example.com/map.png)"}
+			]`))
+		case "logseq.App.getCurrentGraph":
+			w.Write([]byte(`{"name": "g", "path": "/home/me/graph"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+
+	assets, err := logseq.NewClient(ts.URL, "token", nil).GetPageAssets("trip")
+	if err != nil {
+		t.Fatalf("GetPageAssets failed: %v", err)
+	}
+	expected := []logseq.PageAsset{
+		{Ref: "../assets/beach.jpg", Block: "b1", Path: "/home/me/graph/assets/beach.jpg"},
+		{Ref: "../assets/tickets.pdf", Block: "b2", Path: "/home/me/graph/assets/tickets.pdf"},
+		{Ref: "https://example.com/map.png", Block: "b3"},
+	}
+	if !reflect.DeepEqual(assets, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, assets)
+	}
+}
+
+func TestClient_ListTasks(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query = body.Args[0].(string)
+		w.Write([]byte(`[
+			{"uuid": "t2", "content": "TODO write report", "marker": "TODO", "page": {"name": "work"}},
+			{"uuid": "t1", "content": "DOING call Bob", "marker": "DOING", "page": {"name": "2026-01-18"}}
+		]`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	tasks, err := client.ListTasks(nil)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if !strings.Contains(query, ":block/marker") || !strings.Contains(query, `#{"TODO" "DOING" "NOW" "LATER"}`) {
+		t.Errorf("Expected open markers to be queried by default, got %s", query)
+	}
+	expected := []logseq.Task{
+		{UUID: "t1", Content: "DOING call Bob", Page: "2026-01-18", Marker: "DOING"},
+		{UUID: "t2", Content: "TODO write report", Page: "work", Marker: "TODO"},
+	}
+	if !reflect.DeepEqual(tasks, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, tasks)
+	}
+
+	if _, err := client.ListTasks([]string{"done"}); err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if !strings.Contains(query, `#{"DONE"}`) {
+		t.Errorf("Expected only DONE to be queried, got %s", query)
+	}
+}
+
+func TestClient_GetAgenda(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query = body.Args[0].(string)
+		w.Write([]byte(`[
+			{"uuid": "t1", "content": "TODO report", "marker": "TODO", "scheduled": 20260119, "deadline": 20260121, "page": {"name": "work"}},
+			{"uuid": "t2", "content": "LATER dentist", "marker": "LATER", "scheduled": 20260119, "page": {"name": "home"}},
+			{"uuid": "t3", "content": "TODO taxes", "marker": "TODO", "scheduled": 20251201, "deadline": 20260120, "page": {"name": "home"}}
+		]`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	from := time.Date(2026, 1, 19, 0, 0, 0, 0, time.UTC)
+	agenda, err := client.GetAgenda(from, from.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatalf("GetAgenda failed: %v", err)
+	}
+	if !strings.Contains(query, ":block/scheduled") || !strings.Contains(query, "(>= ?d 20260119)") || !strings.Contains(query, "(<= ?d 20260125)") {
+		t.Errorf("Unexpected agenda query: %s", query)
+	}
+	expected := []logseq.AgendaDay{
+		{Date: "2026-01-19", Items: []logseq.AgendaItem{
+			{UUID: "t2", Content: "LATER dentist", Page: "home", Marker: "LATER", Type: "scheduled"},
+			{UUID: "t1", Content: "TODO report", Page: "work", Marker: "TODO", Type: "scheduled"},
+		}},
+		{Date: "2026-01-20", Items: []logseq.AgendaItem{{UUID: "t3", Content: "TODO taxes", Page: "home", Marker: "TODO", Type: "deadline"}}},
+		{Date: "2026-01-21", Items: []logseq.AgendaItem{{UUID: "t1", Content: "TODO report", Page: "work", Marker: "TODO", Type: "deadline"}}},
+	}
+	if !reflect.DeepEqual(agenda, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, agenda)
+	}
+
+	if _, err := client.GetAgenda(from, from.AddDate(0, 0, -1)); err == nil {
+		t.Error("Expected an error for a reversed range")
+	}
+	if _, err := client.GetAgenda(from, from.AddDate(0, 0, logseq.MaxAgendaDays)); err == nil {
+		t.Error("Expected an error for a range above the cap")
+	}
+}
+
+func TestClient_Query_Disabled(t *testing.T) {
+	dsEnabled := false
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		methods = append(methods, body.Method)
+		switch {
+		case body.Method == "logseq.DB.q":
+			w.Write([]byte(`{"error": "API method logseq.DB.q is disabled"}`))
+		case body.Method == "logseq.DB.datascriptQuery" && dsEnabled:
+			w.Write([]byte(`[[{"uuid": "p1", "name": "projects/alpha"}]]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "MethodNotExist"}`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	_, err := client.GetNamespacePages("projects")
+	if !errors.Is(err, logseq.ErrQueryAPIDisabled) || !strings.Contains(err.Error(), "enable it") {
+		t.Errorf("Expected ErrQueryAPIDisabled with an actionable message, got %v", err)
+	}
+	if strings.Join(methods, ",") != "logseq.DB.q,logseq.DB.datascriptQuery" {
+		t.Errorf("Expected datascriptQuery to be tried after DB.q, got %v", methods)
+	}
+	if _, err := client.ListNamespaces(); !errors.Is(err, logseq.ErrQueryAPIDisabled) {
+		t.Errorf("Expected ListNamespaces to report the disabled API instead of an empty list, got %v", err)
+	}
+
+	dsEnabled = true
+	pages, err := client.GetNamespacePages("projects")
+	if err != nil || len(pages) != 1 {
+		t.Errorf("Expected the datascriptQuery fallback to answer, got %v, %v", pages, err)
+	}
+}
+
+func TestClient_Query_ErrorNotDisabled(t *testing.T) {
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		methods = append(methods, body.Method)
+		w.Write([]byte(`{"error": "Query error: nested :find is not allowed"}`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	_, err := client.Query(`[:find ?p :where [?p :block/name]]`)
+	if err == nil || errors.Is(err, logseq.ErrQueryAPIDisabled) || !strings.Contains(err.Error(), "nested :find") {
+		t.Errorf("Expected the query error to be returned as is, got %v", err)
+	}
+	if strings.Join(methods, ",") != "logseq.DB.q" {
+		t.Errorf("Expected no datascriptQuery retry for a failing query, got %v", methods)
+	}
+}
+
+func TestClient_GetParentNamespace(t *testing.T) {
+	pages := map[string]string{
+		"a/b/c":  `{"uuid": "c-uuid", "name": "a/b/c", "originalName": "A/B/C"}`,
+		"c-uuid": `{"uuid": "c-uuid", "name": "a/b/c", "originalName": "A/B/C"}`,
+		"a/b":    `{"uuid": "b-uuid", "name": "a/b", "originalName": "A/B"}`,
+		"top":    `{"uuid": "t-uuid", "name": "top", "originalName": "Top"}`,
+		"x/y":    `{"uuid": "y-uuid", "name": "x/y", "originalName": "X/Y"}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.getPage" {
+			if page, ok := pages[strings.ToLower(body.Args[0].(string))]; ok {
+				w.Write([]byte(page))
+				return
+			}
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	for _, nameOrUUID := range []string{"A/B/C", "c-uuid"} {
+		name, parent, err := client.GetParentNamespace(nameOrUUID)
+		if err != nil || name != "A/B" || parent == nil || parent.UUID != "b-uuid" {
+			t.Errorf("GetParentNamespace(%s) = %q, %+v, %v; want A/B", nameOrUUID, name, parent, err)
+		}
+	}
+
+	if name, parent, err := client.GetParentNamespace("Top"); err != nil || name != "" || parent != nil {
+		t.Errorf("Expected no parent for a top-level page, got %q, %+v, %v", name, parent, err)
+	}
+	if name, parent, err := client.GetParentNamespace("X/Y"); err != nil || name != "X" || parent != nil {
+		t.Errorf("Expected a missing parent page to be reported by name only, got %q, %+v, %v", name, parent, err)
+	}
+	if _, _, err := client.GetParentNamespace("Missing/Page"); err == nil {
+		t.Error("Expected an error for a missing page")
+	}
+}
+
 func TestClient_InsertBlock_WithProperties(t *testing.T) {
 	callCount := 0
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -502,3 +791,1989 @@ func TestClient_Call_BusinessError(t *testing.T) {
 		t.Errorf("Expected business error, got: %v", err)
 	}
 }
+
+func TestClient_FindBrokenBlockRefs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.DB.q":
+			w.Write([]byte(`[[{"uuid": "b1", "content": "see ((ok)) and ((gone))", "page": {"name": "p1"}}], [{"uuid": "b2", "content": "also ((gone))", "page": {"id": 7}}]]`))
+		case "logseq.Editor.getBlock":
+			if body.Args[0] == "ok" {
+				w.Write([]byte(`{"uuid": "ok", "content": "target"}`))
+			} else {
+				w.Write([]byte(`null`))
+			}
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+	broken, err := client.FindBrokenBlockRefs()
+	if err != nil {
+		t.Fatalf("FindBrokenBlockRefs failed: %v", err)
+	}
+	if len(broken) != 2 {
+		t.Fatalf("Expected 2 broken refs, got %+v", broken)
+	}
+	if broken[0].BlockUUID != "b1" || broken[0].Page != "p1" || broken[0].Ref != "gone" {
+		t.Errorf("Unexpected broken ref: %+v", broken[0])
+	}
+	if broken[1].BlockUUID != "b2" || broken[1].Ref != "gone" {
+		t.Errorf("Unexpected broken ref: %+v", broken[1])
+	}
+}
+
+func TestClient_ValidateGraph(t *testing.T) {
+	getPageCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.DB.q":
+			query := body.Args[0].(string)
+			switch {
+			case strings.Contains(query, `"(("`):
+				w.Write([]byte(`[[{"uuid": "b1", "content": "see ((ok)) and ((gone))", "page": {"name": "p1"}}]]`))
+			case strings.Contains(query, `"[["`):
+				w.Write([]byte(`[[{"uuid": "b2", "content": "[[Existing]] [[Missing]] [[Other Missing]]", "page": {"name": "p2"}}], [{"uuid": "b3", "content": "[[missing]] again", "page": {"name": "p3"}}]]`))
+			case strings.Contains(query, ":block/original-name"):
+				w.Write([]byte(`[[{"uuid": "a", "name": "a"}], [{"uuid": "ab", "name": "a/b", "originalName": "A/B"}], [{"uuid": "xy", "name": "x/y", "originalName": "X/Y"}], [{"uuid": "xyz", "name": "x/y/z"}], [{"uuid": "e1", "name": "existing", "originalName": "Existing"}]]`))
+			default:
+				w.Write([]byte(`[]`))
+			}
+		case "logseq.Editor.getBlock":
+			if body.Args[0] == "ok" {
+				w.Write([]byte(`{"uuid": "ok", "content": "target"}`))
+			} else {
+				w.Write([]byte(`null`))
+			}
+		case "logseq.Editor.getPage":
+			getPageCalls++
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	report, err := client.ValidateGraph(1)
+	if err != nil {
+		t.Fatalf("ValidateGraph failed: %v", err)
+	}
+	if report.Valid {
+		t.Errorf("Expected an invalid graph")
+	}
+	if report.BrokenBlockRefCount != 1 || len(report.BrokenBlockRefs) != 1 || report.BrokenBlockRefs[0].Ref != "gone" {
+		t.Errorf("Unexpected broken refs: %d %+v", report.BrokenBlockRefCount, report.BrokenBlockRefs)
+	}
+	// Missing is reported once per referencing block, Existing never
+	if report.MissingPageCount != 3 || len(report.MissingPages) != 1 || report.MissingPages[0].Link != "Missing" || report.MissingPages[0].Page != "p2" {
+		t.Errorf("Unexpected missing pages: %d %+v", report.MissingPageCount, report.MissingPages)
+	}
+	if getPageCalls != 0 {
+		t.Errorf("Expected links to be checked against the page scan, got %d getPage calls", getPageCalls)
+	}
+	if report.OrphanedChildCount != 1 || report.OrphanedChildren[0].Name != "X/Y" || report.OrphanedChildren[0].MissingParent != "X" {
+		t.Errorf("Unexpected orphaned namespace children: %d %+v", report.OrphanedChildCount, report.OrphanedChildren)
+	}
+}
+
+func TestClient_StripBlockRefs(t *testing.T) {
+	var updated string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			w.Write([]byte(`{"uuid": "b1", "content": "see ((gone)) here"}`))
+		case "logseq.Editor.updateBlock":
+			updated = body.Args[1].(string)
+			w.Write([]byte(`{"uuid": "b1", "content": "see here"}`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+	if err := client.StripBlockRefs("b1", []string{"gone"}); err != nil {
+		t.Fatalf("StripBlockRefs failed: %v", err)
+	}
+	if updated != "see here" {
+		t.Errorf("Expected stripped content 'see here', got %q", updated)
+	}
+}
+
+func TestClient_Call_Headers(t *testing.T) {
+	var userAgent, requestID string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		requestID = r.Header.Get("X-Request-ID")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithUserAgent("yalms/1.2.3"))
+	if _, err := client.Call("method"); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if userAgent != "yalms/1.2.3" {
+		t.Errorf("Expected User-Agent yalms/1.2.3, got %q", userAgent)
+	}
+	if len(requestID) != 8 {
+		t.Errorf("Expected 8 character X-Request-ID, got %q", requestID)
+	}
+}
+
+func TestClient_ImportCSV(t *testing.T) {
+	created := map[string]map[string]any{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`null`))
+		case "logseq.Editor.createPage":
+			name := body.Args[0].(string)
+			created[name] = body.Args[1].(map[string]any)
+			w.Write([]byte(`{"uuid": "u1", "name": "` + name + `"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	data := "Name;Home Town;Quote\nAlice;Paris;\"Hello; world\"\n;Nowhere;x\nBob;;y\n"
+	result, err := client.ImportCSV(data, logseq.CSVImportOptions{
+		Namespace:  "Person",
+		NameColumn: "Name",
+		Delimiter:  ';',
+	})
+	if err != nil {
+		t.Fatalf("ImportCSV failed: %v", err)
+	}
+	if result.Created != 2 {
+		t.Errorf("Expected 2 created rows, got %d", result.Created)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Row != 3 {
+		t.Errorf("Expected one error on row 3, got %+v", result.Errors)
+	}
+	alice := created["Person/Alice"]
+	if alice["Home Town"] != "Paris" || alice["Quote"] != "Hello; world" {
+		t.Errorf("Unexpected properties for Alice: %v", alice)
+	}
+	if _, ok := created["Person/Bob"]["Home Town"]; ok {
+		t.Errorf("Expected empty cells to be skipped, got %v", created["Person/Bob"])
+	}
+
+	if _, err := client.ImportCSV("a,b\n1,2\n", logseq.CSVImportOptions{NameColumn: "Name"}); err == nil {
+		t.Error("Expected error for missing name column")
+	}
+
+	// Malformed rows become row errors instead of aborting the import
+	created = map[string]map[string]any{}
+	result, err = client.ImportCSV("Name,Town\nCarol,Rome\nDan,Oslo,extra\nEve,\"Lima\n", logseq.CSVImportOptions{NameColumn: "Name"})
+	if err != nil {
+		t.Fatalf("ImportCSV failed on malformed rows: %v", err)
+	}
+	if result.Created != 1 || len(result.Errors) != 2 || result.Errors[0].Row != 3 {
+		t.Errorf("Expected Carol to be created and row errors for Dan (line 3) and Eve, got %+v", result)
+	}
+}
+
+func TestClient_ImportCSV_Existing(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			if body.Args[0] == "Alice" {
+				w.Write([]byte(`{"uuid": "a1", "name": "alice"}`))
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.createPage":
+			fmt.Fprintf(w, `{"uuid": "u1", "name": %q}`, body.Args[0])
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	result, err := client.ImportCSV("Name,Town\nAlice,Paris\nBob,Rome\n", logseq.CSVImportOptions{NameColumn: "Name"})
+	if err != nil {
+		t.Fatalf("ImportCSV failed: %v", err)
+	}
+	if result.Created != 1 || result.Existed != 1 {
+		t.Errorf("Expected 1 created and 1 existing page, got %+v", result)
+	}
+}
+
+func TestClient_GetNamespaceTree(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query, _ := body.Args[0].(string)
+		switch {
+		case strings.Contains(query, `"a/b/c"`):
+			// Cycle back to an already visited page
+			w.Write([]byte(`[[{"uuid": "ua", "name": "a"}]]`))
+		case strings.Contains(query, `"a/b"`):
+			w.Write([]byte(`[[{"uuid": "uc", "name": "a/b/c"}]]`))
+		case strings.Contains(query, `"a"`):
+			w.Write([]byte(`[[{"uuid": "ub", "name": "a/b"}], [{"uuid": "ud", "name": "a/d"}]]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+	pages, err := client.GetNamespaceTree("A")
+	if err != nil {
+		t.Fatalf("GetNamespaceTree failed: %v", err)
+	}
+	if len(pages) != 3 {
+		t.Fatalf("Expected 3 descendants, got %+v", pages)
+	}
+	expected := map[string]int{"a/b": 1, "a/d": 1, "a/b/c": 2}
+	for _, p := range pages {
+		if expected[p.Name] != p.Depth {
+			t.Errorf("Page %s: expected depth %d, got %d", p.Name, expected[p.Name], p.Depth)
+		}
+	}
+}
+
+func TestClient_GetBlockChildren(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Args[0] {
+		case "parent":
+			w.Write([]byte(`{"uuid": "parent", "children": [{"uuid": "c1", "content": "one"}, ["uuid", "c2"]]}`))
+		case "c2":
+			w.Write([]byte(`{"uuid": "c2", "content": "two"}`))
+		case "leaf":
+			w.Write([]byte(`{"uuid": "leaf", "content": "no children"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	children, err := client.GetBlockChildren("parent")
+	if err != nil {
+		t.Fatalf("GetBlockChildren failed: %v", err)
+	}
+	if len(children) != 2 || children[0].Content != "one" || children[1].Content != "two" {
+		t.Errorf("Unexpected children: %+v", children)
+	}
+
+	leaf, err := client.GetBlockChildren("leaf")
+	if err != nil || leaf == nil || len(leaf) != 0 {
+		t.Errorf("Expected empty slice for leaf block, got %v (err: %v)", leaf, err)
+	}
+
+	if _, err := client.GetBlockChildren("missing"); err == nil {
+		t.Error("Expected error for missing block")
+	}
+}
+
+func TestClient_PageCache(t *testing.T) {
+	getPageCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.getPage" {
+			getPageCalls++
+			w.Write([]byte(`{"uuid": "u1", "name": "p1"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	uncached := logseq.NewClient(ts.URL, "token", nil)
+	for i := 0; i < 3; i++ {
+		uncached.GetPage("P1")
+	}
+	if getPageCalls != 3 {
+		t.Fatalf("Expected 3 getPage calls without cache, got %d", getPageCalls)
+	}
+
+	getPageCalls = 0
+	cached := logseq.NewClient(ts.URL, "token", nil, logseq.WithPageCache(time.Minute))
+	cached.GetPage("P1")
+	cached.GetPage("p1")
+	cached.GetPage("u1")
+	if getPageCalls != 1 {
+		t.Errorf("Expected 1 getPage call with cache, got %d", getPageCalls)
+	}
+
+	// Mutations invalidate the cache
+	cached.UpsertProperty("u1", "k", "v")
+	cached.GetPage("p1")
+	if getPageCalls != 2 {
+		t.Errorf("Expected cache invalidation after mutation, got %d getPage calls", getPageCalls)
+	}
+
+	// So do block writes, which may create the page or change its properties
+	cached.AppendBlockInPage("p1", "text", nil)
+	cached.GetPage("p1")
+	cached.InsertBlock("u1", "text", nil, nil)
+	cached.GetPage("p1")
+	if getPageCalls != 4 {
+		t.Errorf("Expected cache invalidation after block writes, got %d getPage calls", getPageCalls)
+	}
+}
+
+func TestClient_PageCache_BlockRemoval(t *testing.T) {
+	page := `{"uuid": "u1", "name": "p1", "properties": {"type": "book"}}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(page))
+		case "logseq.Editor.removeBlock", "logseq.Editor.moveBlock":
+			// The removed or moved block held the page properties
+			page = `{"uuid": "u1", "name": "p1"}`
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+
+	for _, write := range []func(*logseq.Client) error{
+		func(c *logseq.Client) error { return c.DeleteBlock("b1") },
+		func(c *logseq.Client) error { return c.MoveBlock("b1", "b2", true, false) },
+	} {
+		client := logseq.NewClient(ts.URL, "token", nil, logseq.WithPageCache(time.Minute))
+		page = `{"uuid": "u1", "name": "p1", "properties": {"type": "book"}}`
+		if p, _ := client.GetPage("p1"); p == nil || p.Properties["type"] != "book" {
+			t.Fatalf("Expected the page properties, got %+v", p)
+		}
+		write(client)
+		if p, _ := client.GetPage("p1"); p == nil || len(p.Properties) != 0 {
+			t.Errorf("Expected fresh page properties after the block write, got %+v", p)
+		}
+	}
+}
+
+func TestClient_PageCache_Copies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"uuid": "u1", "name": "p1", "properties": {"type": "book"}}`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithPageCache(time.Minute))
+
+	first, _ := client.GetPage("p1")
+	first.Properties["type"] = "changed"
+	second, _ := client.GetPage("p1")
+	second.Properties["status"] = "done"
+
+	page, _ := client.GetPage("p1")
+	if page.Properties["type"] != "book" || len(page.Properties) != 1 {
+		t.Errorf("Expected the cached page to be unaffected by callers, got %v", page.Properties)
+	}
+}
+
+func TestClient_PageCache_Concurrent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"uuid": "u1", "name": "p1"}`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithPageCache(time.Minute))
+
+	// Run with -race: lookups and invalidations share the cache map
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				client.GetPage("p1")
+			} else {
+				client.UpsertProperty("u1", "k", "v")
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestClient_FindPageByUniqueProperty(t *testing.T) {
+	response := `[]`
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query, _ = body.Args[0].(string)
+		w.Write([]byte(response))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	page, err := client.FindPageByUniqueProperty("isbn", "123")
+	if err != nil || page != nil {
+		t.Errorf("Expected no match, got %v (err: %v)", page, err)
+	}
+	if !strings.Contains(query, `(get ?props :isbn)`) || !strings.Contains(query, `"123"`) {
+		t.Errorf("Unexpected query: %s", query)
+	}
+
+	response = `[[{"uuid": "u1", "name": "book"}]]`
+	page, err = client.FindPageByUniqueProperty("isbn", "123")
+	if err != nil || page == nil || page.UUID != "u1" {
+		t.Errorf("Expected single match, got %v (err: %v)", page, err)
+	}
+
+	response = `[[{"uuid": "u1", "name": "a"}], [{"uuid": "u2", "name": "b"}]]`
+	_, err = client.FindPageByUniqueProperty("isbn", "123")
+	if !errors.Is(err, logseq.ErrAmbiguousMatch) {
+		t.Errorf("Expected ErrAmbiguousMatch, got %v", err)
+	}
+
+	query = ""
+	_, err = client.FindPageByUniqueProperty("isbn) [?p :block/name ?n", "123")
+	if !errors.Is(err, logseq.ErrInvalidPropertyKey) || query != "" {
+		t.Errorf("Expected a key that is not a keyword to be rejected before querying, got %v (query: %s)", err, query)
+	}
+
+	response = `[[{"uuid": "u1", "name": "a"}]]`
+	pages, err := client.FindPagesWithProperty("ISBN")
+	if err != nil || len(pages) != 1 || !strings.Contains(query, `[(get ?props :isbn)]`) {
+		t.Errorf("Expected any page with the key to match, got %v (err: %v, query: %s)", pages, err, query)
+	}
+	if _, err := client.FindPagesWithProperty(`isbn "x"`); !errors.Is(err, logseq.ErrInvalidPropertyKey) {
+		t.Errorf("Expected ErrInvalidPropertyKey, got %v", err)
+	}
+}
+
+func TestClient_AddTags(t *testing.T) {
+	updates := 0
+	var updated string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			w.Write([]byte(`{"uuid": "b1", "content": "Alice #Person"}`))
+		case "logseq.Editor.updateBlock":
+			updates++
+			updated = body.Args[1].(string)
+			w.Write([]byte(`{"uuid": "b1", "content": "updated"}`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	if err := client.AddTags("b1", []string{"Person", "#Author", "Editor"}); err != nil {
+		t.Fatalf("AddTags failed: %v", err)
+	}
+	if updates != 1 || updated != "Alice #Person #Author #Editor" {
+		t.Errorf("Expected a single update with missing tags, got %d updates: %q", updates, updated)
+	}
+
+	if err := client.AddTags("b1", []string{"Person"}); err != nil || updates != 1 {
+		t.Errorf("Expected no update when all tags are present, got %d updates (err: %v)", updates, err)
+	}
+}
+
+func TestClient_AddTags_WholeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"longer tag present", "Alice #foobar", "Alice #foobar #foo"},
+		{"tag in inline code", "Alice `#foo`", "Alice `#foo` #foo"},
+		{"tag in code fence", "```\n#foo\n```", "```\n#foo\n``` #foo"},
+		{"present in other case", "Alice #Foo", ""},
+		{"present as bracketed tag", "Alice #[[foo]]", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				var body struct {
+					Method string `json:"method"`
+					Args   []any  `json:"args"`
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+				switch body.Method {
+				case "logseq.Editor.getBlock":
+					json.NewEncoder(w).Encode(map[string]any{"uuid": "b1", "content": tt.content})
+				case "logseq.Editor.updateBlock":
+					updated = body.Args[1].(string)
+					w.Write([]byte(`{"uuid": "b1", "content": "updated"}`))
+				}
+			}))
+			defer ts.Close()
+			client := logseq.NewClient(ts.URL, "token", nil)
+
+			if err := client.AddTags("b1", []string{"foo"}); err != nil || updated != tt.want {
+				t.Errorf("Expected update %q, got %q (err: %v)", tt.want, updated, err)
+			}
+		})
+	}
+}
+
+func TestClient_RemoveTags(t *testing.T) {
+	updates := 0
+	var updated string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			w.Write([]byte(`{"uuid": "b1", "content": "Alice #Person #Author text"}`))
+		case "logseq.Editor.updateBlock":
+			updates++
+			updated = body.Args[1].(string)
+			w.Write([]byte(`{"uuid": "b1", "content": "updated"}`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	removed, err := client.RemoveTags("b1", []string{"#Person", "Author", "Missing"})
+	if err != nil {
+		t.Fatalf("RemoveTags failed: %v", err)
+	}
+	if removed != 2 || updates != 1 || updated != "Alice text" {
+		t.Errorf("Expected 2 removed in one update, got %d removed, %d updates: %q", removed, updates, updated)
+	}
+
+	removed, err = client.RemoveTags("b1", []string{"Missing"})
+	if err != nil || removed != 0 || updates != 1 {
+		t.Errorf("Expected no-op for missing tags, got %d removed, %d updates (err: %v)", removed, updates, err)
+	}
+}
+
+func TestClient_RemoveTags_WholeTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"longer tag kept", "Alice #foo #foobar", "Alice #foobar"},
+		{"bracketed tag", "Alice #[[foo]] text", "Alice text"},
+		{"tag at line start", "#foo Alice\nBob", "Alice\nBob"},
+		{"code fence untouched", "#foo list\n```\nif x {\n    y  = 1\n}\n```", "list\n```\nif x {\n    y  = 1\n}\n```"},
+		{"inline code untouched", "Alice `#foo` #foo text", "Alice `#foo` text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				var body struct {
+					Method string `json:"method"`
+					Args   []any  `json:"args"`
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+				switch body.Method {
+				case "logseq.Editor.getBlock":
+					json.NewEncoder(w).Encode(map[string]any{"uuid": "b1", "content": tt.content})
+				case "logseq.Editor.updateBlock":
+					updated = body.Args[1].(string)
+					w.Write([]byte(`{"uuid": "b1", "content": "updated"}`))
+				}
+			}))
+			defer ts.Close()
+			client := logseq.NewClient(ts.URL, "token", nil)
+
+			removed, err := client.RemoveTags("b1", []string{"foo"})
+			if err != nil || removed != 1 || updated != tt.want {
+				t.Errorf("Expected %q, got %q (removed %d, err: %v)", tt.want, updated, removed, err)
+			}
+		})
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"uuid": "b1", "content": "Alice #foobar"}`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+	if removed, err := client.RemoveTags("b1", []string{"foo"}); err != nil || removed != 0 {
+		t.Errorf("Expected #foobar not to count as #foo, got %d removed (err: %v)", removed, err)
+	}
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestClient_GetDailyJournal_Timezone(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.DB.q" {
+			query = body.Args[0].(string)
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+
+	// 2026-01-18 23:30 UTC is already Jan 19th in Berlin-like UTC+1 but still Jan 18th in UTC-5
+	clock := fixedClock(time.Date(2026, 1, 18, 23, 30, 0, 0, time.UTC))
+	tests := []struct {
+		loc      *time.Location
+		expected string
+	}{
+		{time.UTC, "20260118"},
+		{time.FixedZone("UTC+1", 3600), "20260119"},
+		{time.FixedZone("UTC-5", -5*3600), "20260118"},
+	}
+
+	for _, tt := range tests {
+		client := logseq.NewClient(ts.URL, "token", nil, logseq.WithClock(clock), logseq.WithLocation(tt.loc))
+		if _, err := client.GetDailyJournal(); err != nil {
+			t.Fatalf("GetDailyJournal failed: %v", err)
+		}
+		expectedQuery := "[:find (pull ?p [*]) :where [?p :block/journal-day " + tt.expected + "]]"
+		if query != expectedQuery {
+			t.Errorf("%s: expected query %s, got %s", tt.loc, expectedQuery, query)
+		}
+	}
+}
+
+func TestClient_RenameProperty(t *testing.T) {
+	var calls []string
+	props := map[string]any{"published_date": "1937"}
+	failUpsert := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		calls = append(calls, body.Method)
+		switch body.Method {
+		case "logseq.Editor.getBlockProperty":
+			v, _ := json.Marshal(props[body.Args[1].(string)])
+			w.Write(v)
+		case "logseq.Editor.upsertBlockProperty":
+			if failUpsert {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			props[body.Args[1].(string)] = body.Args[2]
+			w.Write([]byte(`null`))
+		case "logseq.Editor.removeBlockProperty":
+			delete(props, body.Args[1].(string))
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	if err := client.RenameProperty("u1", "published_date", "publication_date"); err != nil {
+		t.Fatalf("RenameProperty failed: %v", err)
+	}
+	if props["publication_date"] != "1937" || props["published_date"] != nil {
+		t.Errorf("Expected value moved to new key, got %v", props)
+	}
+	if calls[len(calls)-2] != "logseq.Editor.upsertBlockProperty" || calls[len(calls)-1] != "logseq.Editor.removeBlockProperty" {
+		t.Errorf("Expected upsert before remove, got %v", calls)
+	}
+
+	err := client.RenameProperty("u1", "missing", "other")
+	if !errors.Is(err, logseq.ErrPropertyNotFound) {
+		t.Errorf("Expected ErrPropertyNotFound, got %v", err)
+	}
+
+	props["a"], props["b"] = "1", "2"
+	if err := client.RenameProperty("u1", "a", "b"); err == nil || props["b"] != "2" {
+		t.Errorf("Expected refusal to overwrite existing key, got %v (%v)", err, props)
+	}
+
+	failUpsert = true
+	if err := client.RenameProperty("u1", "a", "c"); err == nil || props["a"] != "1" {
+		t.Errorf("Expected old key kept when upsert fails, got %v (%v)", err, props)
+	}
+}
+
+func TestClient_GetPageSize(t *testing.T) {
+	tree := `[{"uuid": "b1", "content": "hello world", "children": [{"uuid": "b2", "content": "naïve café", "children": [{"uuid": "b3", "content": "x"}]}]}, {"uuid": "b4", "content": ""}]`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			if body.Args[0] == "missing" {
+				w.Write([]byte(`null`))
+				return
+			}
+			w.Write([]byte(fmt.Sprintf(`{"uuid": "%s", "name": "%s"}`, body.Args[0], body.Args[0])))
+		case "logseq.Editor.getPageBlocksTree":
+			if body.Args[0] == "empty" {
+				w.Write([]byte(`null`))
+				return
+			}
+			w.Write([]byte(tree))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	size, err := client.GetPageSize("p1")
+	if err != nil {
+		t.Fatalf("GetPageSize failed: %v", err)
+	}
+	if size.BlockCount != 4 || size.WordCount != 5 || size.CharCount != 22 {
+		t.Errorf("Unexpected size: %+v", size)
+	}
+
+	size, err = client.GetPageSize("empty")
+	if err != nil || *size != (logseq.PageSize{}) {
+		t.Errorf("Expected zeros for empty page, got %+v (%v)", size, err)
+	}
+
+	if _, err := client.GetPageSize("missing"); err == nil {
+		t.Error("Expected error for missing page")
+	}
+}
+
+func TestClient_ApplyTemplate(t *testing.T) {
+	var inserted []any
+	tree := `[{"uuid": "t1", "content": "template:: Meeting\nMeeting on {{date}}", "children": [{"uuid": "t2", "content": "Attendees: {{ people }} {{query todo}} {{missing}}"}]}]`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"uuid": "tp", "name": "meeting template"}`))
+		case "logseq.Editor.getPageBlocksTree":
+			w.Write([]byte(tree))
+		case "logseq.Editor.insertBatchBlock":
+			inserted = body.Args[1].([]any)
+			w.Write([]byte(`[{"uuid": "n1", "content": "Meeting on 2026-01-18"}]`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	result, err := client.ApplyTemplate("Meeting Template", "target", map[string]string{"date": "2026-01-18", "people": "Alice", "unused": "x"})
+	if err != nil {
+		t.Fatalf("ApplyTemplate failed: %v", err)
+	}
+	root := inserted[0].(map[string]any)
+	if root["content"] != "Meeting on 2026-01-18" {
+		t.Errorf("Expected substituted content without template marker, got %q", root["content"])
+	}
+	child := root["children"].([]any)[0].(map[string]any)
+	if child["content"] != "Attendees: Alice {{query todo}} {{missing}}" {
+		t.Errorf("Expected unmatched placeholders left intact, got %q", child["content"])
+	}
+	if strings.Join(result.UsedVariables, ",") != "date,people" || strings.Join(result.UnusedVariables, ",") != "unused" {
+		t.Errorf("Unexpected variable report: %+v", result)
+	}
+}
+
+func TestClient_Call_APIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`Unauthorized`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "bad", nil)
+
+	_, err := client.GetGraph()
+	var apiErr *logseq.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.StatusCode != http.StatusUnauthorized || apiErr.Body != "Unauthorized" || apiErr.Method != "logseq.App.getCurrentGraph" {
+		t.Errorf("Unexpected APIError fields: %+v", apiErr)
+	}
+	if apiErr.Error() != "api error: Unauthorized (status: 401)" {
+		t.Errorf("Unexpected error message: %q", apiErr.Error())
+	}
+	if !logseq.IsUnauthorized(err) {
+		t.Error("Expected IsUnauthorized to be true")
+	}
+	if logseq.IsUnauthorized(&logseq.APIError{StatusCode: http.StatusNotFound}) || logseq.IsUnauthorized(errors.New("x")) {
+		t.Error("Expected IsUnauthorized to be false for other errors")
+	}
+}
+
+func TestClient_GetUserConfigs(t *testing.T) {
+	response := `{"preferredDateFormat": "MMM do, yyyy", "preferredWorkflow": "now"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	configs, err := client.GetUserConfigs()
+	if err != nil {
+		t.Fatalf("GetUserConfigs failed: %v", err)
+	}
+	if configs["preferredDateFormat"] != "MMM do, yyyy" {
+		t.Errorf("Unexpected configs: %v", configs)
+	}
+
+	response = `{"error": "MethodNotExist: get_user_configs"}`
+	if _, err := client.GetUserConfigs(); !errors.Is(err, logseq.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestClient_AppendToJournal(t *testing.T) {
+	var createdName, appendedTo string
+	configs := `{"preferredDateFormat": "MMM do, yyyy"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.App.getUserConfigs":
+			w.Write([]byte(configs))
+		case "logseq.Editor.createPage":
+			createdName = body.Args[0].(string)
+			w.Write([]byte(fmt.Sprintf(`{"uuid": "j1", "name": %q}`, strings.ToLower(createdName))))
+		case "logseq.Editor.appendBlockInPage":
+			appendedTo = body.Args[0].(string)
+			w.Write([]byte(`{"uuid": "b1", "content": "hi"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithClock(fixedClock(time.Date(2026, time.January, 18, 9, 0, 0, 0, time.UTC))), logseq.WithLocation(time.UTC))
+
+	if _, err := client.AppendToJournal(time.Time{}, "hi"); err != nil {
+		t.Fatalf("AppendToJournal failed: %v", err)
+	}
+	if createdName != "Jan 18th, 2026" || appendedTo != "jan 18th, 2026" {
+		t.Errorf("Expected preferred-format journal page, created %q, appended to %q", createdName, appendedTo)
+	}
+
+	configs = `{"error": "MethodNotExist: get_user_configs"}`
+	if _, err := client.AppendToJournal(time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC), "hi"); err != nil {
+		t.Fatalf("AppendToJournal failed: %v", err)
+	}
+	if createdName != "2026-03-05" {
+		t.Errorf("Expected fallback to yyyy-MM-dd, got %q", createdName)
+	}
+}
+
+func TestClient_EnsureJournalPage(t *testing.T) {
+	var existing string
+	var createOptions map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			if existing == "" {
+				w.Write([]byte(`null`))
+				return
+			}
+			w.Write([]byte(existing))
+		case "logseq.Editor.createPage":
+			createOptions, _ = body.Args[2].(map[string]any)
+			fmt.Fprintf(w, `{"uuid": "j1", "name": %q, "journal?": true}`, body.Args[0])
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	page, created, err := client.EnsureJournalPage(time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil || !created || page.Name != "2026-03-05" {
+		t.Fatalf("Expected the journal page to be created, got %+v, %v, %v", page, created, err)
+	}
+	if createOptions["journal"] != true {
+		t.Errorf("Expected the journal option, got %+v", createOptions)
+	}
+
+	createOptions = nil
+	existing = `{"uuid": "j1", "name": "2026-03-05", "journal?": true}`
+	page, created, err = client.EnsureJournalPage(time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil || created || page.UUID != "j1" || createOptions != nil {
+		t.Errorf("Expected the existing journal page to be returned, got %+v, %v, %v", page, created, err)
+	}
+
+	existing = `{"uuid": "p1", "name": "2026-03-05"}`
+	if _, _, err := client.EnsureJournalPage(time.Date(2026, time.March, 5, 0, 0, 0, 0, time.UTC)); !errors.Is(err, logseq.ErrNotAJournalPage) {
+		t.Errorf("Expected ErrNotAJournalPage, got %v", err)
+	}
+}
+
+func TestClient_ReorderBlock(t *testing.T) {
+	order := []string{"c1", "c2", "c3"}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			id := body.Args[0].(string)
+			if id == "parent" {
+				var children []string
+				for _, c := range order {
+					children = append(children, fmt.Sprintf(`{"uuid": %q}`, c))
+				}
+				w.Write([]byte(`{"uuid": "parent", "children": [` + strings.Join(children, ",") + `]}`))
+				return
+			}
+			w.Write([]byte(fmt.Sprintf(`{"uuid": %q, "parent": {"id": 2, "uuid": "parent"}, "page": {"id": 1}}`, id)))
+		case "logseq.Editor.moveBlock":
+			src, target := body.Args[0].(string), body.Args[1].(string)
+			before := body.Args[2].(map[string]any)["before"].(bool)
+			var rest []string
+			for _, c := range order {
+				if c != src {
+					rest = append(rest, c)
+				}
+			}
+			order = nil
+			for _, c := range rest {
+				if c == target && before {
+					order = append(order, src)
+				}
+				order = append(order, c)
+				if c == target && !before {
+					order = append(order, src)
+				}
+			}
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	moved, err := client.ReorderBlock("c3", "up")
+	if err != nil || !moved || strings.Join(order, ",") != "c1,c3,c2" {
+		t.Errorf("Expected c3 moved up, got %v (moved=%v, err=%v)", order, moved, err)
+	}
+	moved, err = client.ReorderBlock("c1", "down")
+	if err != nil || !moved || strings.Join(order, ",") != "c3,c1,c2" {
+		t.Errorf("Expected c1 moved down, got %v (moved=%v, err=%v)", order, moved, err)
+	}
+	moved, err = client.ReorderBlock("c3", "up")
+	if err != nil || moved || strings.Join(order, ",") != "c3,c1,c2" {
+		t.Errorf("Expected no-op for first block, got %v (moved=%v, err=%v)", order, moved, err)
+	}
+	moved, err = client.ReorderBlock("c2", "down")
+	if err != nil || moved {
+		t.Errorf("Expected no-op for last block, got moved=%v, err=%v", moved, err)
+	}
+	if _, err := client.ReorderBlock("c2", "sideways"); err == nil {
+		t.Error("Expected error for invalid direction")
+	}
+}
+
+func TestClient_GetBlockContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		id := body.Args[0].(string)
+		if id == "parent" {
+			var children []string
+			for _, c := range []string{"c1", "c2", "c3", "c4", "c5"} {
+				children = append(children, fmt.Sprintf(`{"uuid": %q, "content": "text %s"}`, c, c))
+			}
+			w.Write([]byte(`{"uuid": "parent", "children": [` + strings.Join(children, ",") + `]}`))
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`{"uuid": %q, "parent": {"id": 2, "uuid": "parent"}, "page": {"id": 1}}`, id)))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	tests := []struct {
+		uuid   string
+		radius int
+		want   string
+	}{
+		{"c3", 1, "c2,c3,c4"},
+		{"c3", 0, "c3"},
+		{"c1", 2, "c1,c2,c3"},
+		{"c5", 1, "c4,c5"},
+		{"c2", 10, "c1,c2,c3,c4,c5"},
+	}
+	for _, tt := range tests {
+		blocks, err := client.GetBlockContext(tt.uuid, tt.radius)
+		if err != nil {
+			t.Fatalf("GetBlockContext(%s, %d) failed: %v", tt.uuid, tt.radius, err)
+		}
+		var got []string
+		for _, b := range blocks {
+			got = append(got, b.UUID)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("GetBlockContext(%s, %d) = %v, want %s", tt.uuid, tt.radius, got, tt.want)
+		}
+	}
+	if blocks, _ := client.GetBlockContext("c3", 1); blocks[0].Content != "text c2" {
+		t.Errorf("Expected sibling content, got %q", blocks[0].Content)
+	}
+	if _, err := client.GetBlockContext("c3", -1); err == nil {
+		t.Error("Expected error for negative radius")
+	}
+}
+
+func TestClient_MoveBlocks(t *testing.T) {
+	var moves []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Args[0] == "bad" {
+			w.Write([]byte(`{"error": "block not found"}`))
+			return
+		}
+		opts := body.Args[2].(map[string]any)
+		moves = append(moves, fmt.Sprintf("%s->%s children=%v before=%v", body.Args[0], body.Args[1], opts["children"], opts["before"]))
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	count, errs := client.MoveBlocks([]logseq.MoveOperation{
+		{UUID: "a", TargetUUID: "b"},
+		{UUID: "bad", TargetUUID: "a"},
+		{UUID: "c", TargetUUID: "a", Sibling: true, Before: true},
+	})
+	if count != 2 || len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "operation 1 (bad)") {
+		t.Errorf("Expected 2 moves and a failure at index 1, got %d, %v", count, errs)
+	}
+	expected := []string{"a->b children=true before=false", "c->a children=false before=true"}
+	if strings.Join(moves, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected moves applied in order %v, got %v", expected, moves)
+	}
+}
+
+func TestClient_GetPages(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Args[0] == "missing" {
+			w.Write([]byte(`null`))
+			return
+		}
+		w.Write([]byte(fmt.Sprintf(`{"uuid": "u-%s", "name": %q}`, body.Args[0], body.Args[0])))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithConcurrency(2))
+
+	names := []string{"a", "missing", "b", "c", "d"}
+	pages, err := client.GetPages(names)
+	if err != nil {
+		t.Fatalf("GetPages failed: %v", err)
+	}
+	if len(pages) != len(names) || pages[1] != nil || pages[0].Name != "a" || pages[4].Name != "d" {
+		t.Errorf("Expected results in input order with nil for missing page, got %v", pages)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 concurrent calls, got %d", maxInFlight)
+	}
+}
+
+func TestClient_GetPageOutline(t *testing.T) {
+	tree := `[{"uuid": "b1", "content": "# Intro\nbody text", "children": [{"uuid": "b2", "content": "Details", "children": [{"uuid": "b3", "content": "Deep"}]}]}, {"uuid": "b4", "content": "Summary"}]`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.getPage" {
+			w.Write([]byte(`{"uuid": "p1", "name": "page"}`))
+			return
+		}
+		w.Write([]byte(tree))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	outline, err := client.GetPageOutline("page", 1)
+	if err != nil {
+		t.Fatalf("GetPageOutline failed: %v", err)
+	}
+	if len(outline) != 2 || outline[0] != (logseq.OutlineEntry{UUID: "b1", Title: "# Intro", Depth: 1}) || outline[1].UUID != "b4" {
+		t.Errorf("Unexpected depth-1 outline: %+v", outline)
+	}
+
+	outline, _ = client.GetPageOutline("page", 2)
+	var uuids []string
+	for _, e := range outline {
+		uuids = append(uuids, fmt.Sprintf("%s@%d", e.UUID, e.Depth))
+	}
+	if strings.Join(uuids, ",") != "b1@1,b2@2,b4@1" {
+		t.Errorf("Unexpected depth-2 outline: %v", uuids)
+	}
+}
+
+func TestClient_CollapsePage(t *testing.T) {
+	tree := `[{"uuid": "b1", "content": "One", "children": [{"uuid": "b2", "content": "Nested"}]}, {"uuid": "b3", "content": "Two"}, {"uuid": "b4", "content": "Three"}]`
+	var toggled []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"uuid": "p1", "name": "page"}`))
+		case "logseq.Editor.getPageBlocksTree":
+			w.Write([]byte(tree))
+		case "logseq.Editor.setBlockCollapsed":
+			toggled = append(toggled, fmt.Sprintf("%v=%v", body.Args[0], body.Args[1]))
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	count, err := client.CollapsePage("page", true, 0)
+	if err != nil {
+		t.Fatalf("CollapsePage failed: %v", err)
+	}
+	if count != 3 || strings.Join(toggled, ",") != "b1=true,b3=true,b4=true" {
+		t.Errorf("Expected one call per top-level block, got %d: %v", count, toggled)
+	}
+
+	toggled = nil
+	count, _ = client.CollapsePage("page", false, 2)
+	if count != 1 || strings.Join(toggled, ",") != "b2=false" {
+		t.Errorf("Expected only depth-2 blocks to be toggled, got %d: %v", count, toggled)
+	}
+}
+
+func TestClient_ReplaceInPage(t *testing.T) {
+	tree := `[{"uuid": "b1", "content": "teh intro", "children": [{"uuid": "b2", "content": "see [[teh page]]"}, {"uuid": "b3", "content": "nested teh"}]}, {"uuid": "b4", "content": "clean"}]`
+	updated := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"uuid": "p1", "name": "page"}`))
+		case "logseq.Editor.getPageBlocksTree":
+			w.Write([]byte(tree))
+		case "logseq.Editor.updateBlock":
+			updated[body.Args[0].(string)] = body.Args[1].(string)
+			w.Write([]byte(`null`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	changed, err := client.ReplaceInPage("page", logseq.ReplaceOptions{Find: "teh", Replace: "the", CaseSensitive: true})
+	if err != nil {
+		t.Fatalf("ReplaceInPage failed: %v", err)
+	}
+	if changed != 2 || len(updated) != 2 || updated["b1"] != "the intro" || updated["b3"] != "nested the" {
+		t.Errorf("Expected b1 and b3 to be updated, got %d: %v", changed, updated)
+	}
+
+	if _, err := client.ReplaceInPage("page", logseq.ReplaceOptions{}); err == nil {
+		t.Error("Expected error for empty search text")
+	}
+}
+
+func TestClient_Aliases(t *testing.T) {
+	alias := `"JRR Tolkien, [[Tolkien]]"`
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"uuid": "p1", "name": "j.r.r. tolkien", "properties": {"alias": ` + alias + `}}`))
+		case "logseq.Editor.upsertBlockProperty":
+			calls = append(calls, fmt.Sprintf("upsert %v=%v", body.Args[1], body.Args[2]))
+			w.Write([]byte(`null`))
+		case "logseq.Editor.removeBlockProperty":
+			calls = append(calls, fmt.Sprintf("remove %v", body.Args[1]))
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	aliases, err := client.AddAlias("J.R.R. Tolkien", "Professor")
+	if err != nil || strings.Join(aliases, "|") != "JRR Tolkien|Tolkien|Professor" {
+		t.Errorf("Expected alias appended, got %v (err=%v)", aliases, err)
+	}
+	if _, err := client.AddAlias("J.R.R. Tolkien", "[[tolkien]]"); err != nil {
+		t.Fatalf("AddAlias failed: %v", err)
+	}
+	if strings.Join(calls, ";") != "upsert alias=JRR Tolkien, Tolkien, Professor" {
+		t.Errorf("Expected one upsert and a no-op for the existing alias, got %v", calls)
+	}
+
+	calls = nil
+	remaining, removed, err := client.RemoveAlias("J.R.R. Tolkien", "tolkien")
+	if err != nil || !removed || strings.Join(remaining, "|") != "JRR Tolkien" || strings.Join(calls, ";") != "upsert alias=JRR Tolkien" {
+		t.Errorf("Expected Tolkien removed, got %v %v (calls %v, err=%v)", remaining, removed, calls, err)
+	}
+
+	alias = `["JRR Tolkien"]`
+	calls = nil
+	remaining, removed, _ = client.RemoveAlias("J.R.R. Tolkien", "JRR Tolkien")
+	if !removed || len(remaining) != 0 || strings.Join(calls, ";") != "remove alias" {
+		t.Errorf("Expected the alias property to be removed, got %v (calls %v)", remaining, calls)
+	}
+	if _, removed, _ := client.RemoveAlias("J.R.R. Tolkien", "Unknown"); removed {
+		t.Error("Expected unknown alias not to be removed")
+	}
+}
+
+func TestClient_ResolvePage_Alias(t *testing.T) {
+	canonical := `[[{"uuid": "p1", "name": "j.r.r. tolkien", "originalName": "J.R.R. Tolkien"}]]`
+	var queries int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.DB.q":
+			queries++
+			if strings.Contains(body.Args[0].(string), `:block/name "tolkien"`) {
+				w.Write([]byte(canonical))
+				return
+			}
+			w.Write([]byte(`[]`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+
+	client := logseq.NewClient(ts.URL, "token", nil)
+	if page, _ := client.GetPage("Tolkien"); page != nil || queries != 0 {
+		t.Errorf("Expected GetPage not to follow aliases, got %+v after %d queries", page, queries)
+	}
+	page, err := client.ResolvePage("Tolkien")
+	if err != nil || page == nil || page.UUID != "p1" {
+		t.Fatalf("Expected the alias to resolve to p1, got %+v (err=%v)", page, err)
+	}
+	if page, _ := client.ResolvePage("Nobody"); page != nil {
+		t.Errorf("Expected unknown name to stay missing, got %+v", page)
+	}
+
+	canonical = `[[{"uuid": "p1", "name": "a"}], [{"uuid": "p2", "name": "b"}]]`
+	if page, _ := client.ResolvePage("Tolkien"); page != nil {
+		t.Errorf("Expected an ambiguous alias not to resolve, got %+v", page)
+	}
+
+	queries = 0
+	client = logseq.NewClient(ts.URL, "token", nil, logseq.WithoutAliasResolution())
+	if page, _ := client.ResolvePage("Tolkien"); page != nil || queries != 0 {
+		t.Errorf("Expected no alias lookup when disabled, got %+v after %d queries", page, queries)
+	}
+}
+
+func TestClient_FindDuplicatePages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"uuid": "p1", "name": "tolkien", "originalName": "Tolkien"},
+			{"uuid": "p2", "name": "tolkien ", "originalName": "tolkien "},
+			{"uuid": "p3", "name": "lewis", "originalName": "Lewis"},
+			{"uuid": "p4", "name": "c.s.  lewis", "originalName": "C.S.  Lewis"},
+			{"uuid": "p5", "name": "c.s. lewis", "originalName": "c.s. Lewis"}
+		]`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	groups, err := client.FindDuplicatePages()
+	if err != nil {
+		t.Fatalf("FindDuplicatePages failed: %v", err)
+	}
+	var got []string
+	for _, group := range groups {
+		var uuids []string
+		for _, p := range group {
+			uuids = append(uuids, p.UUID)
+		}
+		got = append(got, strings.Join(uuids, "+"))
+	}
+	if strings.Join(got, ",") != "p4+p5,p1+p2" {
+		t.Errorf("Expected groups p4+p5 and p1+p2, got %v", got)
+	}
+}
+
+func TestClient_HeavyTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"uuid": "p1", "name": "page"}]`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithTimeout(20*time.Millisecond), logseq.WithHeavyTimeout(2*time.Second))
+
+	if _, err := client.GetPage("page"); err == nil || !strings.Contains(err.Error(), "did not finish within 20ms") {
+		t.Errorf("Expected the regular call to time out, got %v", err)
+	}
+	if _, err := client.CallWithTimeout(20*time.Millisecond, "logseq.Editor.getAllPages"); err == nil {
+		t.Error("Expected CallWithTimeout to honor its deadline")
+	}
+	pages, err := client.ListPages()
+	if err != nil || len(pages) != 1 {
+		t.Errorf("Expected ListPages to use the heavy timeout, got %v (err=%v)", pages, err)
+	}
+}
+
+func TestClient_AppendToBlock(t *testing.T) {
+	existing := "Meeting notes\nstatus:: open"
+	var updated []any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			json.NewEncoder(w).Encode(map[string]any{"uuid": "b1", "content": existing})
+		case "logseq.Editor.updateBlock":
+			updated = body.Args
+			w.Write([]byte(`{"uuid": "b1"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	if _, err := client.AppendToBlock("b1", "Follow-up"); err != nil {
+		t.Fatalf("AppendToBlock failed: %v", err)
+	}
+	if len(updated) != 2 || updated[1] != "Meeting notes\nstatus:: open\nFollow-up" {
+		t.Errorf("Expected content to be appended, got %v", updated)
+	}
+
+	existing = ""
+	client.AppendToBlock("b1", "First line")
+	if updated[1] != "First line" {
+		t.Errorf("Expected no leading newline for empty block, got %q", updated[1])
+	}
+}
+
+func TestClient_PrependToBlock(t *testing.T) {
+	var updated []any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			w.Write([]byte(`{"uuid": "b1", "content": "Call Bob\nstatus:: open"}`))
+		case "logseq.Editor.updateBlock":
+			updated = body.Args
+			w.Write([]byte(`{"uuid": "b1"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	if _, err := client.PrependToBlock("b1", "Agenda", false); err != nil {
+		t.Fatalf("PrependToBlock failed: %v", err)
+	}
+	if updated[1] != "Agenda\nCall Bob\nstatus:: open" {
+		t.Errorf("Expected content on a new first line, got %q", updated[1])
+	}
+
+	client.PrependToBlock("b1", "10:30", true)
+	if updated[1] != "10:30 Call Bob\nstatus:: open" {
+		t.Errorf("Expected content on the same line, got %q", updated[1])
+	}
+}
+
+func TestClient_GetTags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.getBlock" {
+			w.Write([]byte(`{"uuid": "b1", "content": "tags:: [[Book]], Fantasy\nA story #Classic and #[[Must Read]] (#book) see https://x.org/page#anchor", "properties": {"tags": ["book", "fantasy"]}}`))
+			return
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	tags, err := client.GetTags("b1")
+	if err != nil {
+		t.Fatalf("GetTags failed: %v", err)
+	}
+	if got := strings.Join(tags, ","); got != "Book,Fantasy,Classic,Must Read" {
+		t.Errorf("Unexpected tags: %s", got)
+	}
+}
+
+func TestClient_AppendBlockInPage_Idempotent(t *testing.T) {
+	var pageBlocks []map[string]any
+	appends := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPageBlocksTree":
+			json.NewEncoder(w).Encode(pageBlocks)
+		case "logseq.Editor.appendBlockInPage":
+			appends++
+			block := map[string]any{"uuid": fmt.Sprintf("b%d", appends), "content": body.Args[1]}
+			pageBlocks = append(pageBlocks, block)
+			if appends == 1 {
+				// The block was written but the response is lost
+				w.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			json.NewEncoder(w).Encode(block)
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithIdempotentAppend())
+
+	if _, err := client.AppendBlockInPage("page", "Standup notes", nil); err == nil {
+		t.Fatal("Expected the first attempt to fail")
+	}
+	block, err := client.AppendBlockInPage("page", "Standup notes", nil)
+	if err != nil {
+		t.Fatalf("Retried append failed: %v", err)
+	}
+	if appends != 1 || block.UUID != "b1" {
+		t.Errorf("Expected the retry to return the existing block without appending, got %d appends and block %s", appends, block.UUID)
+	}
+
+	if _, err := client.AppendBlockInPage("page", "Different", nil); err != nil || appends != 2 {
+		t.Errorf("Expected a different block to be appended, got %d appends (err: %v)", appends, err)
+	}
+}
+
+func TestClient_OrgFormat(t *testing.T) {
+	var createOptions any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getBlock":
+			w.Write([]byte(`{"uuid": "b1", "content": "#+TAGS: Book, [[Fantasy]]\n:tags: Classic\ntags:: ignored"}`))
+		case "logseq.Editor.createPage":
+			if len(body.Args) > 2 {
+				createOptions = body.Args[2]
+			}
+			w.Write([]byte(`{"uuid": "p1", "name": "new"}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithDefaultFormat(logseq.FormatOrg))
+
+	format, err := client.GetBlockFormat("b1")
+	if err != nil || format != logseq.FormatOrg {
+		t.Errorf("Expected the default format for a block without one, got %q (err: %v)", format, err)
+	}
+
+	tags, _ := client.GetTags("b1")
+	if got := strings.Join(tags, ","); got != "Book,Fantasy,Classic" {
+		t.Errorf("Expected org property lines to be parsed, got %s", got)
+	}
+
+	if _, err := client.CreatePage("New", nil, nil); err != nil {
+		t.Fatalf("CreatePage failed: %v", err)
+	}
+	if opts, _ := createOptions.(map[string]any); opts["format"] != "org" {
+		t.Errorf("Expected new pages to be created as org, got options %v", createOptions)
+	}
+}
+
+func TestClient_SearchAll(t *testing.T) {
+	var queries []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query := body.Args[0].(string)
+		queries = append(queries, query)
+		switch {
+		case strings.Contains(query, ":block/content ?c"):
+			w.Write([]byte(`[[{"uuid": "b1", "content": "Reading Tolkien", "page": {"id": 1, "name": "books"}}], [{"uuid": "b2", "content": "author:: Tolkien", "properties": {"author": "Tolkien"}, "page": {"id": 1, "name": "books"}}]]`))
+		default:
+			w.Write([]byte(`[[{"uuid": "b2", "content": "author:: Tolkien", "properties": {"author": "Tolkien"}, "page": {"id": 1, "name": "books"}}], [{"uuid": "p1", "name": "the hobbit", "properties": {"author": "J.R.R. TOLKIEN"}}], [{"uuid": "b3", "properties": {"tolkien-ref": "no"}, "page": {"id": 2, "name": "misc"}}]]`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	hits, err := client.SearchAll("TOLKIEN", 0)
+	if err != nil {
+		t.Fatalf("SearchAll failed: %v", err)
+	}
+	if len(queries) != 2 || !strings.Contains(queries[0], `"tolkien"`) {
+		t.Errorf("Expected two lower-cased queries, got %v", queries)
+	}
+	var got []string
+	for _, h := range hits {
+		got = append(got, h.UUID+":"+h.MatchType+"@"+h.Page)
+	}
+	if strings.Join(got, ",") != "b1:content@books,b2:content@books,p1:property@the hobbit" {
+		t.Errorf("Unexpected hits: %v", got)
+	}
+
+	hits, _ = client.SearchAll("tolkien", 1)
+	if len(hits) != 1 {
+		t.Errorf("Expected the limit to cap results, got %d", len(hits))
+	}
+}
+
+func TestClient_TLSConfig(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "test", "path": "/path"}`))
+	}))
+	defer ts.Close()
+
+	if _, err := logseq.NewClient(ts.URL, "token", nil).GetGraph(); err == nil {
+		t.Error("Expected the self-signed certificate to be rejected by default")
+	}
+
+	insecure, _ := logseq.NewTLSConfig(true, "")
+	if _, err := logseq.NewClient(ts.URL, "token", nil, logseq.WithTLSConfig(insecure)).GetGraph(); err != nil {
+		t.Errorf("Expected insecure-skip-verify to accept the certificate, got %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600)
+	trusted, err := logseq.NewTLSConfig(false, caFile)
+	if err != nil {
+		t.Fatalf("NewTLSConfig failed: %v", err)
+	}
+	if _, err := logseq.NewClient(ts.URL, "token", nil, logseq.WithTLSConfig(trusted)).GetGraph(); err != nil {
+		t.Errorf("Expected the CA file to be trusted, got %v", err)
+	}
+
+	os.WriteFile(caFile, []byte("not a certificate"), 0o600)
+	if _, err := logseq.NewTLSConfig(false, caFile); err == nil {
+		t.Error("Expected an error for a CA file without certificates")
+	}
+}
+
+func TestClient_CountQuery(t *testing.T) {
+	response := `[[42]]`
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query = body.Args[0].(string)
+		w.Write([]byte(response))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	for resp, want := range map[string]int{`[[42]]`: 42, `7`: 7, `[]`: 0} {
+		response = resp
+		n, err := client.CountQuery("[:find (count ?p) :where [?p :block/name]]")
+		if err != nil || n != want {
+			t.Errorf("CountQuery with response %s = %d, %v; want %d", resp, n, err, want)
+		}
+	}
+
+	response = `[[{"uuid": "p1"}]]`
+	if _, err := client.CountQuery("[:find (pull ?p [*]) :where [?p :block/name]]"); err == nil {
+		t.Error("Expected an error for a non-aggregate query")
+	}
+
+	response = `[[3]]`
+	if n, _ := client.CountPagesByTag("#Book"); n != 3 || !strings.Contains(query, "count-distinct") || !strings.Contains(query, `"book"`) {
+		t.Errorf("Unexpected tag count %d for query %s", n, query)
+	}
+	if n, _ := client.CountPagesByProperty("status", "done"); n != 3 || !strings.Contains(query, ":status") || !strings.Contains(query, `"done"`) {
+		t.Errorf("Unexpected property count %d for query %s", n, query)
+	}
+	if _, err := client.CountPagesByProperty("status)] [(= 1 1", ""); !errors.Is(err, logseq.ErrInvalidPropertyKey) {
+		t.Errorf("Expected ErrInvalidPropertyKey, got %v", err)
+	}
+}
+
+func TestClient_ListAllProperties(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Args []any `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		query = body.Args[0].(string)
+		w.Write([]byte(`[
+			[{"id": 1, "properties": {"author": "[[A]]", "year": 1937}}],
+			[{"id": 2, "properties": {"author": "[[B]]", "status": "done"}}],
+			[{"id": 3, "properties": {"year": 1954}}],
+			[{"id": 4, "properties": {"author": "[[C]]"}}],
+			[{"id": 5, "properties": {}}]
+		]`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	properties, err := client.ListAllProperties()
+	if err != nil {
+		t.Fatalf("ListAllProperties failed: %v", err)
+	}
+	expected := []logseq.PropertyInfo{{Key: "author", Count: 3}, {Key: "year", Count: 2}, {Key: "status", Count: 1}}
+	if len(properties) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, properties)
+	}
+	for i := range expected {
+		if properties[i] != expected[i] {
+			t.Errorf("Expected %v at %d, got %v", expected[i], i, properties[i])
+		}
+	}
+	if !strings.Contains(query, ":block/pre-block?") {
+		t.Errorf("Expected pre-blocks to be excluded, got query %s", query)
+	}
+}
+
+func TestClient_ImportPage(t *testing.T) {
+	var createArgs []any
+	var inserted string
+	existing := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			if existing {
+				w.Write([]byte(`{"uuid": "old-uuid", "name": "book"}`))
+				return
+			}
+			w.Write([]byte(`null`))
+		case "logseq.Editor.createPage":
+			createArgs = body.Args
+			w.Write([]byte(`{"uuid": "new-uuid", "name": "book"}`))
+		case "logseq.Editor.insertBatchBlock":
+			batch, _ := json.Marshal(body.Args[1])
+			inserted = string(batch)
+			if existing {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`[{"uuid": "x"}]`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	export := logseq.PageExport{
+		Name:       "Book",
+		Properties: map[string]any{"author": "Tolkien"},
+		Blocks: []logseq.ExportedBlock{
+			{UUID: "b0", Content: "author:: Tolkien", Properties: map[string]any{"author": "Tolkien"}},
+			{UUID: "b1", Content: "Chapter one"},
+		},
+	}
+	_, status, err := client.ImportPage(export, false)
+	if err != nil || status != logseq.ImportCreated {
+		t.Fatalf("ImportPage failed: %s %v", status, err)
+	}
+	props, _ := createArgs[1].(map[string]any)
+	options, _ := createArgs[2].(map[string]any)
+	if props["author"] != "Tolkien" || options["createFirstBlock"] != false {
+		t.Errorf("Expected page properties and createFirstBlock: false, got %v", createArgs)
+	}
+	if strings.Contains(inserted, "author::") || !strings.Contains(inserted, "Chapter one") {
+		t.Errorf("Expected the properties block to be skipped, got %s", inserted)
+	}
+
+	// A journal page from an older dump that listed journalDay among its properties
+	journal := logseq.PageExport{
+		Name:       "Jan 18th, 2026",
+		Journal:    true,
+		Properties: map[string]any{"journalDay": "2026-01-18", "mood": "calm"},
+		Blocks:     []logseq.ExportedBlock{{UUID: "j0", Content: "Walked"}},
+	}
+	if _, status, err := client.ImportPage(journal, false); err != nil || status != logseq.ImportCreated {
+		t.Fatalf("ImportPage failed for the journal page: %s %v", status, err)
+	}
+	props, _ = createArgs[1].(map[string]any)
+	options, _ = createArgs[2].(map[string]any)
+	if len(props) != 1 || props["mood"] != "calm" || options["journal"] != true {
+		t.Errorf("Expected journalDay not to be written as a property, got %v", createArgs)
+	}
+
+	existing = true
+	_, status, err = client.ImportPage(export, false)
+	if status != logseq.ImportMerged || err == nil || !strings.Contains(err.Error(), "already existed") {
+		t.Errorf("Expected a merge error worded for an existing page, got %s %v", status, err)
+	}
+}
+
+func TestClient_ExportGraph(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+			Args   []any  `json:"args"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Method {
+		case "logseq.Editor.getAllPages":
+			w.Write([]byte(`[{"uuid": "p2", "name": "zeta", "originalName": "Zeta"}, {"uuid": "p1", "name": "alpha", "originalName": "Alpha", "properties": {"type": "book"}}, {"uuid": "j1", "name": "jan 18th, 2026", "journal?": true, "journalDay": 20260118, "properties": {"mood": "calm"}}]`))
+		case "logseq.Editor.getPageBlocksTree":
+			if body.Args[0] == "p1" {
+				w.Write([]byte(`[{"uuid": "b1", "content": "Root", "children": [{"uuid": "b2", "content": "Child", "properties": {"status": "done"}}]}]`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil, logseq.WithConcurrency(2))
+
+	export, err := client.ExportGraph(logseq.ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportGraph failed: %v", err)
+	}
+	if export.Total != 2 || len(export.Pages) != 2 || export.Pages[0].Name != "Alpha" || export.Pages[1].Name != "Zeta" {
+		t.Fatalf("Expected journals to be excluded and pages sorted, got %+v", export)
+	}
+	alpha := export.Pages[0]
+	if alpha.Properties["type"] != "book" || len(alpha.Blocks) != 1 || len(alpha.Blocks[0].Children) != 1 || alpha.Blocks[0].Children[0].Properties["status"] != "done" {
+		t.Errorf("Unexpected page export: %+v", alpha)
+	}
+
+	export, _ = client.ExportGraph(logseq.ExportOptions{IncludeJournals: true, Offset: 1, Limit: 1})
+	if export.Total != 3 || len(export.Pages) != 1 || export.Pages[0].UUID != "j1" {
+		t.Fatalf("Unexpected paged export: %+v", export)
+	}
+	if props := export.Pages[0].Properties; len(props) != 1 || props["mood"] != "calm" {
+		t.Errorf("Expected only the page's own properties, not journalDay, got %v", props)
+	}
+}
+
+func TestClient_EnsurePage_Existing(t *testing.T) {
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		methods = append(methods, body.Method)
+		switch body.Method {
+		case "logseq.Editor.getPage":
+			w.Write([]byte(`{"uuid": "u1", "name": "alice", "properties": {"age": 30}}`))
+		default:
+			w.Write([]byte(`null`))
+		}
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	page, created, err := client.EnsurePage("Alice", map[string]any{"city": "Paris"}, nil)
+	if err != nil || created || page == nil || page.UUID != "u1" {
+		t.Fatalf("Expected existing page, got %v, created=%v, err=%v", page, created, err)
+	}
+	for _, m := range methods {
+		if m == "logseq.Editor.createPage" {
+			t.Error("Existing page must not be recreated")
+		}
+	}
+	if !strings.Contains(strings.Join(methods, ","), "logseq.Editor.upsertBlockProperty") {
+		t.Errorf("Expected supplied properties to be merged, got calls %v", methods)
+	}
+}
+
+func TestClient_EnsurePage_LegacyName(t *testing.T) {
+	exists := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Method == "logseq.Editor.getPage" && exists {
+			w.Write([]byte(`{"uuid": "u1", "name": "a|b"}`))
+			return
+		}
+		w.Write([]byte(`null`))
+	}))
+	defer ts.Close()
+	client := logseq.NewClient(ts.URL, "token", nil)
+
+	// Names are only validated when the page has to be created
+	if page, created, err := client.EnsurePage("a|b", nil, nil); err != nil || created || page.UUID != "u1" {
+		t.Errorf("Expected the existing page with a legacy name, got %v, created=%v, err=%v", page, created, err)
+	}
+	exists = false
+	if _, _, err := client.EnsurePage("a|b", nil, nil); !errors.Is(err, logseq.ErrInvalidPageName) {
+		t.Errorf("Expected ErrInvalidPageName for a new page, got %v", err)
+	}
+}
+
+func TestClient_MaxResponseBytes(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[[{"uuid": "u1", "name": "` + strings.Repeat("x", 200) + `"}]]`))
+	}))
+	defer ts.Close()
+
+	limited := logseq.NewClient(ts.URL, "token", nil, logseq.WithMaxResponseBytes(64))
+	_, err := limited.Call("logseq.DB.q", "[:find (pull ?p [*]) :where [?p :block/name]]")
+	if !errors.Is(err, logseq.ErrResponseTooLarge) {
+		t.Fatalf("Expected ErrResponseTooLarge, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "narrower query") {
+		t.Errorf("Expected hint to narrow the query, got %q", err.Error())
+	}
+
+	unlimited := logseq.NewClient(ts.URL, "token", nil)
+	if _, err := unlimited.Call("logseq.DB.q", "[:find ?p :where [?p :block/name]]"); err != nil {
+		t.Errorf("Expected no limit by default, got %v", err)
+	}
+}
@@ -1,16 +1,21 @@
 package logseq
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // Block represents a Logseq block
 type Block struct {
-	ID         int                    `json:"id"`
-	UUID       string                 `json:"uuid"`
-	Content    string                 `json:"content"`
-	Format     string                 `json:"format"`
-	Left       any            `json:"left"` // minimal representation of ID/UUID or just ID
-	Parent     EntityRef              `json:"parent"` // Can be map or ID
-	Page       EntityRef              `json:"page"`   // Can be map or ID
+	ID         int            `json:"id"`
+	UUID       string         `json:"uuid"`
+	Content    string         `json:"content"`
+	Format     string         `json:"format"`
+	Left       any            `json:"left"`   // minimal representation of ID/UUID or just ID
+	Parent     EntityRef      `json:"parent"` // Can be map or ID
+	Page       EntityRef      `json:"page"`   // Can be map or ID
 	Properties map[string]any `json:"properties,omitempty"`
 	Children   []any          `json:"children,omitempty"` // Can be blocks or uuids depending on depth
 	Refs       []any          `json:"refs,omitempty"`     // References (pages/blocks)
@@ -20,6 +25,7 @@ type Block struct {
 type EntityRef struct {
 	ID   int    `json:"id"`
 	UUID string `json:"uuid"`
+	Name string `json:"name,omitempty"` // Only present when the ref was pulled with its name
 }
 
 func (e *EntityRef) UnmarshalJSON(data []byte) error {
@@ -42,15 +48,22 @@ func (e *EntityRef) UnmarshalJSON(data []byte) error {
 
 // Page represents a Logseq page
 type Page struct {
-	ID           int                    `json:"id"`
-	UUID         string                 `json:"uuid"`
-	Name         string                 `json:"name"`
-	OriginalName string                 `json:"originalName"`
+	ID           int            `json:"id"`
+	UUID         string         `json:"uuid"`
+	Name         string         `json:"name"`
+	OriginalName string         `json:"originalName"`
 	Properties   map[string]any `json:"properties,omitempty"` // Explicit properties field if returned
 	Journal      bool           `json:"journal?"`
+	Depth        int            `json:"depth,omitempty"`     // Only set by GetNamespaceTree (1 = direct child)
+	Format       string         `json:"format,omitempty"`    // "markdown" or "org"
+	CreatedAt    int64          `json:"createdAt,omitempty"` // Epoch milliseconds of creation
+	UpdatedAt    int64          `json:"updatedAt,omitempty"` // Epoch milliseconds of the last edit
 
-	// Capture all other fields as properties
+	// Original values of properties that were normalized (e.g. journal-day ints turned into ISO dates)
 	RawProperties map[string]any `json:"-"`
+
+	// Only the properties object Logseq returned, unlike Properties, which also holds attributes such as journalDay
+	ExplicitProperties map[string]any `json:"-"`
 }
 
 func (p *Page) UnmarshalJSON(data []byte) error {
@@ -66,6 +79,7 @@ func (p *Page) UnmarshalJSON(data []byte) error {
 
 	// Capture explicit properties unmarshaled by json package before we potentially overwrite them
 	explicitProps := p.Properties
+	p.ExplicitProperties = explicitProps
 
 	// Capture raw map to find extra fields
 	var raw map[string]any
@@ -79,7 +93,7 @@ func (p *Page) UnmarshalJSON(data []byte) error {
 		"id": true, "uuid": true, "name": true, "originalName": true, "journal?": true,
 		"properties": true, "left": true, "parent": true, "page": true, "format": true,
 		"children": true, "content": true, "createdAt": true, "updatedAt": true,
-		"file": true, "namespace": true,
+		"file": true, "namespace": true, "depth": true,
 	}
 
 	for k, v := range raw {
@@ -95,9 +109,70 @@ func (p *Page) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	// Expose Logseq date shapes as readable ISO dates, keeping the originals
+	for k, v := range p.Properties {
+		if iso, ok := normalizeDateValue(k, v); ok {
+			if p.RawProperties == nil {
+				p.RawProperties = make(map[string]any)
+			}
+			p.RawProperties[k] = v
+			p.Properties[k] = iso
+		}
+	}
+
 	return nil
 }
 
+// journalDayKeys are the attribute names Logseq uses for journal-day integers
+var journalDayKeys = []string{"journalDay", "journal-day", "journal_day"}
+
+// normalizeDateValue converts Logseq date representations into YYYY-MM-DD.
+// Recognized shapes are journal-day integers (20260118) on date-like keys and
+// page refs carrying a journal day ({"id": 1, "journalDay": 20260118}), alone or in a single-item list.
+func normalizeDateValue(key string, v any) (string, bool) {
+	switch val := v.(type) {
+	case float64:
+		if isDateKey(key) {
+			return journalDayToISO(val)
+		}
+	case map[string]any:
+		for _, k := range journalDayKeys {
+			if day, ok := val[k].(float64); ok {
+				return journalDayToISO(day)
+			}
+		}
+	case []any:
+		if len(val) == 1 {
+			if ref, ok := val[0].(map[string]any); ok {
+				return normalizeDateValue(key, ref)
+			}
+		}
+	}
+	return "", false
+}
+
+func isDateKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, jk := range journalDayKeys {
+		if k == strings.ToLower(jk) {
+			return true
+		}
+	}
+	return strings.Contains(k, "date") || strings.HasSuffix(k, "-day") || strings.HasSuffix(k, "_day") ||
+		k == "scheduled" || k == "deadline"
+}
+
+func journalDayToISO(day float64) (string, bool) {
+	if day != float64(int(day)) || day < 10000101 || day > 99991231 {
+		return "", false
+	}
+	t, err := time.Parse("20060102", strconv.Itoa(int(day)))
+	if err != nil {
+		return "", false
+	}
+	return t.Format("2006-01-02"), true
+}
+
 // GraphInfo represents basic graph information
 type GraphInfo struct {
 	Name string `json:"name"`
@@ -110,3 +185,190 @@ type BlockContent struct {
 	Properties map[string]any `json:"properties,omitempty"`
 	Children   []BlockContent `json:"children,omitempty"`
 }
+
+// MoveOperation describes a single block move for MoveBlocks
+type MoveOperation struct {
+	UUID       string `json:"uuid"`
+	TargetUUID string `json:"target_uuid"`
+	Sibling    bool   `json:"sibling"` // Place next to the target instead of under it
+	Before     bool   `json:"before"`
+}
+
+// OutlineEntry is one line of a page's table of contents
+type OutlineEntry struct {
+	UUID  string `json:"uuid"`
+	Title string `json:"title"` // First line of the block content
+	Depth int    `json:"depth"` // 1 = top-level block
+}
+
+// PageAsset is an asset (image, PDF, media file) referenced from a block of a page
+type PageAsset struct {
+	Ref   string `json:"ref"`            // The reference as written in the block
+	Block string `json:"block"`          // UUID of the first block referencing it
+	Path  string `json:"path,omitempty"` // Absolute path inside the graph; empty for remote URLs or when the graph path is unknown
+}
+
+// GraphExport is a portable dump of pages and their block trees, produced by ExportGraph
+type GraphExport struct {
+	Total int          `json:"total"` // Pages matching the export filter, before offset/limit
+	Pages []PageExport `json:"pages"`
+}
+
+// PageExport is one page of a GraphExport
+type PageExport struct {
+	Name       string          `json:"name"` // Original (display) name
+	UUID       string          `json:"uuid"`
+	Journal    bool            `json:"journal,omitempty"`
+	Properties map[string]any  `json:"properties,omitempty"`
+	Blocks     []ExportedBlock `json:"blocks"`
+}
+
+// ExportedBlock is a block with its nested children in a PageExport
+type ExportedBlock struct {
+	UUID       string          `json:"uuid"`
+	Content    string          `json:"content"`
+	Properties map[string]any  `json:"properties,omitempty"`
+	Children   []ExportedBlock `json:"children,omitempty"`
+}
+
+// ExportOptions selects the pages ExportGraph includes
+type ExportOptions struct {
+	IncludeJournals bool
+	Offset          int
+	Limit           int // 0 means all pages after Offset
+}
+
+// SearchHit is one result of SearchAll
+type SearchHit struct {
+	UUID      string `json:"uuid"`
+	Content   string `json:"content,omitempty"`
+	Page      string `json:"page,omitempty"` // Name of the owning page (the page itself for page properties)
+	MatchType string `json:"match_type"`     // "content" or "property"
+}
+
+// LinkPreview lists the [[linked]] pages of some content, split by whether EnsureLinkedPages would create them
+type LinkPreview struct {
+	Existing    []string `json:"existing"`
+	WouldCreate []string `json:"would_create"` // Includes missing parent namespaces, parents first
+}
+
+// PropertyInfo is a property key in use in the graph and the number of pages and blocks that have it
+type PropertyInfo struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// ReplaceOptions controls a find-and-replace over block content
+type ReplaceOptions struct {
+	Find          string
+	Replace       string
+	CaseSensitive bool
+	WholeWord     bool // Only match where find is not part of a longer word
+	IncludeRefs   bool // Also rewrite text inside [[links]], ((block refs)) and id:: lines
+}
+
+// ReplaceMatch is a block a graph-wide replace would change
+type ReplaceMatch struct {
+	UUID     string `json:"uuid"`
+	Page     string `json:"page,omitempty"`
+	Content  string `json:"content"`
+	Replaced string `json:"replaced"` // The content after the replace
+	Count    int    `json:"count"`    // Number of occurrences replaced
+}
+
+// PageSize summarizes the amount of content on a page
+type PageSize struct {
+	BlockCount int `json:"block_count"`
+	WordCount  int `json:"word_count"`
+	CharCount  int `json:"char_count"`
+}
+
+// TemplateResult describes the blocks created from a template and which variables were substituted
+type TemplateResult struct {
+	Blocks          []Block  `json:"blocks"`
+	UsedVariables   []string `json:"used_variables"`
+	UnusedVariables []string `json:"unused_variables"`
+}
+
+// Task is a block carrying a task marker, as returned by ListTasks
+type Task struct {
+	UUID    string `json:"uuid"`
+	Content string `json:"content"`
+	Page    string `json:"page,omitempty"`
+	Marker  string `json:"marker"`
+}
+
+// AgendaItem is a block scheduled or due on an agenda day
+type AgendaItem struct {
+	UUID    string `json:"uuid"`
+	Content string `json:"content"`
+	Page    string `json:"page,omitempty"`
+	Marker  string `json:"marker,omitempty"`
+	Type    string `json:"type"` // ScheduleScheduled or ScheduleDeadline
+}
+
+// AgendaDay groups the agenda items of one date (YYYY-MM-DD)
+type AgendaDay struct {
+	Date  string       `json:"date"`
+	Items []AgendaItem `json:"items"`
+}
+
+// BrokenRef represents a ((uuid)) block reference whose target block does not exist
+type BrokenRef struct {
+	BlockUUID string `json:"block_uuid"`
+	Page      string `json:"page,omitempty"`
+	Ref       string `json:"ref"`
+}
+
+// MissingPageLink is a [[link]] whose target page does not exist
+type MissingPageLink struct {
+	BlockUUID string `json:"block_uuid"`
+	Page      string `json:"page,omitempty"`
+	Link      string `json:"link"`
+}
+
+// OrphanedNamespaceChild is a namespaced page whose parent namespace page does not exist
+type OrphanedNamespaceChild struct {
+	UUID          string `json:"uuid"`
+	Name          string `json:"name"`
+	MissingParent string `json:"missing_parent"`
+}
+
+// GraphValidationReport is the result of ValidateGraph. Each section carries the full
+// issue count, while the sample lists are capped.
+type GraphValidationReport struct {
+	Valid bool `json:"valid"` // No issues in any section
+
+	BrokenBlockRefCount int         `json:"broken_block_ref_count"`
+	BrokenBlockRefs     []BrokenRef `json:"broken_block_refs"`
+
+	MissingPageCount int               `json:"missing_page_count"`
+	MissingPages     []MissingPageLink `json:"missing_pages"`
+
+	OrphanedChildCount int                      `json:"orphaned_namespace_child_count"`
+	OrphanedChildren   []OrphanedNamespaceChild `json:"orphaned_namespace_children"`
+}
+
+// CSVImportOptions controls how CSV rows are mapped to pages
+type CSVImportOptions struct {
+	Namespace  string
+	NameColumn string
+	Tag        string
+	Delimiter  rune                // Defaults to ',' when zero
+	KeyFunc    func(string) string // Optional property key normalization (e.g. snake_case)
+	ValueFunc  func(any) any       // Optional property value conversion (e.g. CoercePropertyValue)
+}
+
+// CSVRowError describes a CSV row that could not be imported
+type CSVRowError struct {
+	Row   int    `json:"row"` // 1-based line number including the header
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// CSVImportResult summarizes a CSV import
+type CSVImportResult struct {
+	Created int           `json:"created"`
+	Existed int           `json:"existed"` // Rows whose page already existed; their properties were merged
+	Errors  []CSVRowError `json:"errors"`
+}
@@ -72,3 +72,61 @@ func TestModels(t *testing.T) {
 	}
 	// Page struct might not have custom Unmarshal?
 }
+
+func TestModels_Page_NormalizeDates(t *testing.T) {
+	raw := `{
+		"uuid": "u1",
+		"name": "2026-01-18",
+		"journalDay": 20260118,
+		"properties": {
+			"published-date": 19370921,
+			"due": [{"id": 42, "journalDay": 20260201}],
+			"page-count": 20240101,
+			"birth_date": 20261340
+		}
+	}`
+	var p logseq.Page
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	expected := map[string]any{
+		"journalDay":     "2026-01-18",
+		"published-date": "1937-09-21",
+		"due":            "2026-02-01",
+		"page-count":     float64(20240101), // Not a date-like key
+		"birth_date":     float64(20261340), // Not a valid date
+	}
+	for k, want := range expected {
+		if got := p.Properties[k]; got != want {
+			t.Errorf("Properties[%s] = %v, want %v", k, got, want)
+		}
+	}
+	if p.RawProperties["journalDay"] != float64(20260118) {
+		t.Errorf("Expected raw journalDay to be kept, got %v", p.RawProperties["journalDay"])
+	}
+	if _, ok := p.RawProperties["page-count"]; ok {
+		t.Error("Did not expect untouched properties in RawProperties")
+	}
+}
+
+func TestModels_Page_Timestamps(t *testing.T) {
+	raw := `{"uuid": "u1", "name": "page", "createdAt": 1768730400000, "updatedAt": 1768734000000}`
+	var p logseq.Page
+	if err := json.Unmarshal([]byte(raw), &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if p.CreatedAt != 1768730400000 || p.UpdatedAt != 1768734000000 {
+		t.Errorf("Expected timestamps to be populated, got createdAt=%d updatedAt=%d", p.CreatedAt, p.UpdatedAt)
+	}
+	if _, ok := p.Properties["createdAt"]; ok {
+		t.Error("Did not expect createdAt in Properties")
+	}
+
+	out, _ := json.Marshal(p)
+	var roundTrip map[string]any
+	json.Unmarshal(out, &roundTrip)
+	if roundTrip["createdAt"] != float64(1768730400000) || roundTrip["updatedAt"] != float64(1768734000000) {
+		t.Errorf("Expected timestamps in marshaled page, got %s", out)
+	}
+}
@@ -1,8 +1,16 @@
 package logseq
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ExtractLinks finds all [[Page Name]] references in content
@@ -54,7 +62,253 @@ func extractBlockRefs(content string) []string {
 	return refs
 }
 
-// IsJournalName checks if a page name looks like a Logseq journal date
+// tagRe matches #[[Multi Word]] and #word tags; the leading group keeps URL anchors (page#section) from matching
+var tagRe = regexp.MustCompile(`(?:^|[^\w#&/])#(?:\[\[([^\]]+)\]\]|([^\s#.,;:!?()\[\]{}"'` + "`" + `]+))`)
+
+// codeSpanRe matches fenced code blocks and inline code spans, whose # characters are not tags
+var codeSpanRe = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+
+// ExtractTags finds all #tag and #[[Multi Word]] references in content, ignoring code spans
+// Returns a slice of unique tag names found (compared case-insensitively)
+func ExtractTags(content string) []string {
+	content = codeSpanRe.ReplaceAllString(content, " ")
+	matches := tagRe.FindAllStringSubmatch(content, -1)
+
+	unique := make(map[string]bool)
+	var tags []string
+
+	for _, match := range matches {
+		name := strings.TrimSpace(match[1])
+		if name == "" {
+			name = match[2]
+		}
+		if strings.HasPrefix(name, "+") {
+			continue // Org keyword such as #+TITLE:
+		}
+		if name != "" && !unique[strings.ToLower(name)] {
+			unique[strings.ToLower(name)] = true
+			tags = append(tags, name)
+		}
+	}
+	return tags
+}
+
+// removeTag deletes every #tag and #[[tag]] reference to tag outside code spans, using the same
+// tokenisation as ExtractTags, and drops one space next to each removed tag. Reports whether any was removed.
+func removeTag(content, tag string) (string, bool) {
+	// Blank out code spans without shifting offsets, so matches index into content
+	masked := codeSpanRe.ReplaceAllStringFunc(content, func(code string) string {
+		return strings.Repeat(" ", len(code))
+	})
+
+	var b strings.Builder
+	last := 0
+	for _, m := range tagRe.FindAllStringSubmatchIndex(masked, -1) {
+		var name string
+		if m[2] >= 0 {
+			name = strings.TrimSpace(content[m[2]:m[3]])
+		} else {
+			name = content[m[4]:m[5]]
+		}
+		if !strings.EqualFold(name, tag) {
+			continue
+		}
+
+		start := m[0] + strings.IndexByte(content[m[0]:m[1]], '#')
+		end := m[1]
+		atLineEnd := end == len(content) || content[end] == '\n'
+		if start > last && content[start-1] == ' ' && (atLineEnd || content[end] == ' ') {
+			start--
+		} else if (start == 0 || content[start-1] == '\n') && end < len(content) && content[end] == ' ' {
+			end++
+		}
+		b.WriteString(content[last:start])
+		last = end
+	}
+	if last == 0 {
+		return content, false
+	}
+	b.WriteString(content[last:])
+	return b.String(), true
+}
+
+// splitPropertyList turns a multi-value property such as alias:: or tags:: into its values,
+// accepting both a list and a comma separated string. [[brackets]] are stripped and duplicates dropped case-insensitively.
+func splitPropertyList(value any) []string {
+	var raw []string
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			raw = append(raw, fmt.Sprint(item))
+		}
+	case string:
+		raw = strings.Split(v, ",")
+	}
+
+	values := []string{}
+	seen := make(map[string]bool)
+	for _, item := range raw {
+		item = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(item), "[["), "]]"))
+		if item != "" && !seen[strings.ToLower(item)] {
+			seen[strings.ToLower(item)] = true
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+var (
+	intValueRe   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+	floatValueRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)\.[0-9]+$`)
+)
+
+// CoercePropertyValue converts a string property value that is a plain bool, integer or decimal
+// (e.g. "true", "1937", "4.5") into that type. Anything else is returned unchanged, including
+// links and tags, numbers with leading zeros or signs ("007", "+1") and integers that overflow int64.
+func CoercePropertyValue(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	switch t := strings.TrimSpace(s); {
+	case strings.EqualFold(t, "true"):
+		return true
+	case strings.EqualFold(t, "false"):
+		return false
+	case intValueRe.MatchString(t):
+		if n, err := strconv.ParseInt(t, 10, 64); err == nil {
+			return n
+		}
+	case floatValueRe.MatchString(t):
+		if f, err := strconv.ParseFloat(t, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// protectedSpanRe matches text ReplaceText leaves alone unless IncludeRefs is set: links, block refs and block ids
+var protectedSpanRe = regexp.MustCompile(`\[\[[^\]]+\]\]|\(\([^)]+\)\)|(?m:^[ \t]*id::.*$)`)
+
+// ReplaceText replaces every occurrence of opts.Find in content with opts.Replace, returning the new content
+// and the number of replacements. The replacement is inserted literally.
+func ReplaceText(content string, opts ReplaceOptions) (string, int) {
+	if opts.Find == "" {
+		return content, 0
+	}
+	pattern := regexp.QuoteMeta(opts.Find)
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re := regexp.MustCompile(pattern)
+
+	var protected [][]int
+	if !opts.IncludeRefs {
+		protected = protectedSpanRe.FindAllStringIndex(content, -1)
+	}
+
+	var res strings.Builder
+	last, count := 0, 0
+	for _, m := range re.FindAllStringIndex(content, -1) {
+		if opts.WholeWord && !isWholeWord(content, m[0], m[1]) {
+			continue
+		}
+		if overlapsAny(protected, m[0], m[1]) {
+			continue
+		}
+		res.WriteString(content[last:m[0]])
+		res.WriteString(opts.Replace)
+		last = m[1]
+		count++
+	}
+	if count == 0 {
+		return content, 0
+	}
+	res.WriteString(content[last:])
+	return res.String(), count
+}
+
+// isWholeWord reports whether content[start:end] is not directly preceded or followed by a word character
+func isWholeWord(content string, start, end int) bool {
+	isWordRune := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	if before, _ := utf8.DecodeLastRuneInString(content[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(content[end:]); end < len(content) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+func overlapsAny(spans [][]int, start, end int) bool {
+	for _, span := range spans {
+		if start < span[1] && span[0] < end {
+			return true
+		}
+	}
+	return false
+}
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID reports whether s has the 36-character hyphenated shape Logseq uses for block and page UUIDs
+func IsUUID(s string) bool {
+	return uuidRe.MatchString(s)
+}
+
+// MaxPageNameLength is the longest page name (in characters) ValidatePageName accepts.
+// Longer names produce file names most filesystems reject.
+const MaxPageNameLength = 200
+
+// ErrInvalidPageName is returned when a page name cannot be used in Logseq
+var ErrInvalidPageName = errors.New("invalid page name")
+
+// forbiddenPageNameChars break link ([[...]]) or macro ({{...}}) syntax, or the page's file name
+const forbiddenPageNameChars = "[]{}|\\"
+
+// ValidatePageName rejects empty names, names containing characters Logseq cannot link to,
+// empty namespace segments (e.g. "a//b") and names longer than MaxPageNameLength.
+func ValidatePageName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidPageName)
+	}
+	for _, r := range name {
+		if strings.ContainsRune(forbiddenPageNameChars, r) {
+			return fmt.Errorf("%w: contains forbidden character '%c'", ErrInvalidPageName, r)
+		}
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%w: contains control character %q", ErrInvalidPageName, r)
+		}
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if strings.TrimSpace(segment) == "" {
+			return fmt.Errorf("%w: '%s' has an empty namespace segment", ErrInvalidPageName, name)
+		}
+	}
+	if n := utf8.RuneCountInString(name); n > MaxPageNameLength {
+		return fmt.Errorf("%w: %d characters exceeds the maximum of %d", ErrInvalidPageName, n, MaxPageNameLength)
+	}
+	return nil
+}
+
+// ErrInvalidPropertyKey is returned when a property key cannot be used as a query keyword
+var ErrInvalidPropertyKey = errors.New("invalid property key")
+
+// propertyKeyRe matches keys that can be spliced into a Datalog query as a :keyword
+var propertyKeyRe = regexp.MustCompile(`^[\p{L}\p{N}_][\p{L}\p{N}_*+!?-]*$`)
+
+// propertyKeyword returns key as the Datalog keyword Logseq stores it under (lower-cased, with a leading colon).
+// Keys that could change the query, such as ones containing spaces, brackets or quotes, are rejected.
+func propertyKeyword(key string) (string, error) {
+	k := strings.ToLower(key)
+	if !propertyKeyRe.MatchString(k) {
+		return "", fmt.Errorf("%w: '%s' may only contain letters, digits and _-*+!?", ErrInvalidPropertyKey, key)
+	}
+	return ":" + k, nil
+}
+
+// IsJournalName checks if a page name looks like a Logseq journal date in one of the numeric default formats.
+// Graphs with another preferred date format should also check JournalNamePattern of that format.
 func IsJournalName(name string) bool {
 	// YYYY-MM-DD
 	re1 := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
@@ -75,3 +329,369 @@ func IsJournalName(name string) bool {
 
 	return false
 }
+
+// TaskStates are the markers set_task_state may apply; "none" removes the marker
+var TaskStates = []string{"TODO", "DOING", "DONE", "NOW", "LATER", "none"}
+
+// OpenTaskStates are the markers of tasks that are not done; ListTasks returns them by default
+var OpenTaskStates = []string{"TODO", "DOING", "NOW", "LATER"}
+
+// taskMarkers are all leading markers Logseq recognizes and that are stripped before applying a new one
+var taskMarkers = []string{"TODO", "DOING", "DONE", "NOW", "LATER", "WAITING", "WAIT", "CANCELED", "CANCELLED", "IN-PROGRESS"}
+
+// IsTaskState reports whether state is one of TaskStates (markers are case-insensitive)
+func IsTaskState(state string) bool {
+	for _, s := range TaskStates {
+		if strings.EqualFold(s, state) {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskMarker returns the leading task marker of content (e.g. "TODO"), or "" if it has none
+func TaskMarker(content string) string {
+	rest := strings.TrimLeft(content, " ")
+	for _, marker := range taskMarkers {
+		if rest == marker || strings.HasPrefix(rest, marker+" ") {
+			return marker
+		}
+	}
+	return ""
+}
+
+// Schedule types accepted by SetSchedule
+const (
+	ScheduleScheduled = "scheduled"
+	ScheduleDeadline  = "deadline"
+)
+
+var scheduleLineRe = map[string]*regexp.Regexp{
+	ScheduleScheduled: regexp.MustCompile(`(?m)^[ \t]*SCHEDULED: <[^>\n]*>[ \t]*$`),
+	ScheduleDeadline:  regexp.MustCompile(`(?m)^[ \t]*DEADLINE: <[^>\n]*>[ \t]*$`),
+}
+
+// SetScheduleLine sets the SCHEDULED: or DEADLINE: timestamp of a block's content, e.g. "SCHEDULED: <2026-01-18 Sun 10:00>".
+// An existing timestamp of the same type is replaced in place; otherwise the line is inserted after the first line.
+// The time of day is only written when withTime is set.
+func SetScheduleLine(content string, scheduleType string, t time.Time, withTime bool) string {
+	stamp := t.Format("2006-01-02 Mon")
+	if withTime {
+		stamp += t.Format(" 15:04")
+	}
+	line := strings.ToUpper(scheduleType) + ": <" + stamp + ">"
+
+	if re, ok := scheduleLineRe[scheduleType]; ok && re.MatchString(content) {
+		return re.ReplaceAllLiteralString(content, line)
+	}
+	first, rest, found := strings.Cut(content, "\n")
+	if !found {
+		return first + "\n" + line
+	}
+	return first + "\n" + line + "\n" + rest
+}
+
+// SetTaskMarker replaces the leading task marker of content with state.
+// A state of "none" only strips the existing marker.
+func SetTaskMarker(content string, state string) string {
+	rest := strings.TrimLeft(content, " ")
+	for _, marker := range taskMarkers {
+		if rest == marker {
+			rest = ""
+			break
+		}
+		if strings.HasPrefix(rest, marker+" ") {
+			rest = strings.TrimLeft(strings.TrimPrefix(rest, marker), " ")
+			break
+		}
+	}
+
+	if strings.EqualFold(state, "none") || state == "" {
+		return rest
+	}
+	if rest == "" {
+		return strings.ToUpper(state)
+	}
+	return strings.ToUpper(state) + " " + rest
+}
+
+var templateVarRe = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\s*\}\}`)
+
+// substituteVariables replaces {{name}} placeholders with values from vars and records the names it used.
+// Placeholders without a value (and Logseq macros like {{query ...}}) are left intact.
+func substituteVariables(content string, vars map[string]string, used map[string]bool) string {
+	return templateVarRe.ReplaceAllStringFunc(content, func(match string) string {
+		name := templateVarRe.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			return match
+		}
+		used[name] = true
+		return value
+	})
+}
+
+// stripTemplateProperties removes the template:: markers so instantiated blocks are not templates themselves
+func stripTemplateProperties(content string, format string) string {
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if _, ok := propertyLineValue(line, "template", format); ok {
+			continue
+		}
+		if _, ok := propertyLineValue(line, "template-including-parent", format); ok {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// Block formats Logseq stores content in
+const (
+	FormatMarkdown = "markdown"
+	FormatOrg      = "org"
+)
+
+// IsBlockFormat reports whether format is FormatMarkdown or FormatOrg
+func IsBlockFormat(format string) bool {
+	return format == FormatMarkdown || format == FormatOrg
+}
+
+// propertyLineValue returns the value of line if it sets the property key in the given format:
+// "key:: value" in Markdown, ":key: value" (property drawer) or "#+key: value" (page property) in Org.
+// Task markers, links and #tags are written the same way in both formats.
+func propertyLineValue(line string, key string, format string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	prefixes := []string{key + "::"}
+	if format == FormatOrg {
+		prefixes = []string{":" + key + ":", "#+" + key + ":"}
+	}
+	for _, prefix := range prefixes {
+		if len(trimmed) >= len(prefix) && strings.EqualFold(trimmed[:len(prefix)], prefix) {
+			return strings.TrimSpace(trimmed[len(prefix):]), true
+		}
+	}
+	return "", false
+}
+
+// DefaultJournalDateFormat is used for journal page names when the graph's preferred format is unknown
+const DefaultJournalDateFormat = "yyyy-MM-dd"
+
+// FormatJournalDate renders t using a Logseq (date-fns style) date format such as "MMM do, yyyy".
+// Supported tokens are yyyy, yy, MMMM, MMM, MM, M, dd, do, d, EEEE and EEE (E, EE); text in single quotes is literal.
+func FormatJournalDate(t time.Time, format string) string {
+	var out strings.Builder
+	scanDateFormat(format, func(token string, literal bool) {
+		if literal {
+			out.WriteString(token)
+			return
+		}
+		switch token {
+		case "yyyy":
+			out.WriteString(strconv.Itoa(t.Year()))
+		case "yy":
+			out.WriteString(fmt.Sprintf("%02d", t.Year()%100))
+		case "MMMM":
+			out.WriteString(t.Month().String())
+		case "MMM":
+			out.WriteString(t.Month().String()[:3])
+		case "MM":
+			out.WriteString(fmt.Sprintf("%02d", int(t.Month())))
+		case "M":
+			out.WriteString(strconv.Itoa(int(t.Month())))
+		case "dd":
+			out.WriteString(fmt.Sprintf("%02d", t.Day()))
+		case "d":
+			out.WriteString(strconv.Itoa(t.Day()))
+		case "do":
+			out.WriteString(ordinal(t.Day()))
+		case "EEEE":
+			out.WriteString(t.Weekday().String())
+		case "E", "EE", "EEE":
+			out.WriteString(t.Weekday().String()[:3])
+		default:
+			out.WriteString(token)
+		}
+	})
+	return out.String()
+}
+
+// JournalNamePattern returns a case-insensitive pattern matching the page names FormatJournalDate
+// produces for format, e.g. "jan 18th, 2026" for "MMM do, yyyy"
+func JournalNamePattern(format string) *regexp.Regexp {
+	var months, shortMonths, days, shortDays []string
+	for m := time.January; m <= time.December; m++ {
+		months = append(months, m.String())
+		shortMonths = append(shortMonths, m.String()[:3])
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		days = append(days, d.String())
+		shortDays = append(shortDays, d.String()[:3])
+	}
+
+	var out strings.Builder
+	out.WriteString("(?i)^")
+	scanDateFormat(format, func(token string, literal bool) {
+		if literal {
+			out.WriteString(regexp.QuoteMeta(token))
+			return
+		}
+		switch token {
+		case "yyyy":
+			out.WriteString(`\d{4}`)
+		case "yy", "MM", "dd":
+			out.WriteString(`\d{2}`)
+		case "M", "d":
+			out.WriteString(`\d{1,2}`)
+		case "do":
+			out.WriteString(`\d{1,2}(?:st|nd|rd|th)`)
+		case "MMMM":
+			out.WriteString("(?:" + strings.Join(months, "|") + ")")
+		case "MMM":
+			out.WriteString("(?:" + strings.Join(shortMonths, "|") + ")")
+		case "EEEE":
+			out.WriteString("(?:" + strings.Join(days, "|") + ")")
+		case "E", "EE", "EEE":
+			out.WriteString("(?:" + strings.Join(shortDays, "|") + ")")
+		default:
+			out.WriteString(regexp.QuoteMeta(token))
+		}
+	})
+	out.WriteString("$")
+	return regexp.MustCompile(out.String())
+}
+
+// scanDateFormat splits a date-fns style format into runs of the letters y, M, d and E (plus "do"),
+// which it passes to fn as tokens, and literal text, including text in single quotes.
+func scanDateFormat(format string, fn func(token string, literal bool)) {
+	runes := []rune(format)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if r == '\'' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			fn(string(runes[i+1:end]), true)
+			i = end + 1
+			continue
+		}
+		if !strings.ContainsRune("yMdE", r) {
+			fn(string(r), true)
+			i++
+			continue
+		}
+
+		n := 1
+		for i+n < len(runes) && runes[i+n] == r {
+			n++
+		}
+		token := string(runes[i : i+n])
+		if r == 'd' && n == 1 && i+1 < len(runes) && runes[i+1] == 'o' {
+			token, n = "do", 2
+		}
+		i += n
+		fn(token, false)
+	}
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// assetRefRe matches asset references in block content: Markdown images (![alt](path)) and
+// the asset macros {{pdf path}}, {{video path}} and {{audio path}}
+var assetRefRe = regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)[^)]*\)|\{\{(?:pdf|video|audio)\s+([^}\s]+)\s*\}\}`)
+
+// ExtractAssetRefs returns the asset paths or URLs referenced in content, in order of appearance and without duplicates
+func ExtractAssetRefs(content string) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, m := range assetRefRe.FindAllStringSubmatch(content, -1) {
+		ref := m[1]
+		if ref == "" {
+			ref = m[2]
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// ErrPathEscapesGraph is returned when an asset reference resolves outside the graph directory
+var ErrPathEscapesGraph = errors.New("path escapes the graph directory")
+
+var assetLinkRe = regexp.MustCompile(`^!?\[[^\]]*\]\(([^)\s]+)[^)]*\)$`)
+
+// ResolveAssetPath turns an asset reference from a block into an absolute path inside graphPath.
+// It accepts a Markdown link (![img](../assets/x.png)), a path relative to the pages directory
+// ("../assets/x.png"), a graph-relative path ("assets/x.png") or a bare asset filename ("x.png").
+func ResolveAssetPath(graphPath string, ref string) (string, error) {
+	if graphPath == "" {
+		return "", errors.New("graph path is unknown")
+	}
+	ref = strings.TrimSpace(ref)
+	if m := assetLinkRe.FindStringSubmatch(ref); m != nil {
+		ref = m[1]
+	}
+	if ref == "" {
+		return "", errors.New("asset path is empty")
+	}
+	if filepath.IsAbs(ref) {
+		return "", fmt.Errorf("%w: %s", ErrPathEscapesGraph, ref)
+	}
+
+	root := filepath.Clean(graphPath)
+	var resolved string
+	switch {
+	case strings.HasPrefix(ref, "../"):
+		// Block links are relative to the pages/ (or journals/) directory
+		resolved = filepath.Join(root, "pages", ref)
+	case !strings.Contains(ref, "/"):
+		resolved = filepath.Join(root, "assets", ref)
+	default:
+		resolved = filepath.Join(root, ref)
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrPathEscapesGraph, ref)
+	}
+	return resolved, nil
+}
+
+// RunBatch calls fn for every index in [0, n) using at most limit workers. The
+// returned slice holds each item's error at its own index so callers can
+// aggregate results in input order. Limits below 1 are treated as 1.
+func RunBatch(n int, limit int, fn func(i int) error) []error {
+	if limit < 1 {
+		limit = 1
+	}
+	errs := make([]error, n)
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
@@ -1,7 +1,12 @@
 package logseq_test
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/clstb/yalms/pkg/logseq"
 )
@@ -32,3 +37,318 @@ func TestExtractLinks(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractTags(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{"#book", []string{"book"}},
+		{"Reading #book and #fantasy", []string{"book", "fantasy"}},
+		{"A #[[Must Read]] novel", []string{"Must Read"}},
+		{"Mixed #book #[[Book Club]]", []string{"book", "Book Club"}},
+		{"Punctuation (#one), #two. #three! #four?", []string{"one", "two", "three", "four"}},
+		{"Namespaced #Project/Alpha tag", []string{"Project/Alpha"}},
+		{"Duplicate #Book and #book", []string{"Book"}},
+		{"Inline `#not-a-tag` code #real", []string{"real"}},
+		{"```\n#include <stdio.h>\n```\n#after", []string{"after"}},
+		{"URL https://example.com/page#section", nil},
+		{"Heading-like ## and lone # sign", nil},
+		{"#+TITLE: Org keyword", nil},
+		{"No tags here", nil},
+	}
+
+	for _, tt := range tests {
+		got := logseq.ExtractTags(tt.content)
+		if len(got) != len(tt.expected) {
+			t.Errorf("ExtractTags(%q) = %v, want %v", tt.content, got, tt.expected)
+			continue
+		}
+		for i, v := range got {
+			if v != tt.expected[i] {
+				t.Errorf("ExtractTags(%q)[%d] = %q, want %q", tt.content, i, v, tt.expected[i])
+			}
+		}
+	}
+}
+
+func TestReplaceText(t *testing.T) {
+	tests := []struct {
+		content  string
+		opts     logseq.ReplaceOptions
+		expected string
+		count    int
+	}{
+		{"teh cat and teh dog", logseq.ReplaceOptions{Find: "teh", Replace: "the", CaseSensitive: true}, "the cat and the dog", 2},
+		{"Teh cat", logseq.ReplaceOptions{Find: "teh", Replace: "the", CaseSensitive: true}, "Teh cat", 0},
+		{"Teh cat", logseq.ReplaceOptions{Find: "teh", Replace: "the"}, "the cat", 1},
+		{"cat catalog bobcat", logseq.ReplaceOptions{Find: "cat", Replace: "dog", WholeWord: true}, "dog catalog bobcat", 1},
+		{"cat_x café-cat", logseq.ReplaceOptions{Find: "cat", Replace: "dog", WholeWord: true}, "cat_x café-dog", 1},
+		{"Alice met [[Alice Smith]] via ((alice-ref))", logseq.ReplaceOptions{Find: "Alice", Replace: "Bob"}, "Bob met [[Alice Smith]] via ((alice-ref))", 1},
+		{"Alice met [[Alice Smith]]", logseq.ReplaceOptions{Find: "Alice", Replace: "Bob", IncludeRefs: true}, "Bob met [[Bob Smith]]", 2},
+		{"a1 text\nid:: a1b2", logseq.ReplaceOptions{Find: "a1", Replace: "x"}, "x text\nid:: a1b2", 1},
+		{"price is $5", logseq.ReplaceOptions{Find: "$5", Replace: "$6 (.*)"}, "price is $6 (.*)", 1},
+		{"unchanged", logseq.ReplaceOptions{Find: "", Replace: "x"}, "unchanged", 0},
+	}
+
+	for _, tt := range tests {
+		got, count := logseq.ReplaceText(tt.content, tt.opts)
+		if got != tt.expected || count != tt.count {
+			t.Errorf("ReplaceText(%q, %+v) = %q, %d, want %q, %d", tt.content, tt.opts, got, count, tt.expected, tt.count)
+		}
+	}
+}
+
+func TestCoercePropertyValue(t *testing.T) {
+	tests := []struct {
+		in   any
+		want any
+	}{
+		{"1937", int64(1937)},
+		{"-42", int64(-42)},
+		{"0", int64(0)},
+		{"4.5", 4.5},
+		{"-0.25", -0.25},
+		{"true", true},
+		{"False", false},
+		{"[[1937]]", "[[1937]]"},
+		{"#42", "#42"},
+		{"[[Alice]], [[Bob]]", "[[Alice]], [[Bob]]"},
+		{"007", "007"},
+		{"+1", "+1"},
+		{"1e3", "1e3"},
+		{"NaN", "NaN"},
+		{"99999999999999999999", "99999999999999999999"},
+		{"1937 edition", "1937 edition"},
+		{42, 42},
+	}
+	for _, tt := range tests {
+		if got := logseq.CoercePropertyValue(tt.in); got != tt.want {
+			t.Errorf("CoercePropertyValue(%#v) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSetTaskMarker(t *testing.T) {
+	tests := []struct {
+		content  string
+		state    string
+		expected string
+	}{
+		{"Write report", "TODO", "TODO Write report"},
+		{"TODO Write report", "DOING", "DOING Write report"},
+		{"DOING Write report", "DOING", "DOING Write report"},
+		{"DONE Write report", "none", "Write report"},
+		{"Write report", "none", "Write report"},
+		{"LATER Write report", "done", "DONE Write report"},
+		{"TODOS are fun", "NOW", "NOW TODOS are fun"},
+		{"WAITING", "TODO", "TODO"},
+	}
+
+	for _, tt := range tests {
+		got := logseq.SetTaskMarker(tt.content, tt.state)
+		if got != tt.expected {
+			t.Errorf("SetTaskMarker(%q, %q) = %q, want %q", tt.content, tt.state, got, tt.expected)
+		}
+		// Applying the same state again must not change anything
+		if again := logseq.SetTaskMarker(got, tt.state); again != got {
+			t.Errorf("SetTaskMarker is not idempotent for %q: %q -> %q", tt.state, got, again)
+		}
+	}
+
+	if logseq.IsTaskState("STARTED") {
+		t.Error("Expected STARTED to be rejected as a task state")
+	}
+}
+
+func TestSetScheduleLine(t *testing.T) {
+	day := time.Date(2026, 1, 18, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		content      string
+		scheduleType string
+		withTime     bool
+		expected     string
+	}{
+		{"TODO write report", logseq.ScheduleScheduled, false, "TODO write report\nSCHEDULED: <2026-01-18 Sun>"},
+		{"TODO write report", logseq.ScheduleDeadline, true, "TODO write report\nDEADLINE: <2026-01-18 Sun 09:30>"},
+		{"TODO write report\npriority:: high", logseq.ScheduleScheduled, false, "TODO write report\nSCHEDULED: <2026-01-18 Sun>\npriority:: high"},
+		{"TODO write report\nSCHEDULED: <2026-01-01 Thu>", logseq.ScheduleScheduled, true, "TODO write report\nSCHEDULED: <2026-01-18 Sun 09:30>"},
+		{"TODO write report\nSCHEDULED: <2026-01-01 Thu>", logseq.ScheduleDeadline, false, "TODO write report\nDEADLINE: <2026-01-18 Sun>\nSCHEDULED: <2026-01-01 Thu>"},
+		{"TODO write report\nDEADLINE: <2026-01-01 Thu>\nSCHEDULED: <2026-01-01 Thu>", logseq.ScheduleDeadline, false, "TODO write report\nDEADLINE: <2026-01-18 Sun>\nSCHEDULED: <2026-01-01 Thu>"},
+	}
+	for _, tt := range tests {
+		if got := logseq.SetScheduleLine(tt.content, tt.scheduleType, day, tt.withTime); got != tt.expected {
+			t.Errorf("SetScheduleLine(%q, %s) = %q, want %q", tt.content, tt.scheduleType, got, tt.expected)
+		}
+	}
+}
+
+func TestFormatJournalDate(t *testing.T) {
+	date := time.Date(2026, time.January, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"yyyy-MM-dd", "2026-01-02"},
+		{"MMM do, yyyy", "Jan 2nd, 2026"},
+		{"EEEE, dd.MM.yyyy", "Friday, 02.01.2026"},
+		{"yyyy/MM/dd", "2026/01/02"},
+		{"MMMM d 'of' yy", "January 2 of 26"},
+	}
+	for _, tt := range tests {
+		if got := logseq.FormatJournalDate(date, tt.format); got != tt.expected {
+			t.Errorf("FormatJournalDate(%q) = %q, want %q", tt.format, got, tt.expected)
+		}
+	}
+
+	for day, expected := range map[int]string{1: "1st", 11: "11th", 12: "12th", 13: "13th", 22: "22nd", 23: "23rd", 31: "31st"} {
+		d := time.Date(2026, time.January, day, 0, 0, 0, 0, time.UTC)
+		if got := logseq.FormatJournalDate(d, "do"); got != expected {
+			t.Errorf("Ordinal for day %d = %q, want %q", day, got, expected)
+		}
+	}
+}
+
+func TestJournalNamePattern(t *testing.T) {
+	date := time.Date(2026, time.January, 2, 0, 0, 0, 0, time.UTC)
+	for _, format := range []string{"yyyy-MM-dd", "MMM do, yyyy", "EEEE, dd.MM.yyyy", "MMMM d 'of' yy"} {
+		re := logseq.JournalNamePattern(format)
+		name := logseq.FormatJournalDate(date, format)
+		// Page names are stored lower-cased
+		if !re.MatchString(name) || !re.MatchString(strings.ToLower(name)) {
+			t.Errorf("Expected %q to match its format %q", name, format)
+		}
+	}
+
+	re := logseq.JournalNamePattern("MMM do, yyyy")
+	for _, name := range []string{"Jan 2, 2026", "Project 2nd, 2026", "Jan 2nd, 2026 notes", "2026-01-02"} {
+		if re.MatchString(name) {
+			t.Errorf("Expected %q not to match MMM do, yyyy", name)
+		}
+	}
+	if re := logseq.JournalNamePattern("dd.MM.yyyy"); re.MatchString("02x01x2026") {
+		t.Error("Expected literal dots to be matched literally")
+	}
+}
+
+func TestIsUUID(t *testing.T) {
+	valid := []string{"6571c5b8-1f2a-4c3d-9e8f-0a1b2c3d4e5f", "6571C5B8-1F2A-4C3D-9E8F-0A1B2C3D4E5F"}
+	invalid := []string{"", "b1", "My Page", "6571c5b8-1f2a-4c3d-9e8f-0a1b2c3d4e5", "6571c5b81f2a4c3d9e8f0a1b2c3d4e5f", "6571c5b8-1f2a-4c3d-9e8f-0a1b2c3d4e5g", " 6571c5b8-1f2a-4c3d-9e8f-0a1b2c3d4e5f"}
+	for _, s := range valid {
+		if !logseq.IsUUID(s) {
+			t.Errorf("IsUUID(%q) = false, want true", s)
+		}
+	}
+	for _, s := range invalid {
+		if logseq.IsUUID(s) {
+			t.Errorf("IsUUID(%q) = true, want false", s)
+		}
+	}
+}
+
+func TestResolveAssetPath(t *testing.T) {
+	graph := "/home/me/graph"
+	tests := []struct {
+		ref      string
+		expected string
+	}{
+		{"![img](../assets/x.png)", "/home/me/graph/assets/x.png"},
+		{"[report](../assets/report.pdf \"title\")", "/home/me/graph/assets/report.pdf"},
+		{"../assets/x.png", "/home/me/graph/assets/x.png"},
+		{"assets/sub/y.png", "/home/me/graph/assets/sub/y.png"},
+		{"x.png", "/home/me/graph/assets/x.png"},
+	}
+	for _, tt := range tests {
+		got, err := logseq.ResolveAssetPath(graph, tt.ref)
+		if err != nil || got != tt.expected {
+			t.Errorf("ResolveAssetPath(%q) = %q, %v; want %q", tt.ref, got, err, tt.expected)
+		}
+	}
+
+	for _, ref := range []string{"../../secret.txt", "assets/../../etc/passwd", "/etc/passwd", "![x](../../../x.png)"} {
+		if _, err := logseq.ResolveAssetPath(graph, ref); !errors.Is(err, logseq.ErrPathEscapesGraph) {
+			t.Errorf("ResolveAssetPath(%q): expected ErrPathEscapesGraph, got %v", ref, err)
+		}
+	}
+}
+
+func TestExtractAssetRefs(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{"![cover](../assets/cover.png)", []string{"../assets/cover.png"}},
+		{"![a](../assets/a.png \"title\") and ![b](https://example.com/b.jpg)", []string{"../assets/a.png", "https://example.com/b.jpg"}},
+		{"{{pdf ../assets/paper.pdf}}\n{{video ../assets/clip.mp4}} {{audio ../assets/talk.mp3 }}", []string{"../assets/paper.pdf", "../assets/clip.mp4", "../assets/talk.mp3"}},
+		{"![x](../assets/x.png) twice ![x](../assets/x.png)", []string{"../assets/x.png"}},
+		{"[link](../assets/doc.pdf) {{embed [[Page]]}} {{query (todo now)}}", nil},
+	}
+	for _, tt := range tests {
+		got := logseq.ExtractAssetRefs(tt.content)
+		if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("ExtractAssetRefs(%q) = %v, want %v", tt.content, got, tt.expected)
+		}
+	}
+}
+
+func TestValidatePageName(t *testing.T) {
+	for _, name := range []string{"The Hobbit", "Book/The Hobbit", "Person/Author/Tolkien", "Jan 18th, 2026", "2026/01/18", "C#", "Über: Ünïcödé"} {
+		if err := logseq.ValidatePageName(name); err != nil {
+			t.Errorf("ValidatePageName(%q) = %v, want nil", name, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{"", "empty"},
+		{"   ", "empty"},
+		{"[[Nested]]", "'['"},
+		{"Curly {x}", "'{'"},
+		{"A|B", "'|'"},
+		{`Back\slash`, `'\'`},
+		{"Line\nbreak", "control character"},
+		{"Book//Hobbit", "empty namespace segment"},
+		{"/Hobbit", "empty namespace segment"},
+		{"Book/", "empty namespace segment"},
+		{strings.Repeat("a", logseq.MaxPageNameLength+1), "exceeds the maximum"},
+	}
+	for _, tt := range tests {
+		err := logseq.ValidatePageName(tt.name)
+		if !errors.Is(err, logseq.ErrInvalidPageName) {
+			t.Errorf("ValidatePageName(%q) = %v, want ErrInvalidPageName", tt.name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("ValidatePageName(%q) = %q, want it to mention %q", tt.name, err, tt.message)
+		}
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	errs := logseq.RunBatch(6, 2, func(i int) error {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		if i%3 == 0 {
+			return fmt.Errorf("item %d", i)
+		}
+		return nil
+	})
+	if peak > 2 {
+		t.Errorf("Expected at most 2 items in flight, got %d", peak)
+	}
+	for i, err := range errs {
+		if (i%3 == 0) != (err != nil) || (err != nil && err.Error() != fmt.Sprintf("item %d", i)) {
+			t.Errorf("Unexpected error at index %d: %v", i, err)
+		}
+	}
+}